@@ -0,0 +1,184 @@
+// Команда eval прогоняет записанные переписки через ИИ-ассистента и сравнивает
+// версии промпта:
+//
+//	go run ./cmd/eval -suite eval/suite.json -config new.json -baseline old.json \
+//		-provider openai -base-url http://localhost:11434/v1 -model llama3
+//
+// По умолчанию используется фейковая модель, которая повторяет записанные ответы
+// независимо от промпта. Она работает без сети, но проверяет только сам набор
+// и код оценки, а не качество промпта, поэтому сравнение с -baseline требует
+// настоящей модели.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+	"github.com/yourusername/uilet/pkg/llm"
+)
+
+type options struct {
+	provider string
+	baseURL  string
+	apiKey   string
+	model    string
+	timeout  time.Duration
+}
+
+func main() {
+	suitePath := flag.String("suite", "eval/suite.json", "набор записанных переписок")
+	configPath := flag.String("config", "", "настройки ассистента (JSON как в PUT /api/whatsapp/ai/config), по умолчанию стандартные")
+	baselinePath := flag.String("baseline", "", "прежние настройки для сравнения, нужна настоящая модель")
+	judge := flag.Bool("judge", false, "дополнительно оценивать ответы моделью-судьёй")
+	outPath := flag.String("out", "", "сохранить отчёт в JSON")
+
+	var opts options
+	flag.StringVar(&opts.provider, "provider", getEnv("LLM_PROVIDER", "fake"), "fake или openai (любой OpenAI-совместимый сервер)")
+	flag.StringVar(&opts.baseURL, "base-url", getEnv("LLM_BASE_URL", llm.DefaultBaseURL), "адрес OpenAI-совместимого API")
+	flag.StringVar(&opts.apiKey, "api-key", getEnv("LLM_API_KEY", os.Getenv("OPENAI_API_KEY")), "ключ API")
+	flag.StringVar(&opts.model, "model", getEnv("LLM_MODEL", llm.DefaultModel), "модель по умолчанию")
+	flag.DurationVar(&opts.timeout, "timeout", llm.DefaultTimeout, "таймаут запроса к модели")
+	flag.Parse()
+
+	suite, err := service.LoadEvalSuite(*suitePath)
+	if err != nil {
+		log.Fatalf("Error loading suite: %v", err)
+	}
+
+	candidate, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	// Фейковая модель ответит обеим версиям одинаково, и сравнение всегда будет ничьей
+	if *baselinePath != "" && opts.provider == "fake" {
+		log.Fatalf("Comparing with -baseline needs a real model: the fake provider replays the same recorded replies for both configs, use -provider openai")
+	}
+
+	ctx := context.Background()
+	var report interface{}
+	regressed := false
+
+	candidateRun := run(ctx, opts, suite, candidate, labelOf(*configPath, "candidate"), *judge)
+	if *baselinePath == "" {
+		printRun(candidateRun)
+		report = candidateRun
+	} else {
+		baseline, err := loadConfig(*baselinePath)
+		if err != nil {
+			log.Fatalf("Error loading baseline: %v", err)
+		}
+		baselineRun := run(ctx, opts, suite, baseline, labelOf(*baselinePath, "baseline"), *judge)
+
+		diff := service.CompareEvalRuns(baselineRun, candidateRun)
+		printDiff(diff)
+		report = diff
+		for _, c := range diff.Cases {
+			if len(c.Regressed) > 0 {
+				regressed = true
+			}
+		}
+	}
+
+	if *outPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding report: %v", err)
+		}
+		if err := os.WriteFile(*outPath, data, 0644); err != nil {
+			log.Fatalf("Error writing report: %v", err)
+		}
+	}
+
+	if regressed {
+		os.Exit(1)
+	}
+}
+
+// run прогоняет набор со свежей моделью, чтобы фейковая модель отвечала с начала
+func run(ctx context.Context, opts options, suite *model.EvalSuite, config *model.AIConfig, label string, judge bool) *model.EvalRun {
+	var judgeLLM llm.LLM
+	if judge {
+		judgeLLM = newLLM(opts, nil)
+	}
+
+	runner := service.NewEvalRunner(newLLM(opts, suite), judgeLLM)
+	result := runner.Run(ctx, suite, config, label)
+	if result.Model == "" {
+		result.Model = opts.model
+		if opts.provider == "fake" {
+			result.Model = "fake"
+		}
+	}
+	return result
+}
+
+// newLLM создаёт модель. Фейковой модели для прогона набора заранее
+// выдаются записанные вызовы инструментов и ответы переписок по порядку.
+func newLLM(opts options, suite *model.EvalSuite) llm.LLM {
+	if opts.provider != "fake" {
+		return llm.NewOpenAI(llm.OpenAIConfig{
+			BaseURL: opts.baseURL,
+			APIKey:  opts.apiKey,
+			Model:   opts.model,
+			Timeout: opts.timeout,
+		})
+	}
+
+	fake := llm.NewFake()
+	if suite != nil {
+		for _, c := range suite.Cases {
+			if len(c.RecordedTools) > 0 {
+				var calls []llm.ToolCall
+				for i, tool := range c.RecordedTools {
+					calls = append(calls, llm.ToolCall{ID: fmt.Sprintf("%s-%d", c.ID, i), Name: tool.Name, Arguments: string(tool.Arguments)})
+				}
+				fake.Script(llm.Response{ToolCalls: calls})
+			}
+
+			reply := c.RecordedReply
+			if reply == "" {
+				reply = c.Turns[len(c.Turns)-1].Content
+			}
+			fake.Script(llm.Response{Content: reply})
+		}
+	}
+	return fake
+}
+
+func loadConfig(path string) (*model.AIConfig, error) {
+	config := model.DefaultAIConfig(0)
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+func labelOf(path, fallback string) string {
+	if path == "" {
+		return fallback
+	}
+	return path
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
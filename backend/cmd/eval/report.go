@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+const maxReplyPreview = 200
+
+func printRun(run *model.EvalRun) {
+	fmt.Printf("# %s: набор %s, модель %s\n\n", run.Label, run.SuiteVersion, run.Model)
+	fmt.Printf("Итоговая оценка: %.0f%%\n\n", run.Score*100)
+
+	for _, result := range run.Results {
+		fmt.Printf("## %s — %.0f%%\n", result.CaseID, result.Score*100)
+		if result.Error != "" {
+			fmt.Printf("Ошибка: %s\n\n", result.Error)
+			continue
+		}
+		fmt.Printf("> %s\n", preview(result.Reply))
+		for _, check := range result.Checks {
+			mark := "✓"
+			if !check.Passed {
+				mark = "✗"
+			}
+			line := fmt.Sprintf("- %s %s", mark, check.Name)
+			if check.Detail != "" && !check.Passed {
+				line += ": " + check.Detail
+			}
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+}
+
+func printDiff(diff *model.EvalDiff) {
+	fmt.Printf("# %s → %s (набор %s)\n\n", diff.Baseline.Label, diff.Candidate.Label, diff.Candidate.SuiteVersion)
+	fmt.Printf("Итоговая оценка: %.0f%% → %.0f%% (%+.0f)\n\n",
+		diff.Baseline.Score*100, diff.Candidate.Score*100, (diff.Candidate.Score-diff.Baseline.Score)*100)
+
+	fmt.Println("| Переписка | Было | Стало | Ухудшилось | Исправлено |")
+	fmt.Println("|---|---|---|---|---|")
+	for _, c := range diff.Cases {
+		fmt.Printf("| %s | %.0f%% | %.0f%% | %s | %s |\n",
+			c.CaseID, c.BaselineScore*100, c.CandidateScore*100, strings.Join(c.Regressed, ", "), strings.Join(c.Fixed, ", "))
+	}
+	fmt.Println()
+
+	for _, c := range diff.Cases {
+		if c.BaselineReply == c.CandidateReply {
+			continue
+		}
+		fmt.Printf("## %s\n", c.CaseID)
+		fmt.Printf("- было: %s\n", preview(c.BaselineReply))
+		fmt.Printf("+ стало: %s\n\n", preview(c.CandidateReply))
+	}
+}
+
+func preview(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxReplyPreview {
+		return string(runes[:maxReplyPreview]) + "…"
+	}
+	return text
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -11,11 +13,17 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/yourusername/uilet/internal/config"
 	"github.com/yourusername/uilet/internal/handler"
+	"github.com/yourusername/uilet/internal/model"
 	"github.com/yourusername/uilet/internal/repository/postgres"
 	"github.com/yourusername/uilet/internal/service"
 	"github.com/yourusername/uilet/pkg/hash"
 	"github.com/yourusername/uilet/pkg/jwt"
+	"github.com/yourusername/uilet/pkg/llm"
+	"github.com/yourusername/uilet/pkg/mail"
 	"github.com/yourusername/uilet/pkg/middleware"
+	"github.com/yourusername/uilet/pkg/otp"
+	"github.com/yourusername/uilet/pkg/ratelimit"
+	"github.com/yourusername/uilet/pkg/stt"
 )
 
 func main() {
@@ -44,32 +52,125 @@ func main() {
 
 	// Инициализация компонентов
 	hasher := hash.NewPasswordHasher(10)
-	tokenManager := jwt.NewTokenManager(cfg.JWTKey)
+	keyset, err := newKeyset(cfg)
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+	tokenManager := jwt.NewTokenManager(keyset, cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTAccessTTL)
 	userRepo := postgres.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo, hasher, tokenManager)
-	authHandler := handler.NewAuthHandler(authService)
+	notificationService := service.NewNotificationService(postgres.NewNotificationRepository(db))
+	sessionService := service.NewSessionService(postgres.NewSessionRepository(db), tokenManager, notificationService, cfg.JWTRefreshTTL)
+	phoneCodeRepo := postgres.NewPhoneCodeRepository(db)
+	accountService := service.NewAccountService(userRepo, postgres.NewAuthTokenRepository(db), phoneCodeRepo, newMailSender(cfg), hasher, sessionService, notificationService, cfg.AppURL)
+	rateLimitStore := newRateLimitStore(cfg, db)
+	loginGuard := service.NewLoginGuard(rateLimitStore)
+	twoFactorService := service.NewTwoFactorService(postgres.NewTwoFactorRepository(db), userRepo, sessionService, notificationService, loginGuard)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	authService := service.NewAuthService(userRepo, hasher, twoFactorService, accountService, loginGuard)
+	authHandler := handler.NewAuthHandler(authService, sessionService, accountService)
+	otpSenders := newOTPSenders(cfg)
+	phoneAuthService := service.NewPhoneAuthService(userRepo, phoneCodeRepo, otpSenders, twoFactorService)
+	phoneAuthHandler := handler.NewPhoneAuthHandler(phoneAuthService)
+	organizationService := service.NewOrganizationService(postgres.NewOrganizationRepository(db), accountService, otpSenders[otp.ChannelSMS], notificationService, cfg.AppURL)
+	twoFactorService.RequireWhen(organizationService.RequiresTwoFactor)
+	organizationHandler := handler.NewOrganizationHandler(organizationService)
+	apiKeyService := service.NewAPIKeyService(postgres.NewAPIKeyRepository(db), notificationService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 	apartmentRepo := postgres.NewApartmentRepository(db)
-	apartmentService := service.NewApartmentService(apartmentRepo)
+	translationRepo := postgres.NewApartmentTranslationRepository(db)
+	apartmentService := service.NewApartmentService(apartmentRepo, translationRepo, organizationService)
 	apartmentHandler := handler.NewApartmentHandler(apartmentService)
+	aiConfigRepo := postgres.NewAIConfigRepository(db)
+	usageService := service.NewUsageService(postgres.NewUsageRepository(db))
+	usageHandler := handler.NewUsageHandler(usageService)
+	llmClient := usageService.Meter(newLLM(cfg))
+	listingTextService := service.NewListingTextService(llmClient, apartmentRepo, translationRepo)
+	listingTextHandler := handler.NewListingTextHandler(listingTextService)
+	conversationRepo := postgres.NewConversationRepository(db)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	conversationService := service.NewConversationService(conversationRepo, notificationService, llmClient)
+	listingContext := service.NewListingContextBuilder(apartmentRepo)
+	apartmentService.OnChange(listingContext.Invalidate)
+	bookingRepo := postgres.NewBookingRequestRepository(db)
+	bookingService := service.NewBookingService(bookingRepo, apartmentRepo, apartmentService, listingContext, notificationService)
+	bookingHandler := handler.NewBookingHandler(bookingService)
+	agentService := service.NewAgentService(llmClient, listingContext, bookingService, apartmentService, postgres.NewToolCallRepository(db), cfg.PublicURL)
+	scenarioRepo := postgres.NewScenarioRepository(db)
+	templateService := service.NewTemplateService(postgres.NewMessageTemplateRepository(db), scenarioRepo, bookingRepo, aiConfigRepo)
+	templateHandler := handler.NewTemplateHandler(templateService)
+	mediaService := service.NewMediaService(postgres.NewMessageMediaRepository(db), conversationService, aiConfigRepo, newTranscriber(cfg))
+	outboxService := service.NewOutboxService(postgres.NewOutboundRepository(db), notificationService, cfg.OutboundPerMinute)
+	consentService := service.NewConsentService(postgres.NewConsentRepository(db), aiConfigRepo)
+	consentHandler := handler.NewConsentHandler(consentService)
+	whatsAppService := service.NewWhatsAppService(userRepo, aiConfigRepo, conversationService, listingContext, agentService, templateService, mediaService, outboxService, consentService)
+	whatsAppHandler := handler.NewWhatsAppHandler(whatsAppService, outboxService)
+	conversationHandler := handler.NewConversationHandler(conversationService, whatsAppService, mediaService)
+	scheduledMessageRepo := postgres.NewScheduledMessageRepository(db)
+	scenarioService := service.NewScenarioService(scenarioRepo, scheduledMessageRepo, bookingRepo, aiConfigRepo, templateService, apartmentRepo)
+	bookingService.OnConfirm(scenarioService.OnBookingConfirmed)
+	scenarioHandler := handler.NewScenarioHandler(scenarioService)
+	privacyService := service.NewPrivacyService(postgres.NewPrivacyRepository(db), userRepo, hasher, accountService, notificationService)
+	privacyService.OnErase(whatsAppService.Disconnect)
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+
+	// Очередь исходящих сообщений и отправка сообщений сценариев по расписанию
+	go outboxService.Run(context.Background(), whatsAppService)
+	scheduler := service.NewMessageScheduler(scenarioService, scheduledMessageRepo, outboxService, consentService, conversationService)
+	go scheduler.Run(context.Background())
+	// Удаление аккаунтов, срок удаления которых наступил
+	go privacyService.Run(context.Background())
 
 	// Настройка роутера
 	router := gin.Default()
+	if cfg.TrustedProxies != "" {
+		if err := router.SetTrustedProxies(strings.Split(cfg.TrustedProxies, ",")); err != nil {
+			log.Fatalf("Error setting trusted proxies: %v", err)
+		}
+	} else {
+		// Без явно указанных прокси X-Forwarded-For не учитывается: иначе клиент
+		// подменял бы свой IP и обходил ограничения частоты запросов
+		if err := router.SetTrustedProxies(nil); err != nil {
+			log.Fatalf("Error setting trusted proxies: %v", err)
+		}
+		log.Println("TRUSTED_PROXIES is not set, client IPs are taken from the remote address")
+	}
 
 	// Настройка CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Публичные роуты
+	// Ограничения частоты запросов
+	authLimit := middleware.RateLimit(ratelimit.New(rateLimitStore, "auth", ratelimit.Limit{Requests: 60, Window: 15 * time.Minute}), middleware.ByIP)
+	signUpLimit := middleware.RateLimit(ratelimit.New(rateLimitStore, "sign-up", ratelimit.Limit{Requests: 10, Window: time.Hour}), middleware.ByIP)
+	emailLimit := middleware.RateLimit(ratelimit.New(rateLimitStore, "auth-email", ratelimit.Limit{Requests: 10, Window: time.Hour}), middleware.ByIP)
+	phoneCodeLimit := middleware.RateLimit(ratelimit.New(rateLimitStore, "phone-code", ratelimit.Limit{Requests: 10, Window: time.Hour}), middleware.ByIP)
+	uploadLimit := middleware.RateLimit(ratelimit.New(rateLimitStore, "upload", ratelimit.Limit{Requests: 60, Window: time.Hour}), middleware.ByUser)
+	exportLimit := middleware.RateLimit(ratelimit.New(rateLimitStore, "export", ratelimit.Limit{Requests: 10, Window: time.Hour}), middleware.ByUser)
+	aiTestLimit := middleware.RateLimit(ratelimit.New(rateLimitStore, "ai-test", ratelimit.Limit{Requests: 30, Window: 10 * time.Minute}), middleware.ByUser)
+
 	auth := router.Group("/auth")
+	auth.Use(authLimit)
 	{
-		auth.POST("/sign-up", authHandler.SignUp)
+		auth.POST("/sign-up", signUpLimit, authHandler.SignUp)
 		auth.POST("/sign-in", authHandler.SignIn)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/logout", authHandler.Logout)
+		auth.POST("/forgot-password", emailLimit, authHandler.ForgotPassword)
+		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/verify-email", authHandler.VerifyEmail)
+		auth.POST("/email-change/confirm", authHandler.ConfirmEmailChange)
+		auth.POST("/phone/code", phoneCodeLimit, phoneAuthHandler.RequestCode)
+		auth.POST("/phone/verify", phoneAuthHandler.Verify)
+		auth.POST("/2fa/verify", twoFactorHandler.Verify)
+		auth.POST("/2fa/setup", twoFactorHandler.SetupWithChallenge)
+		auth.POST("/2fa/setup/confirm", twoFactorHandler.ConfirmWithChallenge)
 	}
 
 	// Публичные роуты для изображений
@@ -77,20 +178,114 @@ func main() {
 
 	// Защищенные роуты
 	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware(tokenManager))
+	api.Use(middleware.APIKeyMiddleware(apiKeyService, apiKeyScopes), middleware.AuthMiddleware(tokenManager, sessionService))
 	{
 		api.GET("/user/profile", authHandler.GetProfile)
 		api.PUT("/user/profile", authHandler.UpdateProfile)
+		api.PUT("/user/email", emailLimit, authHandler.ChangeEmail)
+		api.GET("/user/avatar", authHandler.GetAvatar)
+		api.PUT("/user/avatar", uploadLimit, authHandler.UploadAvatar)
+		api.DELETE("/user/avatar", authHandler.DeleteAvatar)
+		api.GET("/user/export", exportLimit, privacyHandler.ExportAccount)
+		api.POST("/user/deletion", privacyHandler.RequestDeletion)
+		api.DELETE("/user/deletion", privacyHandler.CancelDeletion)
+		api.POST("/user/verify-email/resend", emailLimit, authHandler.ResendVerification)
+		api.GET("/user/2fa", twoFactorHandler.GetStatus)
+		api.POST("/user/2fa/enroll", twoFactorHandler.Enroll)
+		api.POST("/user/2fa/confirm", twoFactorHandler.Confirm)
+		api.POST("/user/2fa/disable", twoFactorHandler.Disable)
+		api.POST("/user/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		api.GET("/user/api-keys", apiKeyHandler.GetAll)
+		api.POST("/user/api-keys", apiKeyHandler.Create)
+		api.DELETE("/user/api-keys/:id", apiKeyHandler.Revoke)
+		api.GET("/user/sessions", authHandler.GetSessions)
+		api.DELETE("/user/sessions/:id", authHandler.RevokeSession)
+		api.POST("/user/sessions/logout-all", authHandler.LogoutAll)
 		api.POST("/apartments", apartmentHandler.Create)
 		api.GET("/apartments", apartmentHandler.GetUserApartments)
 		api.PUT("/apartments/:id", apartmentHandler.Update)
 		api.GET("/apartments/:id", apartmentHandler.GetApartmentDetails)
-		api.POST("/apartments/:id/images", apartmentHandler.UploadImages)
+		api.POST("/apartments/:id/images", uploadLimit, apartmentHandler.UploadImages)
 		api.DELETE("/apartments/:id", apartmentHandler.Delete)
 		api.DELETE("/apartments/:id/images/:index", apartmentHandler.DeleteImage)
 		apartmentRoutes := api.Group("/apartments")
 		{
 			apartmentRoutes.PATCH("/:id/toggle-active", apartmentHandler.ToggleActive)
+			apartmentRoutes.GET("/:id/guest-info", scenarioHandler.GetGuestInfo)
+			apartmentRoutes.PUT("/:id/guest-info", scenarioHandler.UpdateGuestInfo)
+			apartmentRoutes.POST("/:id/description/draft", listingTextHandler.DraftDescription)
+			apartmentRoutes.POST("/:id/translations", listingTextHandler.Translate)
+			apartmentRoutes.GET("/:id/translations", listingTextHandler.GetTranslations)
+			apartmentRoutes.PUT("/:id/translations/:lang", listingTextHandler.UpdateTranslation)
+			apartmentRoutes.POST("/:id/translations/:lang/publish", listingTextHandler.Publish)
+		}
+		organizationRoutes := api.Group("/organizations")
+		{
+			organizationRoutes.GET("", organizationHandler.GetAll)
+			organizationRoutes.POST("", organizationHandler.Create)
+			organizationRoutes.PUT("/:id", organizationHandler.Update)
+			organizationRoutes.GET("/:id/members", organizationHandler.GetMembers)
+			organizationRoutes.PUT("/:id/members/:userId", organizationHandler.UpdateMember)
+			organizationRoutes.DELETE("/:id/members/:userId", organizationHandler.RemoveMember)
+			organizationRoutes.GET("/:id/invitations", organizationHandler.GetInvitations)
+			organizationRoutes.POST("/:id/invitations", organizationHandler.Invite)
+			organizationRoutes.DELETE("/:id/invitations/:invitationId", organizationHandler.RevokeInvitation)
+		}
+		api.POST("/invitations/accept", organizationHandler.AcceptInvitation)
+		whatsAppRoutes := api.Group("/whatsapp")
+		{
+			whatsAppRoutes.POST("/login", whatsAppHandler.InitiateLogin)
+			whatsAppRoutes.GET("/ai/config", whatsAppHandler.GetAIConfig)
+			whatsAppRoutes.PUT("/ai/config", whatsAppHandler.ConfigureAI)
+			whatsAppRoutes.POST("/ai/test", aiTestLimit, whatsAppHandler.TestAI)
+			whatsAppRoutes.GET("/outbox", whatsAppHandler.GetOutbox)
+			whatsAppRoutes.POST("/outbox/:id/retry", whatsAppHandler.RetryOutbound)
+		}
+		conversationRoutes := api.Group("/conversations")
+		{
+			conversationRoutes.GET("", conversationHandler.GetUserConversations)
+			conversationRoutes.GET("/:id", conversationHandler.GetConversation)
+			conversationRoutes.PATCH("/:id/mode", conversationHandler.SetMode)
+			conversationRoutes.POST("/:id/messages", conversationHandler.Reply)
+			conversationRoutes.GET("/:id/media/:mediaId", conversationHandler.GetMedia)
+		}
+		consentRoutes := api.Group("/consents")
+		{
+			consentRoutes.GET("", consentHandler.GetConsents)
+			consentRoutes.PUT("/:phone", consentHandler.Update)
+			consentRoutes.GET("/:phone/log", consentHandler.GetLog)
+		}
+		guestRoutes := api.Group("/guests")
+		{
+			guestRoutes.GET("/:phone/export", exportLimit, privacyHandler.ExportGuest)
+			guestRoutes.DELETE("/:phone", privacyHandler.EraseGuest)
+		}
+		api.GET("/analytics/usage", usageHandler.GetUsage)
+		api.GET("/notifications", notificationHandler.GetNotifications)
+		api.POST("/notifications/:id/read", notificationHandler.MarkRead)
+		bookingRoutes := api.Group("/booking-requests")
+		{
+			bookingRoutes.GET("", bookingHandler.GetBookingRequests)
+			bookingRoutes.POST("/:id/confirm", bookingHandler.Confirm)
+			bookingRoutes.POST("/:id/reject", bookingHandler.Reject)
+		}
+		scenarioRoutes := api.Group("/scenarios")
+		{
+			scenarioRoutes.GET("", scenarioHandler.GetScenarios)
+			scenarioRoutes.POST("", scenarioHandler.Create)
+			scenarioRoutes.PUT("/:id", scenarioHandler.Update)
+			scenarioRoutes.DELETE("/:id", scenarioHandler.Delete)
+			scenarioRoutes.GET("/messages", scenarioHandler.GetScheduledMessages)
+		}
+		templateRoutes := api.Group("/templates")
+		{
+			templateRoutes.GET("", templateHandler.GetTemplates)
+			templateRoutes.GET("/variables", templateHandler.GetVariables)
+			templateRoutes.POST("", templateHandler.Create)
+			templateRoutes.GET("/:id", templateHandler.GetTemplate)
+			templateRoutes.PUT("/:id", templateHandler.Update)
+			templateRoutes.DELETE("/:id", templateHandler.Delete)
+			templateRoutes.POST("/:id/preview", templateHandler.Preview)
 		}
 	}
 
@@ -102,3 +297,139 @@ func main() {
 		log.Fatalf("Error starting server: %v", err)
 	}
 }
+
+// newKeyset загружает ключи JWT из JWT_KEYSET_FILE или JWT_KEY. Вне режима разработки
+// сервер не стартует со стандартным или коротким секретом.
+func newKeyset(cfg *config.Config) (*jwt.Keyset, error) {
+	var keyset *jwt.Keyset
+	var err error
+	if cfg.JWTKeysetFile != "" {
+		keyset, err = jwt.LoadKeysetFile(cfg.JWTKeysetFile)
+	} else {
+		if cfg.JWTKey == "" {
+			return nil, fmt.Errorf("set JWT_KEY or JWT_KEYSET_FILE")
+		}
+		keyset, err = jwt.NewHMACKeyset(cfg.JWTKeyID, cfg.JWTKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset.CheckStrength(); err != nil {
+		if !cfg.IsDevelopment() {
+			return nil, fmt.Errorf("%v; set APP_ENV=development to allow it locally", err)
+		}
+		log.Printf("Warning: %v", err)
+	}
+
+	log.Printf("JWT signing key: %s (%s)", keyset.Signing().ID, keyset.Signing().Method.Alg())
+	return keyset, nil
+}
+
+func newLLM(cfg *config.Config) llm.LLM {
+	if cfg.LLMProvider == "fake" {
+		log.Println("Using fake LLM provider")
+		return llm.NewFake()
+	}
+
+	return llm.NewOpenAI(llm.OpenAIConfig{
+		BaseURL: cfg.LLMBaseURL,
+		APIKey:  cfg.LLMAPIKey,
+		Model:   cfg.LLMModel,
+		Timeout: cfg.LLMTimeout,
+	})
+}
+
+func newMailSender(cfg *config.Config) mail.Sender {
+	if cfg.MailProvider != "smtp" {
+		log.Println("Using log mail sender, emails are written to the server log")
+		return mail.NewLog()
+	}
+
+	return mail.NewSMTP(mail.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.MailFrom,
+	})
+}
+
+// newRateLimitStore - счётчики в памяти или, для нескольких экземпляров сервера, в базе
+func newRateLimitStore(cfg *config.Config, db *sql.DB) ratelimit.Store {
+	if cfg.RateLimitStore == "postgres" {
+		return postgres.NewRateLimitRepository(db)
+	}
+	return ratelimit.NewMemory()
+}
+
+// apiKeyScopes - маршруты, доступные интеграциям владельца по API-ключу, и право,
+// которое для них нужно. Остальные маршруты по ключу закрыты.
+var apiKeyScopes = middleware.RouteScopes{
+	"GET /api/apartments":                      model.ScopeApartmentsRead,
+	"GET /api/apartments/:id":                  model.ScopeApartmentsRead,
+	"GET /api/apartments/:id/translations":     model.ScopeApartmentsRead,
+	"POST /api/apartments":                     model.ScopeApartmentsWrite,
+	"PUT /api/apartments/:id":                  model.ScopeApartmentsWrite,
+	"PATCH /api/apartments/:id/toggle-active":  model.ScopeApartmentsWrite,
+	"POST /api/apartments/:id/images":          model.ScopeApartmentsWrite,
+	"DELETE /api/apartments/:id/images/:index": model.ScopeApartmentsWrite,
+	"GET /api/booking-requests":                model.ScopeBookingsRead,
+	"POST /api/booking-requests/:id/confirm":   model.ScopeBookingsWrite,
+	"POST /api/booking-requests/:id/reject":    model.ScopeBookingsWrite,
+}
+
+// newOTPSenders собирает доступные каналы доставки кодов входа. Канал без провайдера
+// недоступен; log пишет коды в журнал сервера и разрешён только в режиме разработки.
+func newOTPSenders(cfg *config.Config) map[string]otp.Sender {
+	senders := make(map[string]otp.Sender)
+
+	switch cfg.OTPWhatsAppProvider {
+	case "cloud":
+		senders[otp.ChannelWhatsApp] = otp.NewWhatsApp(otp.WhatsAppConfig{
+			Token:         cfg.WhatsAppCloudToken,
+			PhoneNumberID: cfg.WhatsAppPhoneNumberID,
+			Template:      cfg.WhatsAppOTPTemplate,
+		})
+	case "log":
+		if !cfg.IsDevelopment() {
+			log.Fatalf("OTP_WHATSAPP_PROVIDER=log writes login codes to the server log; set APP_ENV=development to allow it locally")
+		}
+		log.Println("Using log OTP sender for WhatsApp, codes are written to the server log")
+		senders[otp.ChannelWhatsApp] = otp.NewLog(otp.ChannelWhatsApp)
+	}
+
+	switch cfg.OTPSMSProvider {
+	case "twilio":
+		senders[otp.ChannelSMS] = otp.NewTwilio(otp.TwilioConfig{
+			AccountSID: cfg.TwilioAccountSID,
+			AuthToken:  cfg.TwilioAuthToken,
+			From:       cfg.TwilioFrom,
+		})
+	case "log":
+		if !cfg.IsDevelopment() {
+			log.Fatalf("OTP_SMS_PROVIDER=log writes login codes to the server log; set APP_ENV=development to allow it locally")
+		}
+		log.Println("Using log OTP sender for SMS, codes are written to the server log")
+		senders[otp.ChannelSMS] = otp.NewLog(otp.ChannelSMS)
+	}
+
+	return senders
+}
+
+// newTranscriber возвращает nil, если распознавание голосовых отключено
+func newTranscriber(cfg *config.Config) stt.Transcriber {
+	switch cfg.STTProvider {
+	case "none", "":
+		return nil
+	case "fake":
+		log.Println("Using fake speech-to-text provider")
+		return stt.NewFake()
+	}
+
+	return stt.NewOpenAI(stt.OpenAIConfig{
+		BaseURL: cfg.STTBaseURL,
+		APIKey:  cfg.STTAPIKey,
+		Model:   cfg.STTModel,
+	})
+}
@@ -2,18 +2,70 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port       string
+	// AppEnv - "development" разрешает слабый JWT-секрет для локальной разработки
+	AppEnv string
+	Port   string
+	// PublicURL - внешний адрес API, используется в ссылках для гостей
+	PublicURL  string
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
 	JWTKey     string
+	// JWTKeyID - kid ключа JWT_KEY; JWTKeysetFile - JSON-набор ключей для ротации, заменяет JWT_KEY
+	JWTKeyID      string
+	JWTKeysetFile string
+	JWTIssuer     string
+	JWTAudience   string
+	// JWTAccessTTL - время жизни access-токена, JWTRefreshTTL - сессии без активности
+	JWTAccessTTL  time.Duration
+	JWTRefreshTTL time.Duration
+	// LLMProvider - "openai" для OpenAI-совместимого API или "fake" для офлайн-режима
+	LLMProvider string
+	LLMBaseURL  string
+	LLMAPIKey   string
+	LLMModel    string
+	LLMTimeout  time.Duration
+	// STTProvider - распознавание голосовых: "openai", "fake" или "none"
+	STTProvider string
+	STTBaseURL  string
+	STTAPIKey   string
+	STTModel    string
+	// AppURL - адрес фронтенда для ссылок в письмах
+	AppURL string
+	// MailProvider - "smtp" или "log" (письма пишутся в лог сервера)
+	MailProvider string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	// OTPWhatsAppProvider - доставка кодов входа в WhatsApp: "cloud" или "log"; пусто - канал выключен
+	OTPWhatsAppProvider   string
+	WhatsAppCloudToken    string
+	WhatsAppPhoneNumberID string
+	WhatsAppOTPTemplate   string
+	// OTPSMSProvider - доставка кодов по SMS: "twilio" или "log"; пусто - канал выключен
+	OTPSMSProvider   string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFrom       string
+	// TrustedProxies - адреса прокси через запятую, которым можно верить в X-Forwarded-For.
+	// Без них лимиты по IP можно обойти подделкой заголовка.
+	TrustedProxies string
+	// RateLimitStore - где хранить счётчики ограничений: "memory" для одного сервера
+	// или "postgres", если экземпляров несколько
+	RateLimitStore string
+	// OutboundPerMinute - сколько сообщений в минуту можно отправить с одного номера WhatsApp
+	OutboundPerMinute int
 }
 
 func LoadConfig() (*Config, error) {
@@ -22,19 +74,85 @@ func LoadConfig() (*Config, error) {
 	}
 
 	return &Config{
+		AppEnv:     getEnv("APP_ENV", "production"),
 		Port:       getEnv("PORT", "8080"),
+		PublicURL:  getEnv("PUBLIC_URL", "http://localhost:8080"),
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "uilet"),
-		JWTKey:     getEnv("JWT_KEY", "your-secret-key"),
+		JWTKey:     getEnv("JWT_KEY", ""),
+
+		JWTKeyID:      getEnv("JWT_KEY_ID", "default"),
+		JWTKeysetFile: getEnv("JWT_KEYSET_FILE", ""),
+		JWTIssuer:     getEnv("JWT_ISSUER", "uilet"),
+		JWTAudience:   getEnv("JWT_AUDIENCE", "uilet-api"),
+
+		JWTAccessTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+		JWTRefreshTTL: getEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
+
+		LLMProvider: getEnv("LLM_PROVIDER", "openai"),
+		LLMBaseURL:  getEnv("LLM_BASE_URL", "https://api.openai.com/v1"),
+		LLMAPIKey:   getEnv("LLM_API_KEY", os.Getenv("OPENAI_API_KEY")),
+		LLMModel:    getEnv("LLM_MODEL", "gpt-3.5-turbo"),
+		LLMTimeout:  getEnvDuration("LLM_TIMEOUT", 30*time.Second),
+
+		STTProvider: getEnv("STT_PROVIDER", "openai"),
+		STTBaseURL:  getEnv("STT_BASE_URL", getEnv("LLM_BASE_URL", "https://api.openai.com/v1")),
+		STTAPIKey:   getEnv("STT_API_KEY", getEnv("LLM_API_KEY", os.Getenv("OPENAI_API_KEY"))),
+		STTModel:    getEnv("STT_MODEL", "whisper-1"),
+
+		AppURL:       getEnv("APP_URL", "http://localhost:3000"),
+		MailProvider: getEnv("MAIL_PROVIDER", "log"),
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnv("SMTP_PORT", "1025"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "Uilet <no-reply@uilet.kz>"),
+
+		OTPWhatsAppProvider:   getEnv("OTP_WHATSAPP_PROVIDER", ""),
+		WhatsAppCloudToken:    getEnv("WHATSAPP_CLOUD_TOKEN", ""),
+		WhatsAppPhoneNumberID: getEnv("WHATSAPP_PHONE_NUMBER_ID", ""),
+		WhatsAppOTPTemplate:   getEnv("WHATSAPP_OTP_TEMPLATE", "uilet_login_code"),
+		OTPSMSProvider:        getEnv("OTP_SMS_PROVIDER", ""),
+		TwilioAccountSID:      getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:       getEnv("TWILIO_AUTH_TOKEN", ""),
+		TwilioFrom:            getEnv("TWILIO_FROM", ""),
+
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+		RateLimitStore: getEnv("RATE_LIMIT_STORE", "memory"),
+
+		OutboundPerMinute: getEnvInt("OUTBOUND_PER_MINUTE", 20),
 	}, nil
 }
 
+// IsDevelopment - локальный запуск, где допустимы упрощённые настройки
+func (c *Config) IsDevelopment() bool {
+	return c.AppEnv == "development"
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
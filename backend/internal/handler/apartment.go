@@ -3,6 +3,7 @@ package handler
 import (
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -42,13 +43,13 @@ func (h *ApartmentHandler) Create(c *gin.Context) {
 	// Создаем объявление
 	apartmentID, err := h.service.Create(userID.(uint), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create apartment: %v", err)})
+		respondApartmentError(c, err)
 		return
 	}
 
 	// Если есть данные о доступности, сохраняем их
 	if len(input.Availabilities) > 0 {
-		if err := h.service.UpdateAvailabilities(apartmentID, input.Availabilities); err != nil {
+		if err := h.service.UpdateAvailabilities(userID.(uint), apartmentID, input.Availabilities); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update availabilities: %v", err)})
 			return
 		}
@@ -141,13 +142,7 @@ func (h *ApartmentHandler) Update(c *gin.Context) {
 
 	// Обновляем данные объявления
 	if err := h.service.Update(userID.(uint), apartmentID, input); err != nil {
-		status := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "unauthorized") {
-			status = http.StatusForbidden
-		} else if strings.Contains(err.Error(), "not found") {
-			status = http.StatusNotFound
-		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		respondApartmentError(c, err)
 		return
 	}
 
@@ -200,7 +195,7 @@ func (h *ApartmentHandler) GetApartmentDetails(c *gin.Context) {
 
 	apartment, err := h.service.GetApartmentDetails(userID.(uint), apartmentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondApartmentError(c, err)
 		return
 	}
 
@@ -242,7 +237,7 @@ func (h *ApartmentHandler) UploadImages(c *gin.Context) {
 	}
 
 	if err := h.service.AddImages(userID.(uint), apartmentID, imageData, imageTypes); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondApartmentError(c, err)
 		return
 	}
 
@@ -356,7 +351,7 @@ func (h *ApartmentHandler) Delete(c *gin.Context) {
 	apartmentID := c.Param("id")
 
 	if err := h.service.Delete(userID.(uint), apartmentID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondApartmentError(c, err)
 		return
 	}
 
@@ -375,7 +370,7 @@ func (h *ApartmentHandler) DeleteImage(c *gin.Context) {
 	}
 
 	if err := h.service.DeleteImage(userID.(uint), apartmentID, index); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondApartmentError(c, err)
 		return
 	}
 
@@ -388,17 +383,21 @@ func (h *ApartmentHandler) ToggleActive(c *gin.Context) {
 
 	err := h.service.ToggleActive(userID.(uint), apartmentID)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to toggle apartment status: %v", err)})
+		respondApartmentError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "status updated successfully"})
 }
+
+// respondApartmentError: нет прав - 403, квартира не найдена или чужая - 404
+func respondApartmentError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		status = http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func (h *APIKeyHandler) GetAll(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	keys, err := h.service.GetByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// Create возвращает ключ целиком один раз, потом виден только его prefix
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.CreateAPIKeyInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.service.Create(userID.(uint), input)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAPIKeyLimit) || errors.Is(err, service.ErrDuplicateScope) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(userID.(uint), id); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API-ключ отозван"})
+}
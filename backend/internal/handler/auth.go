@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/uilet/internal/model"
@@ -9,11 +13,13 @@ import (
 )
 
 type AuthHandler struct {
-	service *service.AuthService
+	service  *service.AuthService
+	sessions *service.SessionService
+	accounts *service.AccountService
 }
 
-func NewAuthHandler(service *service.AuthService) *AuthHandler {
-	return &AuthHandler{service: service}
+func NewAuthHandler(service *service.AuthService, sessions *service.SessionService, accounts *service.AccountService) *AuthHandler {
+	return &AuthHandler{service: service, sessions: sessions, accounts: accounts}
 }
 
 func (h *AuthHandler) SignUp(c *gin.Context) {
@@ -35,7 +41,7 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Пользователь успешно создан",
+		"message": "Проверьте почту: мы отправили письмо для завершения регистрации",
 	})
 }
 
@@ -50,18 +56,267 @@ func (h *AuthHandler) SignIn(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.SignIn(input)
+	result, err := h.service.SignIn(input, sessionMeta(c))
 	if err != nil {
+		if respondThrottled(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":                       err.Error(),
+				"email_verification_required": true,
+			})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token":   token,
-		"message": "Вход выполнен успешно",
-	})
+	c.JSON(http.StatusOK, signInResponse(result, "Вход выполнен успешно"))
+}
+
+// respondThrottled отвечает 429 с Retry-After, если вход отклонён защитой от перебора
+func respondThrottled(c *gin.Context, err error) bool {
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(throttled.Wait.Seconds()+0.99)))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
+
+// signInResponse - токены или, если нужен второй фактор, промежуточный токен
+func signInResponse(result *model.SignInResult, message string) gin.H {
+	if result.Tokens == nil {
+		message = "Введите код из приложения-аутентификатора"
+		if result.ChallengePurpose == model.ChallengeSetup {
+			message = "Для входа подключите двухфакторную аутентификацию"
+		}
+		return gin.H{
+			"two_factor_required": true,
+			"setup_required":      result.ChallengePurpose == model.ChallengeSetup,
+			"challenge_token":     result.ChallengeToken,
+			"expires_in":          result.ChallengeExpiresIn,
+			"message":             message,
+		}
+	}
+
+	return gin.H{
+		"token":         result.Tokens.AccessToken,
+		"refresh_token": result.Tokens.RefreshToken,
+		"expires_in":    result.Tokens.ExpiresIn,
+		"message":       message,
+	}
+}
+
+// Refresh выдаёт новую пару токенов, старый refresh-токен больше не действует
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input model.RefreshInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.sessions.Refresh(input.RefreshToken, sessionMeta(c))
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var input model.RefreshInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.sessions.Logout(input.RefreshToken); err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Вы вышли из аккаунта"})
+}
+
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID, _ := c.Get("sessionID")
+
+	sessions, err := h.sessions.GetActive(userID.(uint), sessionID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.sessions.Revoke(userID.(uint), c.Param("id")); err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Сессия завершена"})
+}
+
+// LogoutAll завершает сессии на всех устройствах, включая текущее
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	count, err := h.sessions.LogoutAll(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Вы вышли на всех устройствах", "sessions": count})
+}
+
+// ForgotPassword отправляет ссылку для сброса пароля, если такой email зарегистрирован
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var input model.ForgotPasswordInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accounts.RequestPasswordReset(input); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Если этот email зарегистрирован, мы отправили на него ссылку для сброса пароля"})
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input model.ResetPasswordInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accounts.ResetPassword(input); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Пароль изменён, войдите с новым паролем"})
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var input model.VerifyEmailInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accounts.VerifyEmail(input.Token); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email подтверждён"})
+}
+
+// ChangeEmail отправляет ссылку подтверждения на новый адрес
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.ChangeEmailInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accounts.RequestEmailChange(userID.(uint), input); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ссылка для подтверждения отправлена на новый адрес"})
+}
+
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	var input model.VerifyEmailInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accounts.ConfirmEmailChange(input.Token); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email изменён"})
+}
+
+// ResendVerification повторно отправляет письмо подтверждения на email владельца
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.ResendVerificationInput
+
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accounts.SendVerification(userID.(uint), input.Language); err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Письмо отправлено"})
+}
+
+func respondAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAccountEmailLimit):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmailAlreadyVerified):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidEmailToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWrongPassword), errors.Is(err, service.ErrInvalidPhoneCode), errors.Is(err, service.ErrPhoneCodeAttempts):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func sessionMeta(c *gin.Context) model.SessionMeta {
+	return model.SessionMeta{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
+func respondSessionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRefreshToken), errors.Is(err, service.ErrRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 func (h *AuthHandler) GetProfile(c *gin.Context) {
@@ -84,10 +339,78 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateProfile(userID.(uint), input); err != nil {
+	user, err := h.service.UpdateProfile(userID.(uint), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UploadAvatar принимает изображение в поле avatar multipart-формы
+func (h *AuthHandler) UploadAvatar(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл avatar не найден"})
+		return
+	}
+	if file.Size > service.MaxAvatarSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Аватар должен быть не больше 5 МБ"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, service.MaxAvatarSize))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	if err := h.service.SetAvatar(userID.(uint), data); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAvatarType) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Аватар обновлён"})
+}
+
+func (h *AuthHandler) GetAvatar(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	avatar, contentType, err := h.service.GetAvatar(userID.(uint))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, contentType, avatar)
+}
+
+func (h *AuthHandler) DeleteAvatar(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.service.DeleteAvatar(userID.(uint)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "profile updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Аватар удалён"})
 }
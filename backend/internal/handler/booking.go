@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type BookingHandler struct {
+	service *service.BookingService
+}
+
+func NewBookingHandler(service *service.BookingService) *BookingHandler {
+	return &BookingHandler{service: service}
+}
+
+func (h *BookingHandler) GetBookingRequests(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	status := model.BookingRequestStatus(c.Query("status"))
+
+	requests, err := h.service.GetByUserID(userID.(uint), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+func (h *BookingHandler) Confirm(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	req, err := h.service.Confirm(userID.(uint), c.Param("id"))
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
+
+func (h *BookingHandler) Reject(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	req, err := h.service.Reject(userID.(uint), c.Param("id"))
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
+
+func respondBookingError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type ConsentHandler struct {
+	service *service.ConsentService
+}
+
+func NewConsentHandler(service *service.ConsentService) *ConsentHandler {
+	return &ConsentHandler{service: service}
+}
+
+func (h *ConsentHandler) GetConsents(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	consents, err := h.service.GetByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, consents)
+}
+
+// Update меняет согласие гостя вручную, запись попадает в журнал
+func (h *ConsentHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.UpdateConsentInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	consent, err := h.service.Update(userID.(uint), c.Param("phone"), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, consent)
+}
+
+func (h *ConsentHandler) GetLog(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	entries, err := h.service.GetLog(userID.(uint), c.Param("phone"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
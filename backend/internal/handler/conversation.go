@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type ConversationHandler struct {
+	service  *service.ConversationService
+	whatsApp *service.WhatsAppService
+	media    *service.MediaService
+}
+
+func NewConversationHandler(service *service.ConversationService, whatsApp *service.WhatsAppService, media *service.MediaService) *ConversationHandler {
+	return &ConversationHandler{service: service, whatsApp: whatsApp, media: media}
+}
+
+func (h *ConversationHandler) GetUserConversations(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	conversations, err := h.service.GetByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversations)
+}
+
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	conversationID := c.Param("id")
+
+	conv, err := h.service.GetConversation(userID.(uint), conversationID)
+	if err != nil {
+		respondConversationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conv)
+}
+
+func (h *ConversationHandler) SetMode(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.SetConversationModeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conv, err := h.service.SetMode(userID.(uint), c.Param("id"), input.Mode)
+	if err != nil {
+		respondConversationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conv)
+}
+
+func (h *ConversationHandler) Reply(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.OwnerReplyInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(input.Text) == "" && input.TemplateID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Укажите текст или шаблон"})
+		return
+	}
+	if input.IdempotencyKey == "" {
+		input.IdempotencyKey = c.GetHeader("Idempotency-Key")
+	}
+
+	message, err := h.whatsApp.ReplyAsOwner(userID.(uint), c.Param("id"), input)
+	if err != nil {
+		respondConversationError(c, err)
+		return
+	}
+
+	// Повтор запроса с тем же ключом: сообщение уже в очереди
+	if message == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Сообщение уже отправлено"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+// GetMedia отдаёт файл вложения, а для геолокации - её координаты
+func (h *ConversationHandler) GetMedia(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	media, err := h.media.Get(userID.(uint), c.Param("id"), c.Param("mediaId"))
+	if err != nil {
+		respondConversationError(c, err)
+		return
+	}
+
+	if len(media.Data) == 0 {
+		c.JSON(http.StatusOK, media)
+		return
+	}
+
+	contentType := media.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Data(http.StatusOK, contentType, media.Data)
+}
+
+func respondConversationError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type TemplateHandler struct {
+	service *service.TemplateService
+}
+
+func NewTemplateHandler(service *service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{service: service}
+}
+
+func (h *TemplateHandler) GetTemplates(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	templates, err := h.service.GetByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *TemplateHandler) GetVariables(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"variables":  h.service.Variables(),
+		"languages":  model.TemplateLanguages,
+		"categories": []model.TemplateCategory{model.TemplateUtility, model.TemplateMarketing, model.TemplateAuthentication},
+	})
+}
+
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	template, err := h.service.Get(userID.(uint), c.Param("id"))
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *TemplateHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.MessageTemplateInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.service.Create(userID.(uint), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+func (h *TemplateHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.MessageTemplateInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.service.Update(userID.(uint), c.Param("id"), input)
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *TemplateHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.service.Delete(userID.(uint), c.Param("id")); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Шаблон удалён"})
+}
+
+func (h *TemplateHandler) Preview(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.PreviewTemplateInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.service.Preview(userID.(uint), c.Param("id"), input)
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func respondTemplateError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type OrganizationHandler struct {
+	service *service.OrganizationService
+}
+
+func NewOrganizationHandler(service *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{service: service}
+}
+
+func (h *OrganizationHandler) GetAll(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	orgs, err := h.service.GetForUser(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.CreateOrganizationInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.service.Create(userID.(uint), input)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	var input model.UpdateOrganizationInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.service.Update(userID.(uint), orgID, input)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) GetMembers(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.service.GetMembers(userID.(uint), orgID)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := uintParam(c, "userId")
+	if !ok {
+		return
+	}
+	var input model.UpdateMemberInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateMember(userID.(uint), orgID, memberID, input); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Роль участника изменена"})
+}
+
+// RemoveMember исключает участника; свой id - выход из организации
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := uintParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(userID.(uint), orgID, memberID); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Участник исключён из организации"})
+}
+
+func (h *OrganizationHandler) Invite(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	var input model.InviteMemberInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.service.Invite(userID.(uint), orgID, input)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+func (h *OrganizationHandler) GetInvitations(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.service.GetInvitations(userID.(uint), orgID)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *OrganizationHandler) RevokeInvitation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := uintParam(c, "invitationId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeInvitation(userID.(uint), orgID, invitationID); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Приглашение отозвано"})
+}
+
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.AcceptInvitationInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.service.Accept(userID.(uint), input)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func respondOrganizationError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrCannotRemoveOwner):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidInvitation):
+		status = http.StatusGone
+	case errors.Is(err, service.ErrInvitationLimit):
+		status = http.StatusTooManyRequests
+	case strings.Contains(err.Error(), "not found"):
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// uintParam читает числовой параметр пути и сам отвечает 400, если он некорректен
+func uintParam(c *gin.Context, name string) (uint, bool) {
+	value, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || value == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return uint(value), true
+}
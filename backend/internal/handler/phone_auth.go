@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type PhoneAuthHandler struct {
+	service *service.PhoneAuthService
+}
+
+func NewPhoneAuthHandler(service *service.PhoneAuthService) *PhoneAuthHandler {
+	return &PhoneAuthHandler{service: service}
+}
+
+// RequestCode отправляет код входа в WhatsApp или SMS
+func (h *PhoneAuthHandler) RequestCode(c *gin.Context) {
+	var input model.RequestPhoneCodeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sent, err := h.service.RequestCode(c.Request.Context(), input)
+	if err != nil {
+		respondPhoneAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sent)
+}
+
+// Verify проверяет код и выдаёт токены, при первом входе регистрирует владельца
+func (h *PhoneAuthHandler) Verify(c *gin.Context) {
+	var input model.VerifyPhoneCodeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, created, err := h.service.Verify(input, sessionMeta(c))
+	if err != nil {
+		respondPhoneAuthError(c, err)
+		return
+	}
+
+	message := "Вход выполнен успешно"
+	status := http.StatusOK
+	if created {
+		message = "Пользователь успешно создан"
+		status = http.StatusCreated
+	}
+
+	response := signInResponse(result, message)
+	response["new_user"] = created
+	c.JSON(status, response)
+}
+
+func respondPhoneAuthError(c *gin.Context, err error) {
+	var cooldown *service.PhoneCodeCooldownError
+	switch {
+	case errors.As(err, &cooldown):
+		c.Header("Retry-After", strconv.Itoa(int(cooldown.Wait.Seconds()+0.5)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPhoneCodeLimit), errors.Is(err, service.ErrPhoneCodeAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPhoneCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPhoneChannel):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPhoneUndeliverable):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
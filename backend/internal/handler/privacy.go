@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type PrivacyHandler struct {
+	service *service.PrivacyService
+}
+
+func NewPrivacyHandler(service *service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{service: service}
+}
+
+// ExportAccount отдаёт zip-архив со всеми данными владельца
+func (h *PrivacyHandler) ExportAccount(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	archive, err := h.service.ExportAccount(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendArchive(c, "uilet-export", archive)
+}
+
+func (h *PrivacyHandler) RequestDeletion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.DeleteAccountInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deletion, err := h.service.RequestDeletion(userID.(uint), input)
+	if err != nil {
+		respondPrivacyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deletion)
+}
+
+func (h *PrivacyHandler) CancelDeletion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.service.CancelDeletion(userID.(uint)); err != nil {
+		respondPrivacyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Удаление аккаунта отменено"})
+}
+
+// ExportGuest отдаёт zip-архив с данными гостя, чтобы владелец переслал его гостю
+func (h *PrivacyHandler) ExportGuest(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	archive, err := h.service.ExportGuest(userID.(uint), c.Param("phone"))
+	if err != nil {
+		respondPrivacyError(c, err)
+		return
+	}
+
+	sendArchive(c, "uilet-guest-export", archive)
+}
+
+// EraseGuest удаляет данные гостя по его просьбе
+func (h *PrivacyHandler) EraseGuest(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	erased, err := h.service.EraseGuest(userID.(uint), c.Param("phone"))
+	if err != nil {
+		respondPrivacyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Данные гостя удалены", "erased": erased})
+}
+
+func sendArchive(c *gin.Context, name string, archive []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.zip"`, name, time.Now().Format("2006-01-02")))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func respondPrivacyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWrongPassword):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDeletionNotRequested):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidGuestPhone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
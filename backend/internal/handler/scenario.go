@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type ScenarioHandler struct {
+	service *service.ScenarioService
+}
+
+func NewScenarioHandler(service *service.ScenarioService) *ScenarioHandler {
+	return &ScenarioHandler{service: service}
+}
+
+func (h *ScenarioHandler) GetScenarios(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	scenarios, err := h.service.GetByUserID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, scenarios)
+}
+
+func (h *ScenarioHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.ScenarioInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scenario, err := h.service.Create(userID.(uint), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, scenario)
+}
+
+func (h *ScenarioHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.ScenarioInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scenario, err := h.service.Update(userID.(uint), c.Param("id"), input)
+	if err != nil {
+		respondScenarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, scenario)
+}
+
+func (h *ScenarioHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.service.Delete(userID.(uint), c.Param("id")); err != nil {
+		respondScenarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Сценарий удалён"})
+}
+
+func (h *ScenarioHandler) GetScheduledMessages(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	messages, err := h.service.GetScheduledMessages(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+func (h *ScenarioHandler) GetGuestInfo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	info, err := h.service.GetGuestInfo(userID.(uint), c.Param("id"))
+	if err != nil {
+		respondScenarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *ScenarioHandler) UpdateGuestInfo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.UpdateGuestInfoInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := h.service.UpdateGuestInfo(userID.(uint), c.Param("id"), input)
+	if err != nil {
+		respondScenarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+func respondScenarioError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		status = http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
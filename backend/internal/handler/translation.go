@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type ListingTextHandler struct {
+	service *service.ListingTextService
+}
+
+func NewListingTextHandler(service *service.ListingTextService) *ListingTextHandler {
+	return &ListingTextHandler{service: service}
+}
+
+// DraftDescription пишет черновик описания квартиры с помощью ИИ
+func (h *ListingTextHandler) DraftDescription(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.DraftDescriptionInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation, err := h.service.DraftDescription(c.Request.Context(), userID.(uint), c.Param("id"), input)
+	if err != nil {
+		respondListingTextError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+// Translate переводит описание и правила на выбранные языки черновиками
+func (h *ListingTextHandler) Translate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.TranslateListingInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translations, err := h.service.Translate(c.Request.Context(), userID.(uint), c.Param("id"), input)
+	if err != nil {
+		respondListingTextError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, translations)
+}
+
+func (h *ListingTextHandler) GetTranslations(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	translations, err := h.service.GetTranslations(userID.(uint), c.Param("id"))
+	if err != nil {
+		respondListingTextError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, translations)
+}
+
+func (h *ListingTextHandler) UpdateTranslation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.UpdateTranslationInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation, err := h.service.UpdateTranslation(userID.(uint), c.Param("id"), c.Param("lang"), input)
+	if err != nil {
+		respondListingTextError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+func (h *ListingTextHandler) Publish(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	translation, err := h.service.Publish(userID.(uint), c.Param("id"), c.Param("lang"))
+	if err != nil {
+		respondListingTextError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+func respondListingTextError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrListingTextLimit), errors.Is(err, service.ErrQuotaExceeded):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, service.ErrForbidden):
+		status = http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
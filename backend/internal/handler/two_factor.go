@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type TwoFactorHandler struct {
+	service *service.TwoFactorService
+}
+
+func NewTwoFactorHandler(service *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{service: service}
+}
+
+// Verify - второй шаг входа по промежуточному токену
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	var input model.TwoFactorChallengeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Verify(input, sessionMeta(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signInResponse(&model.SignInResult{Tokens: tokens}, "Вход выполнен успешно"))
+}
+
+// SetupWithChallenge выдаёт секрет, если 2FA обязательна и ещё не подключена
+func (h *TwoFactorHandler) SetupWithChallenge(c *gin.Context) {
+	var input model.TwoFactorChallengeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.service.SetupWithChallenge(input.ChallengeToken)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *TwoFactorHandler) ConfirmWithChallenge(c *gin.Context) {
+	var input model.TwoFactorChallengeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, codes, err := h.service.ConfirmWithChallenge(input, sessionMeta(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	response := signInResponse(&model.SignInResult{Tokens: tokens}, "Двухфакторная аутентификация включена")
+	response["recovery_codes"] = codes.Codes
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	status, err := h.service.Status(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll начинает подключение: секрет и ссылка для QR-кода
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	enrollment, err := h.service.Enroll(userID.(uint))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.TwoFactorCodeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.Confirm(userID.(uint), input.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.TwoFactorCodeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Disable(userID.(uint), input.Code); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Двухфакторная аутентификация отключена"})
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var input model.TwoFactorCodeInput
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(userID.(uint), input.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func respondTwoFactorError(c *gin.Context, err error) {
+	if respondThrottled(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode), errors.Is(err, service.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrChallengeAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled), errors.Is(err, service.ErrTwoFactorNotEnrolling):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/service"
+)
+
+type UsageHandler struct {
+	service *service.UsageService
+}
+
+func NewUsageHandler(service *service.UsageService) *UsageHandler {
+	return &UsageHandler{service: service}
+}
+
+// GetUsage возвращает расход токенов и оценку стоимости за период ?from=&to=
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	report, err := h.service.Report(userID.(uint), c.Query("from"), c.Query("to"))
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
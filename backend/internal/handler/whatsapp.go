@@ -1,73 +1,118 @@
 package handler
 
 import (
-    "net/http"
-    "log"
+	"log"
+	"net/http"
+	"strings"
 
-    "github.com/gin-gonic/gin"
-    "github.com/yourusername/uilet/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/service"
 )
 
 type WhatsAppHandler struct {
-    service *service.WhatsAppService
+	service *service.WhatsAppService
+	outbox  *service.OutboxService
 }
 
-func NewWhatsAppHandler(service *service.WhatsAppService) *WhatsAppHandler {
-    return &WhatsAppHandler{
-        service: service,
-    }
+func NewWhatsAppHandler(service *service.WhatsAppService, outbox *service.OutboxService) *WhatsAppHandler {
+	return &WhatsAppHandler{
+		service: service,
+		outbox:  outbox,
+	}
 }
 
 func (h *WhatsAppHandler) InitiateLogin(c *gin.Context) {
-    userID, _ := c.Get("userID")
+	userID, _ := c.Get("userID")
 
-    qr, err := h.service.InitiateLogin(userID.(uint))
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
+	qr, err := h.service.InitiateLogin(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-    c.JSON(http.StatusOK, gin.H{"qr": qr})
+	c.JSON(http.StatusOK, gin.H{"qr": qr})
+}
+
+func (h *WhatsAppHandler) GetAIConfig(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	config, err := h.service.GetAIConfig(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
 }
 
 func (h *WhatsAppHandler) ConfigureAI(c *gin.Context) {
-    userID, _ := c.Get("userID")
-    var config service.AIConfig
+	userID, _ := c.Get("userID")
+	var input model.UpdateAIConfigInput
 
-    if err := c.BindJSON(&config); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-    if err := h.service.ConfigureAI(userID.(uint), config); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
+	config, err := h.service.ConfigureAI(userID.(uint), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-    c.JSON(http.StatusOK, gin.H{"message": "AI configured successfully"})
+	c.JSON(http.StatusOK, config)
 }
 
 func (h *WhatsAppHandler) TestAI(c *gin.Context) {
-    var input struct {
-        Message string `json:"message" binding:"required"`
-    }
-
-    if err := c.BindJSON(&input); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат запроса"})
-        return
-    }
-
-    log.Printf("Received test message: %s", input.Message)
-
-    response, err := h.service.TestAI(input.Message)
-    if err != nil {
-        log.Printf("Error testing AI: %v", err)
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": "Ошибка при получении ответа от ИИ",
-            "details": err.Error(),
-        })
-        return
-    }
-
-    c.JSON(http.StatusOK, gin.H{"response": response})
-} 
\ No newline at end of file
+	userID, _ := c.Get("userID")
+	var input struct {
+		Message string `json:"message" binding:"required"`
+	}
+
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат запроса"})
+		return
+	}
+
+	response, err := h.service.TestAI(c.Request.Context(), userID.(uint), input.Message)
+	if err != nil {
+		log.Printf("Error testing AI: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Ошибка при получении ответа от ИИ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"response": response})
+}
+
+// GetOutbox возвращает очередь исходящих сообщений, ?status= фильтрует по статусу
+func (h *WhatsAppHandler) GetOutbox(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	messages, err := h.outbox.GetByUserID(userID.(uint), model.OutboundStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// RetryOutbound повторно ставит в очередь сообщение, которое не удалось отправить
+func (h *WhatsAppHandler) RetryOutbound(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.outbox.Retry(userID.(uint), c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Неотправленное сообщение не найдено"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Сообщение поставлено в очередь"})
+}
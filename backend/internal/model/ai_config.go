@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+const (
+	DefaultAIPrompt      = "Вы - помощник по аренде недвижимости. Отвечайте кратко и по делу."
+	DefaultAILanguage    = "ru"
+	DefaultAITemperature = 0.7
+	DefaultAIMaxTokens   = 150
+	DefaultTimezone      = "Asia/Almaty"
+)
+
+// BusinessHours задаёт время, в которое ассистент отвечает гостям.
+// Пустые Start и End означают круглосуточную работу.
+type BusinessHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+// QuietHours - время, когда автоматические сообщения гостю откладываются.
+// Считается по часовому поясу гостя, пустые Start и End отключают тихие часы.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type AIConfig struct {
+	UserID      uint    `json:"-" db:"user_id"`
+	Prompt      string  `json:"prompt" db:"prompt"`
+	Tone        string  `json:"tone" db:"tone"`
+	Language    string  `json:"language" db:"language"`
+	Temperature float32 `json:"temperature" db:"temperature"`
+	MaxTokens   int     `json:"max_tokens" db:"max_tokens"`
+	// Model - пустое значение означает модель, заданную в LLM_MODEL
+	Model         string        `json:"model" db:"model"`
+	BusinessHours BusinessHours `json:"business_hours" db:"business_hours"`
+	QuietHours    QuietHours    `json:"quiet_hours" db:"quiet_hours"`
+	Enabled       bool          `json:"enabled" db:"enabled"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateAIConfigInput - частичное обновление: поле, которого нет в запросе (nil),
+// сохраняет прежнее значение. Пустые строки и нулевые max_tokens возвращают значения по умолчанию.
+type UpdateAIConfigInput struct {
+	Prompt        *string        `json:"prompt"`
+	Tone          *string        `json:"tone"`
+	Language      *string        `json:"language" binding:"omitempty,oneof=ru kk en"`
+	Temperature   *float32       `json:"temperature" binding:"omitempty,min=0,max=2"`
+	MaxTokens     *int           `json:"max_tokens" binding:"omitempty,min=0,max=4096"`
+	Model         *string        `json:"model"`
+	BusinessHours *BusinessHours `json:"business_hours"`
+	QuietHours    *QuietHours    `json:"quiet_hours"`
+	Enabled       *bool          `json:"enabled"`
+}
+
+// DefaultAIConfig возвращает настройки, которые используются,
+// пока владелец ничего не сохранил.
+func DefaultAIConfig(userID uint) *AIConfig {
+	return &AIConfig{
+		UserID:        userID,
+		Prompt:        DefaultAIPrompt,
+		Language:      DefaultAILanguage,
+		Temperature:   DefaultAITemperature,
+		MaxTokens:     DefaultAIMaxTokens,
+		BusinessHours: BusinessHours{Timezone: DefaultTimezone},
+		Enabled:       true,
+	}
+}
+
+type AIConfigRepository interface {
+	GetByUserID(userID uint) (*AIConfig, error)
+	Upsert(config *AIConfig) error
+}
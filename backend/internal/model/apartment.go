@@ -7,6 +7,7 @@ import (
 type Apartment struct {
 	ID             uint            `json:"id" db:"id"`
 	UserID         uint            `json:"user_id" db:"user_id"`
+	OrganizationID uint            `json:"organization_id" db:"organization_id"`
 	Complex        string          `json:"complex" db:"complex"`
 	Rooms          int             `json:"rooms" db:"rooms"`
 	Price          int             `json:"price" db:"price"`
@@ -24,9 +25,13 @@ type Apartment struct {
 	ImageTypes     []string        `json:"image_types" db:"image_types"`
 	ImageCount     int             `json:"image_count" db:"image_count"`
 	Availabilities []Availability  `json:"availabilities"`
+	// Translations - опубликованные владельцем описания и правила на разных языках
+	Translations []ApartmentTranslation `json:"translations,omitempty"`
 }
 
 type CreateApartmentInput struct {
+	// OrganizationID - куда добавить квартиру; по умолчанию личная организация владельца
+	OrganizationID uint                `json:"organization_id"`
 	Complex        string              `json:"complex" binding:"required"`
 	Rooms          int                 `json:"rooms" binding:"required,min=1"`
 	Price          int                 `json:"price" binding:"required,min=0"`
@@ -58,13 +63,16 @@ type UpdateApartmentInput struct {
 type ApartmentRepository interface {
 	Create(apartment *Apartment) error
 	GetByUserID(userID uint) ([]Apartment, error)
+	GetActiveByUserID(userID uint, from, to time.Time) ([]Apartment, error)
 	GetByID(userID uint, apartmentID string) (*Apartment, error)
-	Update(userID uint, apartmentID string, input *UpdateApartmentInput) error
-	Delete(userID uint, apartmentID string) error
-	AddImages(userID uint, apartmentID string, imageData [][]byte, imageTypes []string) error
+	GetAccess(userID uint, apartmentID string) (*ApartmentAccess, error)
+	Update(apartmentID string, input *UpdateApartmentInput) error
+	Delete(apartmentID string) error
+	AddImages(apartmentID string, imageData [][]byte, imageTypes []string) error
 	GetImage(apartmentID string, index int) ([]byte, string, error)
-	DeleteImage(userID uint, apartmentID string, index int) error
-	ToggleActive(userID uint, apartmentID string) error
+	DeleteImage(apartmentID string, index int) error
+	ToggleActive(apartmentID string) error
 	DeleteAvailabilities(apartmentID uint) error
 	CreateAvailability(apartmentID uint, availability *Availability) error
+	HasConflict(apartmentID uint, start, end time.Time) (bool, error)
 }
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Права API-ключа. Ключ работает только на маршрутах, для которых указано право.
+const (
+	ScopeApartmentsRead  = "apartments:read"
+	ScopeApartmentsWrite = "apartments:write"
+	ScopeBookingsRead    = "bookings:read"
+	ScopeBookingsWrite   = "bookings:write"
+)
+
+var APIKeyScopes = []string{ScopeApartmentsRead, ScopeApartmentsWrite, ScopeBookingsRead, ScopeBookingsWrite}
+
+// APIKey - личный ключ владельца для интеграций. Сам ключ показывается один раз
+// при создании, в базе хранится только хеш секрета.
+type APIKey struct {
+	ID         uint       `json:"id" db:"id"`
+	UserID     uint       `json:"-" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip,omitempty" db:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreatedAPIKey - ответ на создание ключа, единственный раз, когда виден Key
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type CreateAPIKeyInput struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=apartments:read apartments:write bookings:read bookings:write"`
+	// ExpiresInDays - срок действия ключа; 0 - бессрочный
+	ExpiresInDays int `json:"expires_in_days" binding:"min=0,max=365"`
+}
+
+type APIKeyRepository interface {
+	Create(secretHash string, key *APIKey) error
+	GetByUserID(userID uint) ([]APIKey, error)
+	CountActive(userID uint) (int, error)
+	GetByPrefix(prefix string) (*APIKey, string, error)
+	Touch(id uint, ip string) error
+	Revoke(userID, id uint) error
+}
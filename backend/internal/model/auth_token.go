@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Назначение одноразового токена из письма
+const (
+	TokenPasswordReset     = "password_reset"
+	TokenEmailVerification = "email_verification"
+	// TokenEmailChange - подтверждение нового адреса; AuthToken.Email - новый адрес
+	TokenEmailChange = "email_change"
+)
+
+// Языки писем владельцам
+var EmailLanguages = []string{"ru", "kk"}
+
+// AuthToken - одноразовый токен из письма. Сам токен не хранится, только его хеш.
+type AuthToken struct {
+	UserID    uint      `db:"user_id"`
+	Purpose   string    `db:"purpose"`
+	Email     string    `db:"email"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type ForgotPasswordInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Language string `json:"language" binding:"omitempty,oneof=ru kk"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationInput struct {
+	Language string `json:"language" binding:"omitempty,oneof=ru kk"`
+}
+
+type AuthTokenRepository interface {
+	Create(tokenHash string, token *AuthToken) error
+	Consume(tokenHash, purpose string) (*AuthToken, error)
+}
@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+type BookingRequestStatus string
+
+const (
+	BookingPending   BookingRequestStatus = "pending"
+	BookingConfirmed BookingRequestStatus = "confirmed"
+	BookingRejected  BookingRequestStatus = "rejected"
+	BookingCancelled BookingRequestStatus = "cancelled"
+)
+
+// BookingRequest - заявка гостя, которую владелец должен подтвердить.
+// После подтверждения в календаре квартиры появляется занятый период.
+type BookingRequest struct {
+	ID             uint                 `json:"id" db:"id"`
+	UserID         uint                 `json:"user_id" db:"user_id"`
+	ApartmentID    uint                 `json:"apartment_id" db:"apartment_id"`
+	ConversationID uint                 `json:"conversation_id,omitempty" db:"conversation_id"`
+	GuestName      string               `json:"guest_name" db:"guest_name"`
+	GuestPhone     string               `json:"guest_phone" db:"guest_phone"`
+	DateStart      time.Time            `json:"date_start" db:"date_start"`
+	DateEnd        time.Time            `json:"date_end" db:"date_end"`
+	Nights         int                  `json:"nights" db:"nights"`
+	TotalPrice     int                  `json:"total_price" db:"total_price"`
+	Status         BookingRequestStatus `json:"status" db:"status"`
+	Source         string               `json:"source" db:"source"`
+	AvailabilityID uint                 `json:"availability_id,omitempty" db:"availability_id"`
+	CreatedAt      time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+type CreateBookingRequestInput struct {
+	ApartmentID uint   `json:"apartment_id" binding:"required"`
+	DateStart   string `json:"date_start" binding:"required"`
+	DateEnd     string `json:"date_end" binding:"required"`
+	GuestName   string `json:"guest_name"`
+	GuestPhone  string `json:"guest_phone"`
+}
+
+type BookingRequestRepository interface {
+	Create(request *BookingRequest) error
+	GetByUserID(userID uint, status BookingRequestStatus) ([]BookingRequest, error)
+	GetByID(userID uint, requestID string) (*BookingRequest, error)
+	GetLatestForGuest(userID uint, conversationID uint, guestPhone string) (*BookingRequest, error)
+	UpdateStatus(userID uint, requestID uint, status BookingRequestStatus, availabilityID uint) error
+}
+
+// ToolCall - запись журнала о вызове инструмента ИИ-агентом
+type ToolCall struct {
+	ID             uint      `json:"id" db:"id"`
+	UserID         uint      `json:"user_id" db:"user_id"`
+	ConversationID uint      `json:"conversation_id,omitempty" db:"conversation_id"`
+	Tool           string    `json:"tool" db:"tool"`
+	Arguments      string    `json:"arguments" db:"arguments"`
+	Result         string    `json:"result" db:"result"`
+	Error          string    `json:"error,omitempty" db:"error"`
+	DurationMs     int       `json:"duration_ms" db:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type ToolCallRepository interface {
+	Create(call *ToolCall) error
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+type ConsentStatus string
+
+const (
+	ConsentOptedIn  ConsentStatus = "opted_in"
+	ConsentOptedOut ConsentStatus = "opted_out"
+)
+
+// ConsentSource - откуда пришло изменение согласия
+type ConsentSource string
+
+const (
+	// ConsentFromFirstMessage - гость сам написал первым
+	ConsentFromFirstMessage ConsentSource = "first_message"
+	// ConsentFromKeyword - гость написал STOP или START
+	ConsentFromKeyword ConsentSource = "guest_keyword"
+	ConsentFromOwner   ConsentSource = "owner"
+)
+
+// GuestConsent - текущее согласие гостя на автоматические сообщения владельца
+type GuestConsent struct {
+	UserID     uint          `json:"-" db:"user_id"`
+	GuestPhone string        `json:"guest_phone" db:"guest_phone"`
+	Status     ConsentStatus `json:"status" db:"status"`
+	UpdatedAt  time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+type ConsentLogEntry struct {
+	ID         uint          `json:"id" db:"id"`
+	UserID     uint          `json:"-" db:"user_id"`
+	GuestPhone string        `json:"guest_phone" db:"guest_phone"`
+	Status     ConsentStatus `json:"status" db:"status"`
+	Source     ConsentSource `json:"source" db:"source"`
+	Message    string        `json:"message" db:"message"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+}
+
+// UpdateConsentInput - владелец вручную меняет согласие гостя, например по звонку
+type UpdateConsentInput struct {
+	Status ConsentStatus `json:"status" binding:"required,oneof=opted_in opted_out"`
+	Note   string        `json:"note"`
+}
+
+type ConsentRepository interface {
+	Get(userID uint, guestPhone string) (*GuestConsent, error)
+	Set(entry *ConsentLogEntry) (bool, error)
+	GetByUserID(userID uint) ([]GuestConsent, error)
+	GetLog(userID uint, guestPhone string) ([]ConsentLogEntry, error)
+}
@@ -0,0 +1,98 @@
+package model
+
+import "time"
+
+type MessageRole string
+
+const (
+	RoleGuest     MessageRole = "guest"
+	RoleAssistant MessageRole = "assistant"
+	RoleOwner     MessageRole = "owner"
+)
+
+// MessageKind - тип сообщения в переписке
+type MessageKind string
+
+const (
+	KindText     MessageKind = "text"
+	KindImage    MessageKind = "image"
+	KindAudio    MessageKind = "audio"
+	KindLocation MessageKind = "location"
+)
+
+type ConversationMode string
+
+const (
+	// ModeBot - ИИ-ассистент отвечает гостю сам
+	ModeBot ConversationMode = "bot"
+	// ModeHuman - владелец перехватил чат, бот молчит, пока его не вернут
+	ModeHuman ConversationMode = "human"
+	// ModePaused - бот молчит до PausedUntil, например после сообщения владельца с телефона
+	ModePaused ConversationMode = "paused"
+)
+
+type Conversation struct {
+	ID               uint                  `json:"id" db:"id"`
+	UserID           uint                  `json:"user_id" db:"user_id"`
+	GuestPhone       string                `json:"guest_phone" db:"guest_phone"`
+	Summary          string                `json:"summary" db:"summary"`
+	SummarizedUntil  uint                  `json:"-" db:"summarized_until"`
+	Mode             ConversationMode      `json:"mode" db:"mode"`
+	PausedUntil      *time.Time            `json:"paused_until,omitempty" db:"paused_until"`
+	FailureCount     int                   `json:"-" db:"failure_count"`
+	EscalationReason string                `json:"escalation_reason,omitempty" db:"escalation_reason"`
+	EscalatedAt      *time.Time            `json:"escalated_at,omitempty" db:"escalated_at"`
+	LastMessageAt    time.Time             `json:"last_message_at" db:"last_message_at"`
+	CreatedAt        time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at" db:"updated_at"`
+	Messages         []ConversationMessage `json:"messages,omitempty"`
+}
+
+// BotActive сообщает, должен ли ассистент отвечать в переписке в момент now
+func (c *Conversation) BotActive(now time.Time) bool {
+	switch c.Mode {
+	case ModeHuman:
+		return false
+	case ModePaused:
+		return c.PausedUntil != nil && now.After(*c.PausedUntil)
+	}
+	return true
+}
+
+type SetConversationModeInput struct {
+	Mode ConversationMode `json:"mode" binding:"required,oneof=bot human paused"`
+}
+
+// OwnerReplyInput - ответ владельца: свой текст или шаблон из библиотеки.
+// IdempotencyKey защищает от повторной отправки при повторе запроса.
+type OwnerReplyInput struct {
+	Text           string `json:"text"`
+	TemplateID     *uint  `json:"template_id"`
+	Language       string `json:"language"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type ConversationMessage struct {
+	ID             uint        `json:"id" db:"id"`
+	ConversationID uint        `json:"conversation_id" db:"conversation_id"`
+	Role           MessageRole `json:"role" db:"role"`
+	Content        string      `json:"content" db:"content"`
+	Kind           MessageKind `json:"kind" db:"kind"`
+	MediaID        *uint       `json:"media_id,omitempty" db:"media_id"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+}
+
+type ConversationRepository interface {
+	GetOrCreate(userID uint, guestPhone string) (*Conversation, error)
+	GetByUserID(userID uint) ([]Conversation, error)
+	GetByID(userID uint, conversationID string) (*Conversation, error)
+	AddMessage(message *ConversationMessage) error
+	GetMessages(conversationID uint) ([]ConversationMessage, error)
+	GetRecentMessages(conversationID uint, afterID uint, limit int) ([]ConversationMessage, error)
+	CountMessagesAfter(conversationID uint, afterID uint) (int, error)
+	UpdateSummary(conversationID uint, summary string, summarizedUntil uint) error
+	SetMode(conversationID uint, mode ConversationMode, pausedUntil *time.Time) error
+	Escalate(conversationID uint, reason string) error
+	IncrementFailures(conversationID uint) (int, error)
+	ResetFailures(conversationID uint) error
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EvalSuite - версионированный набор записанных переписок для офлайн-проверки ассистента
+type EvalSuite struct {
+	Version string     `json:"version"`
+	Cases   []EvalCase `json:"cases"`
+}
+
+// EvalTurn - сообщение переписки, role: guest или assistant
+type EvalTurn struct {
+	Role    MessageRole `json:"role"`
+	Content string      `json:"content"`
+}
+
+// EvalCase - переписка, на последнее сообщение гостя в которой ассистент должен ответить
+type EvalCase struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	// Date - "сегодня" для ассистента в формате ГГГГ-ММ-ДД, чтобы прогоны были воспроизводимы
+	Date       string      `json:"date"`
+	Apartments []Apartment `json:"apartments"`
+	Turns      []EvalTurn  `json:"turns"`
+	// RecordedTools и RecordedReply - инструменты, которые ассистент вызвал в действительности,
+	// и его ответ. Фейковая модель повторяет их.
+	RecordedTools []EvalToolCall  `json:"recorded_tools,omitempty"`
+	RecordedReply string          `json:"recorded_reply,omitempty"`
+	Expect        EvalExpectation `json:"expect"`
+}
+
+type EvalToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// EvalExpectation - что должно быть в ответе. Пустые поля не проверяются.
+type EvalExpectation struct {
+	// Price - сумма в тенге, которую ответ должен назвать
+	Price int `json:"price,omitempty"`
+	// Available - должен ли ответ сказать, что квартира свободна
+	Available *bool `json:"available,omitempty"`
+	// Language - язык ответа (ru, kk, en), по умолчанию язык последнего сообщения гостя
+	Language       string   `json:"language,omitempty"`
+	MustMention    []string `json:"must_mention,omitempty"`
+	MustNotMention []string `json:"must_not_mention,omitempty"`
+}
+
+type EvalCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type EvalCaseResult struct {
+	CaseID string      `json:"case_id"`
+	Reply  string      `json:"reply"`
+	Checks []EvalCheck `json:"checks"`
+	// JudgeScore - оценка модели-судьи от 1 до 5, 0 - судья не запускался
+	JudgeScore int     `json:"judge_score,omitempty"`
+	Score      float64 `json:"score"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// EvalRun - результат прогона набора с одной версией настроек ассистента
+type EvalRun struct {
+	Label        string           `json:"label"`
+	SuiteVersion string           `json:"suite_version"`
+	Model        string           `json:"model"`
+	Results      []EvalCaseResult `json:"results"`
+	Score        float64          `json:"score"`
+	StartedAt    time.Time        `json:"started_at"`
+}
+
+// EvalCaseDiff - изменение результата одной переписки между версиями
+type EvalCaseDiff struct {
+	CaseID         string   `json:"case_id"`
+	BaselineScore  float64  `json:"baseline_score"`
+	CandidateScore float64  `json:"candidate_score"`
+	Regressed      []string `json:"regressed,omitempty"`
+	Fixed          []string `json:"fixed,omitempty"`
+	BaselineReply  string   `json:"baseline_reply"`
+	CandidateReply string   `json:"candidate_reply"`
+}
+
+// EvalDiff сравнивает прогон новой версии промпта с прежней
+type EvalDiff struct {
+	Baseline  *EvalRun       `json:"baseline"`
+	Candidate *EvalRun       `json:"candidate"`
+	Cases     []EvalCaseDiff `json:"cases"`
+}
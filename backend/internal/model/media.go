@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// MessageMedia - вложение в переписке. Файл хранится в базе,
+// в сообщении переписки остаётся его текстовое описание для ИИ.
+type MessageMedia struct {
+	ID             uint        `json:"id" db:"id"`
+	ConversationID uint        `json:"conversation_id" db:"conversation_id"`
+	Kind           MessageKind `json:"kind" db:"kind"`
+	MimeType       string      `json:"mime_type" db:"mime_type"`
+	Data           []byte      `json:"-" db:"data"`
+	Caption        string      `json:"caption,omitempty" db:"caption"`
+	Latitude       *float64    `json:"latitude,omitempty" db:"latitude"`
+	Longitude      *float64    `json:"longitude,omitempty" db:"longitude"`
+	Transcript     string      `json:"transcript,omitempty" db:"transcript"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+}
+
+type MessageMediaRepository interface {
+	Create(media *MessageMedia) error
+	GetByID(userID uint, conversationID string, mediaID string) (*MessageMedia, error)
+}
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// TemplateCategory - категория шаблона по классификации WhatsApp Business
+type TemplateCategory string
+
+const (
+	TemplateUtility        TemplateCategory = "utility"
+	TemplateMarketing      TemplateCategory = "marketing"
+	TemplateAuthentication TemplateCategory = "authentication"
+)
+
+// TemplateLanguages - языки, на которых владелец может вести шаблоны
+var TemplateLanguages = []string{"ru", "kk", "en"}
+
+// MessageTemplate - многоразовый шаблон сообщения гостю.
+// Bodies содержит варианты текста по кодам языков.
+type MessageTemplate struct {
+	ID        uint              `json:"id" db:"id"`
+	UserID    uint              `json:"user_id" db:"user_id"`
+	Name      string            `json:"name" db:"name"`
+	Category  TemplateCategory  `json:"category" db:"category"`
+	Bodies    map[string]string `json:"bodies" db:"bodies"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+type MessageTemplateInput struct {
+	Name     string            `json:"name" binding:"required"`
+	Category TemplateCategory  `json:"category" binding:"required"`
+	Bodies   map[string]string `json:"bodies" binding:"required"`
+}
+
+type PreviewTemplateInput struct {
+	BookingRequestID uint   `json:"booking_request_id" binding:"required"`
+	Language         string `json:"language"`
+}
+
+// TemplatePreview - текст шаблона с подставленными данными заявки
+type TemplatePreview struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// TemplateVariable - переменная, доступная в тексте шаблона как {name}
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type MessageTemplateRepository interface {
+	Create(template *MessageTemplate) error
+	GetByUserID(userID uint) ([]MessageTemplate, error)
+	GetByID(userID uint, templateID string) (*MessageTemplate, error)
+	Update(template *MessageTemplate) error
+	Delete(userID uint, templateID string) error
+	IsUsed(templateID uint) (bool, error)
+}
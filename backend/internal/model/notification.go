@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+const (
+	NotificationEscalation     = "escalation"
+	NotificationBookingRequest = "booking_request"
+	NotificationMessageFailed  = "message_failed"
+	NotificationGuardrail      = "guardrail"
+	NotificationSecurity       = "security"
+	NotificationTeam           = "team"
+)
+
+// NotificationKinds - виды уведомлений, которые владелец может выключить в профиле
+var NotificationKinds = []string{
+	NotificationEscalation,
+	NotificationBookingRequest,
+	NotificationMessageFailed,
+	NotificationGuardrail,
+	NotificationTeam,
+}
+
+type Notification struct {
+	ID        uint              `json:"id" db:"id"`
+	UserID    uint              `json:"user_id" db:"user_id"`
+	Type      string            `json:"type" db:"type"`
+	Title     string            `json:"title" db:"title"`
+	Body      string            `json:"body" db:"body"`
+	Data      map[string]string `json:"data" db:"data"`
+	ReadAt    *time.Time        `json:"read_at" db:"read_at"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+}
+
+type NotificationRepository interface {
+	Create(notification *Notification) error
+	GetByUserID(userID uint, unreadOnly bool) ([]Notification, error)
+	MarkRead(userID uint, notificationID string) error
+}
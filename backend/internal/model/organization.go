@@ -0,0 +1,138 @@
+package model
+
+import "time"
+
+// Роли участников организации
+const (
+	// MemberOwner - владелец: всё, включая участников и настройки организации
+	MemberOwner = "owner"
+	// MemberManager - менеджер: заводит и редактирует квартиры, календарь и тексты
+	MemberManager = "manager"
+	// MemberHousekeeper - горничная: видит квартиры, календарь и сведения для заселения
+	MemberHousekeeper = "housekeeper"
+	// MemberViewer - наблюдатель, например бухгалтер: только просмотр
+	MemberViewer = "viewer"
+)
+
+// Permission - действие, которое проверяется перед изменением данных организации
+type Permission string
+
+const (
+	PermApartmentView   Permission = "apartment.view"
+	PermApartmentCreate Permission = "apartment.create"
+	PermApartmentEdit   Permission = "apartment.edit"
+	PermApartmentDelete Permission = "apartment.delete"
+	PermMembersManage   Permission = "members.manage"
+	PermOrgSettings     Permission = "organization.settings"
+)
+
+var rolePermissions = map[string][]Permission{
+	MemberOwner: {
+		PermApartmentView, PermApartmentCreate, PermApartmentEdit, PermApartmentDelete,
+		PermMembersManage, PermOrgSettings,
+	},
+	MemberManager:     {PermApartmentView, PermApartmentCreate, PermApartmentEdit},
+	MemberHousekeeper: {PermApartmentView},
+	MemberViewer:      {PermApartmentView},
+}
+
+// RoleCan - разрешено ли роли действие
+func RoleCan(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type Organization struct {
+	ID               uint      `json:"id" db:"id"`
+	Name             string    `json:"name" db:"name"`
+	OwnerID          uint      `json:"owner_id" db:"owner_id"`
+	RequireTwoFactor bool      `json:"require_two_factor" db:"require_two_factor"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	// Role - роль запросившего пользователя
+	Role string `json:"role,omitempty"`
+}
+
+type OrganizationMember struct {
+	OrganizationID uint      `json:"organization_id" db:"organization_id"`
+	UserID         uint      `json:"user_id" db:"user_id"`
+	Email          string    `json:"email,omitempty" db:"email"`
+	Phone          string    `json:"phone,omitempty" db:"phone"`
+	Role           string    `json:"role" db:"role"`
+	TwoFactor      bool      `json:"two_factor_enabled"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type OrganizationInvitation struct {
+	ID             uint       `json:"id" db:"id"`
+	OrganizationID uint       `json:"organization_id" db:"organization_id"`
+	Email          string     `json:"email,omitempty" db:"email"`
+	Phone          string     `json:"phone,omitempty" db:"phone"`
+	Role           string     `json:"role" db:"role"`
+	InvitedBy      uint       `json:"invited_by" db:"invited_by"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	// Link - ссылка-приглашение, возвращается один раз при создании,
+	// чтобы владелец мог переслать её сам, например в WhatsApp
+	Link string `json:"link,omitempty"`
+}
+
+// ApartmentAccess - роль пользователя в организации, которой принадлежит квартира
+type ApartmentAccess struct {
+	ApartmentID    uint
+	OrganizationID uint
+	// OwnerID - аккаунт владельца организации: его WhatsApp, ИИ и календарь обслуживают гостей
+	OwnerID uint
+	Role    string
+}
+
+func (a *ApartmentAccess) Can(permission Permission) bool {
+	return RoleCan(a.Role, permission)
+}
+
+type CreateOrganizationInput struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type UpdateOrganizationInput struct {
+	Name             string `json:"name" binding:"required,max=255"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
+}
+
+// InviteMemberInput - нужен email или телефон
+type InviteMemberInput struct {
+	Email string `json:"email" binding:"omitempty,email"`
+	Phone string `json:"phone"`
+	Role  string `json:"role" binding:"required,oneof=manager housekeeper viewer"`
+	// Language - язык письма: ru или kk
+	Language string `json:"language" binding:"omitempty,oneof=ru kk"`
+}
+
+type UpdateMemberInput struct {
+	Role string `json:"role" binding:"required,oneof=manager housekeeper viewer"`
+}
+
+type AcceptInvitationInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type OrganizationRepository interface {
+	Create(org *Organization) error
+	GetForUser(userID uint) ([]Organization, error)
+	GetMembership(userID, organizationID uint) (*Organization, error)
+	GetPersonal(userID uint) (*Organization, error)
+	Update(org *Organization) error
+	GetMembers(organizationID uint) ([]OrganizationMember, error)
+	SetRole(organizationID, userID uint, role string) error
+	RemoveMember(organizationID, userID uint) error
+	CreateInvitation(tokenHash string, invitation *OrganizationInvitation) error
+	GetInvitations(organizationID uint) ([]OrganizationInvitation, error)
+	DeleteInvitation(organizationID, invitationID uint) error
+	AcceptInvitation(tokenHash string, userID uint) (*OrganizationInvitation, error)
+	RequiresTwoFactor(userID uint) (bool, error)
+}
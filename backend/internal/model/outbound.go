@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+type OutboundStatus string
+
+const (
+	OutboundQueued    OutboundStatus = "queued"
+	OutboundSending   OutboundStatus = "sending"
+	OutboundSent      OutboundStatus = "sent"
+	OutboundDelivered OutboundStatus = "delivered"
+	OutboundRead      OutboundStatus = "read"
+	OutboundFailed    OutboundStatus = "failed"
+)
+
+// OutboundMessage - исходящее сообщение в очереди отправки. Body - текст,
+// подпись к фото или адрес точки на карте.
+// IdempotencyKey не даёт поставить одно и то же сообщение в очередь дважды.
+type OutboundMessage struct {
+	ID             uint           `json:"id" db:"id"`
+	UserID         uint           `json:"user_id" db:"user_id"`
+	GuestPhone     string         `json:"guest_phone" db:"guest_phone"`
+	Kind           MessageKind    `json:"kind" db:"kind"`
+	Body           string         `json:"body" db:"body"`
+	Data           []byte         `json:"-" db:"data"`
+	MimeType       string         `json:"mime_type,omitempty" db:"mime_type"`
+	Latitude       *float64       `json:"latitude,omitempty" db:"latitude"`
+	Longitude      *float64       `json:"longitude,omitempty" db:"longitude"`
+	LocationName   string         `json:"location_name,omitempty" db:"location_name"`
+	IdempotencyKey string         `json:"idempotency_key" db:"idempotency_key"`
+	Status         OutboundStatus `json:"status" db:"status"`
+	Attempts       int            `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      string         `json:"last_error,omitempty" db:"last_error"`
+	WhatsAppID     string         `json:"whatsapp_id,omitempty" db:"whatsapp_id"`
+	SentAt         *time.Time     `json:"sent_at,omitempty" db:"sent_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty" db:"delivered_at"`
+	ReadAt         *time.Time     `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+type OutboundRepository interface {
+	Enqueue(message *OutboundMessage) (bool, error)
+	GetByUserID(userID uint, status OutboundStatus) ([]OutboundMessage, error)
+	ClaimDue(limit int) ([]OutboundMessage, error)
+	MarkSent(messageID uint, whatsAppID string) error
+	Retry(messageID uint, nextAttemptAt time.Time, lastError string) error
+	Defer(messageID uint, nextAttemptAt time.Time) error
+	Fail(messageID uint, lastError string) error
+	Requeue(userID uint, messageID string) error
+	UpdateReceipt(userID uint, whatsAppID string, status OutboundStatus) error
+	FailStale(olderThan time.Time) (int64, error)
+}
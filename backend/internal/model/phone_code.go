@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// PhoneCode - одноразовый код входа по номеру телефона
+type PhoneCode struct {
+	ID        uint      `db:"id"`
+	Phone     string    `db:"phone"`
+	Channel   string    `db:"channel"`
+	CodeHash  string    `db:"code_hash"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type RequestPhoneCodeInput struct {
+	Phone string `json:"phone" binding:"required"`
+	// Channel - whatsapp (по умолчанию) или sms
+	Channel  string `json:"channel" binding:"omitempty,oneof=whatsapp sms"`
+	Language string `json:"language" binding:"omitempty,oneof=ru kk"`
+}
+
+type VerifyPhoneCodeInput struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// PhoneCodeSent - куда ушёл код и когда можно запросить новый
+type PhoneCodeSent struct {
+	Phone     string `json:"phone"`
+	Channel   string `json:"channel"`
+	ExpiresIn int    `json:"expires_in"`
+	ResendIn  int    `json:"resend_in"`
+}
+
+type PhoneCodeRepository interface {
+	Create(code *PhoneCode) error
+	GetLatest(phone string) (*PhoneCode, error)
+	CountSince(phone string, since time.Time) (int, error)
+	GetActive(phone string) (*PhoneCode, error)
+	AddAttempt(id uint) (int, error)
+	MarkUsed(id uint) error
+}
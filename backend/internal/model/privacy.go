@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AccountDeletionGracePeriod - сколько аккаунт ждёт удаления после запроса.
+// В это время владелец может передумать и отменить удаление.
+const AccountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// DataExport - данные владельца или гостя по разделам: имя раздела -> JSON-массив записей.
+// В архив каждый раздел попадает отдельным файлом <раздел>.json.
+type DataExport map[string]json.RawMessage
+
+type DeleteAccountInput struct {
+	// Password обязателен, если у аккаунта есть пароль
+	Password string `json:"password"`
+}
+
+type AccountDeletion struct {
+	ScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+type PrivacyRepository interface {
+	ExportAccount(userID uint) (DataExport, error)
+	ExportGuest(userID uint, phones []string) (DataExport, error)
+	ScheduleDeletion(userID uint, at time.Time) error
+	CancelDeletion(userID uint) error
+	GetDueDeletions(limit int) ([]uint, error)
+	EraseAccount(userID uint) error
+	EraseGuest(userID uint, phones []string) (int64, error)
+}
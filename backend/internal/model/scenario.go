@@ -0,0 +1,111 @@
+package model
+
+import "time"
+
+type ScenarioTrigger string
+
+const (
+	// TriggerBookingConfirmed - сразу после подтверждения заявки владельцем
+	TriggerBookingConfirmed ScenarioTrigger = "booking_confirmed"
+	// TriggerBeforeCheckIn - за OffsetHours часов до времени заезда
+	TriggerBeforeCheckIn ScenarioTrigger = "before_check_in"
+	// TriggerCheckOutMorning - утром в день выезда, в SendTime
+	TriggerCheckOutMorning ScenarioTrigger = "check_out_morning"
+	// TriggerAfterCheckOut - через OffsetHours часов после выезда, например с просьбой об отзыве
+	TriggerAfterCheckOut ScenarioTrigger = "after_check_out"
+)
+
+// Scenario - сообщение гостю, которое отправляется по событию бронирования.
+// Текст задаётся в Template или берётся из шаблона библиотеки TemplateID.
+// В тексте можно использовать переменные вида {guest_name}.
+type Scenario struct {
+	ID          uint            `json:"id" db:"id"`
+	UserID      uint            `json:"user_id" db:"user_id"`
+	Name        string          `json:"name" db:"name"`
+	Trigger     ScenarioTrigger `json:"trigger" db:"trigger"`
+	OffsetHours int             `json:"offset_hours" db:"offset_hours"`
+	SendTime    string          `json:"send_time" db:"send_time"`
+	Template    string          `json:"template" db:"template"`
+	TemplateID  *uint           `json:"template_id,omitempty" db:"template_id"`
+	Enabled     bool            `json:"enabled" db:"enabled"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+type ScenarioInput struct {
+	Name        string          `json:"name" binding:"required"`
+	Trigger     ScenarioTrigger `json:"trigger" binding:"required"`
+	OffsetHours int             `json:"offset_hours" binding:"min=0"`
+	SendTime    string          `json:"send_time"`
+	Template    string          `json:"template"`
+	TemplateID  *uint           `json:"template_id"`
+	Enabled     bool            `json:"enabled"`
+}
+
+// ApartmentGuestInfo - сведения для заселения гостя. Код от двери хранится
+// отдельно от объявления и не передаётся ИИ-ассистенту.
+type ApartmentGuestInfo struct {
+	ApartmentID  uint      `json:"apartment_id" db:"apartment_id"`
+	Address      string    `json:"address" db:"address"`
+	Complex      string    `json:"complex" db:"complex"`
+	CheckInTime  string    `json:"check_in_time" db:"check_in_time"`
+	CheckOutTime string    `json:"check_out_time" db:"check_out_time"`
+	DoorCode     string    `json:"door_code" db:"door_code"`
+	Instructions string    `json:"instructions" db:"instructions"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type UpdateGuestInfoInput struct {
+	CheckInTime  string `json:"check_in_time"`
+	CheckOutTime string `json:"check_out_time"`
+	DoorCode     string `json:"door_code"`
+	Instructions string `json:"instructions"`
+}
+
+type ScheduledMessageStatus string
+
+const (
+	ScheduledPending   ScheduledMessageStatus = "pending"
+	ScheduledSending   ScheduledMessageStatus = "sending"
+	ScheduledSent      ScheduledMessageStatus = "sent"
+	ScheduledFailed    ScheduledMessageStatus = "failed"
+	ScheduledCancelled ScheduledMessageStatus = "cancelled"
+)
+
+// ScheduledMessage - запланированная отправка сценария по конкретной заявке.
+// Текст подставляется в момент отправки, чтобы учесть последние правки владельца.
+type ScheduledMessage struct {
+	ID               uint                   `json:"id" db:"id"`
+	UserID           uint                   `json:"user_id" db:"user_id"`
+	ScenarioID       uint                   `json:"scenario_id" db:"scenario_id"`
+	BookingRequestID uint                   `json:"booking_request_id" db:"booking_request_id"`
+	GuestPhone       string                 `json:"guest_phone" db:"guest_phone"`
+	SendAt           time.Time              `json:"send_at" db:"send_at"`
+	Status           ScheduledMessageStatus `json:"status" db:"status"`
+	Attempts         int                    `json:"attempts" db:"attempts"`
+	Body             string                 `json:"body,omitempty" db:"body"`
+	LastError        string                 `json:"last_error,omitempty" db:"last_error"`
+	SentAt           *time.Time             `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt        time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+type ScenarioRepository interface {
+	Create(scenario *Scenario) error
+	GetByUserID(userID uint) ([]Scenario, error)
+	GetByID(userID uint, scenarioID string) (*Scenario, error)
+	Update(scenario *Scenario) error
+	Delete(userID uint, scenarioID string) error
+	GetGuestInfo(userID uint, apartmentID string) (*ApartmentGuestInfo, error)
+	UpsertGuestInfo(info *ApartmentGuestInfo) error
+}
+
+type ScheduledMessageRepository interface {
+	Schedule(message *ScheduledMessage) (bool, error)
+	GetByUserID(userID uint) ([]ScheduledMessage, error)
+	ClaimDue(limit int) ([]ScheduledMessage, error)
+	MarkSent(messageID uint, body string) error
+	Reschedule(messageID uint, sendAt time.Time, lastError string) error
+	Finish(messageID uint, status ScheduledMessageStatus, lastError string) error
+	FailStale(olderThan time.Time) (int64, error)
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Причины отзыва сессии
+const (
+	RevokeLogout     = "logout"
+	RevokeLogoutAll  = "logout_all"
+	RevokeByOwner    = "revoked"
+	RevokeTokenReuse = "token_reuse"
+)
+
+// Session - вход с одного устройства
+type Session struct {
+	ID         string    `json:"id" db:"id"`
+	UserID     uint      `json:"-" db:"user_id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent" db:"user_agent"`
+	IP         string    `json:"ip" db:"ip"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+	// Current - сессия, из которой пришёл запрос
+	Current bool `json:"current"`
+}
+
+// SessionMeta - откуда выполняется вход или обновление токена
+type SessionMeta struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenPair - короткий access-токен и refresh-токен для его обновления
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn - время жизни access-токена в секундах
+	ExpiresIn int `json:"expires_in"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type SessionRepository interface {
+	Create(session *Session, tokenHash string, tokenExpiresAt time.Time) error
+	Rotate(oldHash, newHash string, expiresAt time.Time, meta SessionMeta) (*Session, bool, error)
+	Touch(userID uint, sessionID string) error
+	GetActiveByUserID(userID uint) ([]Session, error)
+	Revoke(userID uint, sessionID, reason string) error
+	RevokeByToken(tokenHash, reason string) (*Session, error)
+	RevokeAll(userID uint, reason string) ([]string, error)
+}
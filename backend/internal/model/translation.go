@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// ListingLanguages - языки, на которых публикуются описания квартир
+var ListingLanguages = []string{"ru", "kk", "en"}
+
+type TranslationStatus string
+
+const (
+	TranslationDraft     TranslationStatus = "draft"
+	TranslationPublished TranslationStatus = "published"
+)
+
+// ApartmentTranslation - описание и правила квартиры на одном языке
+type ApartmentTranslation struct {
+	ApartmentID uint              `json:"apartment_id" db:"apartment_id"`
+	Language    string            `json:"language" db:"language"`
+	Description string            `json:"description" db:"description"`
+	Rules       string            `json:"rules" db:"rules"`
+	Status      TranslationStatus `json:"status" db:"status"`
+	// Generated - текст написан ИИ и владелец его ещё не правил
+	Generated bool      `json:"generated" db:"generated"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type DraftDescriptionInput struct {
+	Language string `json:"language" binding:"omitempty,oneof=ru kk en"`
+	// Captions - подписи к фотографиям, из них ИИ берёт детали интерьера
+	Captions []string `json:"captions"`
+}
+
+type TranslateListingInput struct {
+	// Source - язык текста, с которого переводим; по умолчанию русский
+	Source string `json:"source" binding:"omitempty,oneof=ru kk en"`
+	// Languages - пустой список означает все языки, кроме исходного
+	Languages []string `json:"languages" binding:"dive,oneof=ru kk en"`
+}
+
+type UpdateTranslationInput struct {
+	Description string `json:"description"`
+	Rules       string `json:"rules"`
+}
+
+type ApartmentTranslationRepository interface {
+	Get(apartmentID uint, language string) (*ApartmentTranslation, error)
+	GetByApartmentID(apartmentID uint) ([]ApartmentTranslation, error)
+	GetPublished(apartmentIDs []uint) (map[uint][]ApartmentTranslation, error)
+	Upsert(translation *ApartmentTranslation) error
+	SetStatus(apartmentID uint, language string, status TranslationStatus) error
+}
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// Назначение промежуточного входа
+const (
+	// ChallengeVerify - у владельца включена 2FA, нужен код из приложения
+	ChallengeVerify = "verify"
+	// ChallengeSetup - 2FA обязательна, но ещё не настроена: до входа нужно её подключить
+	ChallengeSetup = "setup"
+)
+
+// TwoFactor - TOTP владельца. Пока Enabled = false, секрет только выдан и ждёт подтверждения.
+type TwoFactor struct {
+	UserID       uint       `db:"user_id"`
+	Secret       string     `db:"secret"`
+	Enabled      bool       `db:"enabled"`
+	LastUsedStep int64      `db:"last_used_step"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+}
+
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required - 2FA требует администратор, отключить её нельзя
+	Required          bool       `json:"required"`
+	ConfirmedAt       *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollment - секрет для ручного ввода и ссылка otpauth:// для QR-кода
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes показываются владельцу один раз, сохраняются только их хеши
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type TwoFactorChallenge struct {
+	UserID    uint      `db:"user_id"`
+	Purpose   string    `db:"purpose"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// SignInResult - либо пара токенов, либо промежуточный токен для второго шага входа
+type SignInResult struct {
+	Tokens             *TokenPair
+	ChallengeToken     string
+	ChallengePurpose   string
+	ChallengeExpiresIn int
+}
+
+type TwoFactorCodeInput struct {
+	// Code - код из приложения или резервный код
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorChallengeInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+}
+
+type TwoFactorRepository interface {
+	Get(userID uint) (*TwoFactor, error)
+	SaveSecret(userID uint, secret string) error
+	Confirm(userID uint, step int64, recoveryHashes []string) error
+	UseStep(userID uint, step int64) error
+	UseRecoveryCode(userID uint, codeHash string) error
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	CountRecoveryCodes(userID uint) (int, error)
+	Delete(userID uint) error
+	CreateChallenge(tokenHash string, challenge *TwoFactorChallenge) error
+	GetChallenge(tokenHash string) (*TwoFactorChallenge, error)
+	AddChallengeAttempt(tokenHash string) (int, error)
+	UseChallenge(tokenHash string) error
+}
@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+type Plan string
+
+const (
+	PlanFree     Plan = "free"
+	PlanStandard Plan = "standard"
+	PlanPro      Plan = "pro"
+)
+
+// PlanTokenQuotas - месячный лимит токенов ИИ (запрос + ответ) по тарифам
+var PlanTokenQuotas = map[Plan]int{
+	PlanFree:     200000,
+	PlanStandard: 2000000,
+	PlanPro:      10000000,
+}
+
+// UsagePurpose - зачем вызывалась модель
+type UsagePurpose string
+
+const (
+	UsageReply   UsagePurpose = "reply"
+	UsageSummary UsagePurpose = "summary"
+	UsageTest    UsagePurpose = "test"
+	UsageListing UsagePurpose = "listing"
+)
+
+// LLMUsage - один вызов языковой модели
+type LLMUsage struct {
+	ID               uint         `json:"id" db:"id"`
+	UserID           uint         `json:"user_id" db:"user_id"`
+	ConversationID   *uint        `json:"conversation_id,omitempty" db:"conversation_id"`
+	Purpose          UsagePurpose `json:"purpose" db:"purpose"`
+	Model            string       `json:"model" db:"model"`
+	PromptTokens     int          `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int          `json:"completion_tokens" db:"completion_tokens"`
+	LatencyMs        int          `json:"latency_ms" db:"latency_ms"`
+	Error            string       `json:"error,omitempty" db:"error"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+}
+
+// UsageBreakdown - расход за период в разрезе модели, назначения или дня
+type UsageBreakdown struct {
+	Key              string  `json:"key"`
+	Model            string  `json:"-"`
+	Calls            int     `json:"calls"`
+	Failed           int     `json:"failed"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	AvgLatencyMs     int     `json:"avg_latency_ms"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// UsageReport - отчёт о расходе ИИ для страницы аналитики
+type UsageReport struct {
+	From             time.Time        `json:"from"`
+	To               time.Time        `json:"to"`
+	Plan             Plan             `json:"plan"`
+	MonthlyQuota     int              `json:"monthly_quota"`
+	MonthUsed        int              `json:"month_used"`
+	Calls            int              `json:"calls"`
+	Failed           int              `json:"failed"`
+	PromptTokens     int              `json:"prompt_tokens"`
+	CompletionTokens int              `json:"completion_tokens"`
+	EstimatedCostUSD float64          `json:"estimated_cost_usd"`
+	ByModel          []UsageBreakdown `json:"by_model"`
+	ByPurpose        []UsageBreakdown `json:"by_purpose"`
+	Daily            []UsageBreakdown `json:"daily"`
+}
+
+type UsageRepository interface {
+	Create(usage *LLMUsage) error
+	GetPlan(userID uint) (Plan, error)
+	TokensSince(userID uint, since time.Time) (int, error)
+	Breakdown(userID uint, from, to time.Time, groupBy string) ([]UsageBreakdown, error)
+}
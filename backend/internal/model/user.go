@@ -3,16 +3,52 @@ package model
 import "time"
 
 type User struct {
-	ID           uint      `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID           uint   `json:"id" db:"id"`
+	Email        string `json:"email" db:"email"`
+	PasswordHash string `json:"-" db:"password_hash"`
+	// EmailVerified - владелец перешёл по ссылке из письма подтверждения
+	EmailVerified bool `json:"email_verified" db:"email_verified"`
+	// PendingEmail - новый адрес, ждущий подтверждения; до перехода по ссылке вход по старому
+	PendingEmail string `json:"pending_email,omitempty" db:"pending_email"`
+	// Phone - номер в формате E.164; PhoneVerified - номер подтверждён кодом и годится для входа
+	Phone         string `json:"phone,omitempty" db:"phone"`
+	PhoneVerified bool   `json:"phone_verified" db:"phone_verified"`
+	Name          string `json:"name" db:"name"`
+	HasAvatar     bool   `json:"has_avatar" db:"has_avatar"`
+	// Language - язык писем и интерфейса: ru или kk
+	Language string `json:"language" db:"language"`
+	// Timezone - часовой пояс IANA, например Asia/Almaty
+	Timezone                string                  `json:"timezone" db:"timezone"`
+	NotificationPreferences NotificationPreferences `json:"notification_preferences" db:"notification_preferences"`
+	// CompanyName и BIN - реквизиты для счетов: название и БИН/ИИН из 12 цифр
+	CompanyName string `json:"company_name" db:"company_name"`
+	BIN         string `json:"bin" db:"bin"`
+	// DeletionScheduledAt - когда аккаунт будет удалён по запросу владельца
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" db:"deletion_scheduled_at"`
+	// Deleted - аккаунт уже обезличен, данных владельца в нём не осталось
+	Deleted   bool      `json:"-" db:"deleted"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationPreferences - вид уведомления -> включено ли оно.
+// Вид, которого нет в настройках, включён; уведомления безопасности выключить нельзя.
+type NotificationPreferences map[string]bool
+
+// Enabled - нужно ли сохранять уведомление этого вида
+func (p NotificationPreferences) Enabled(kind string) bool {
+	if kind == NotificationSecurity {
+		return true
+	}
+	enabled, ok := p[kind]
+	return !ok || enabled
 }
 
 type SignUpInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
+	// Language - язык писем: ru или kk
+	Language string `json:"language" binding:"omitempty,oneof=ru kk"`
 }
 
 type SignInInput struct {
@@ -20,7 +56,23 @@ type SignInInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileInput заменяет профиль целиком: клиент отправляет все поля из GET /user/profile.
+// Пустые язык и часовой пояс означают значения по умолчанию, отсутствующие настройки уведомлений не меняются.
 type UpdateProfileInput struct {
-	Name  string `json:"name"`
-	Phone string `json:"phone"`
+	Name                    string                  `json:"name" binding:"max=255"`
+	Phone                   string                  `json:"phone"`
+	Language                string                  `json:"language" binding:"omitempty,oneof=ru kk"`
+	Timezone                string                  `json:"timezone" binding:"max=64"`
+	NotificationPreferences NotificationPreferences `json:"notification_preferences"`
+	CompanyName             string                  `json:"company_name" binding:"max=255"`
+	BIN                     string                  `json:"bin"`
+}
+
+// ChangeEmailInput - смена email: новый адрес начинает работать после перехода по ссылке из письма.
+// Пароль обязателен, если он задан, иначе нужен код из WhatsApp или SMS на номер владельца
+// (POST /api/auth/phone/code): так смену не сделать с чужого открытого устройства.
+type ChangeEmailInput struct {
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password"`
+	PhoneCode string `json:"phone_code"`
 }
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type AIConfigRepository struct {
+	db *sql.DB
+}
+
+func NewAIConfigRepository(db *sql.DB) *AIConfigRepository {
+	return &AIConfigRepository{db: db}
+}
+
+// GetByUserID возвращает nil без ошибки, если владелец ещё не сохранял настройки
+func (r *AIConfigRepository) GetByUserID(userID uint) (*model.AIConfig, error) {
+	query := `
+        SELECT user_id, prompt, tone, language, temperature, max_tokens,
+               model, business_hours, quiet_hours, enabled, created_at, updated_at
+        FROM ai_configs WHERE user_id = $1
+    `
+
+	var config model.AIConfig
+	var hoursJSON, quietJSON []byte
+	err := r.db.QueryRow(query, userID).Scan(
+		&config.UserID,
+		&config.Prompt,
+		&config.Tone,
+		&config.Language,
+		&config.Temperature,
+		&config.MaxTokens,
+		&config.Model,
+		&hoursJSON,
+		&quietJSON,
+		&config.Enabled,
+		&config.CreatedAt,
+		&config.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting ai config: %v", err)
+	}
+
+	if err := json.Unmarshal(hoursJSON, &config.BusinessHours); err != nil {
+		return nil, fmt.Errorf("error parsing business hours: %v", err)
+	}
+	if err := json.Unmarshal(quietJSON, &config.QuietHours); err != nil {
+		return nil, fmt.Errorf("error parsing quiet hours: %v", err)
+	}
+
+	return &config, nil
+}
+
+func (r *AIConfigRepository) Upsert(config *model.AIConfig) error {
+	query := `
+        INSERT INTO ai_configs (
+            user_id, prompt, tone, language, temperature, max_tokens,
+            model, business_hours, quiet_hours, enabled, created_at, updated_at
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        ON CONFLICT (user_id) DO UPDATE SET
+            prompt = EXCLUDED.prompt,
+            tone = EXCLUDED.tone,
+            language = EXCLUDED.language,
+            temperature = EXCLUDED.temperature,
+            max_tokens = EXCLUDED.max_tokens,
+            model = EXCLUDED.model,
+            business_hours = EXCLUDED.business_hours,
+            quiet_hours = EXCLUDED.quiet_hours,
+            enabled = EXCLUDED.enabled,
+            updated_at = EXCLUDED.updated_at
+        RETURNING created_at
+    `
+
+	hoursJSON, err := json.Marshal(config.BusinessHours)
+	if err != nil {
+		return fmt.Errorf("error marshaling business hours: %v", err)
+	}
+	quietJSON, err := json.Marshal(config.QuietHours)
+	if err != nil {
+		return fmt.Errorf("error marshaling quiet hours: %v", err)
+	}
+
+	err = r.db.QueryRow(
+		query,
+		config.UserID,
+		config.Prompt,
+		config.Tone,
+		config.Language,
+		config.Temperature,
+		config.MaxTokens,
+		config.Model,
+		hoursJSON,
+		quietJSON,
+		config.Enabled,
+		config.CreatedAt,
+		config.UpdatedAt,
+	).Scan(&config.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving ai config: %v", err)
+	}
+
+	return nil
+}
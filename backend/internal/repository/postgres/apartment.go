@@ -25,11 +25,11 @@ func (r *ApartmentRepository) Create(apartment *model.Apartment) error {
             user_id, complex, rooms, price, description, 
             address, area, floor, amenities,
             location, rules, created_at, updated_at,
-            images, image_types, image_count, is_active
+            images, image_types, image_count, is_active, organization_id
         )
         VALUES (
             $1, $2, $3, $4, $5, $6, $7, $8, $9, 
-            $10, $11, $12, $13, $14::bytea[], $15::varchar[], COALESCE($16, 0), $17
+            $10, $11, $12, $13, $14::bytea[], $15::varchar[], COALESCE($16, 0), $17, $18
         )
         RETURNING id
     `
@@ -58,6 +58,7 @@ func (r *ApartmentRepository) Create(apartment *model.Apartment) error {
 		pq.Array(apartment.ImageTypes),
 		apartment.ImageCount,
 		apartment.IsActive,
+		apartment.OrganizationID,
 	).Scan(&apartment.ID)
 
 	if err != nil {
@@ -67,10 +68,11 @@ func (r *ApartmentRepository) Create(apartment *model.Apartment) error {
 	return nil
 }
 
+// GetByUserID возвращает квартиры всех организаций, в которых состоит пользователь
 func (r *ApartmentRepository) GetByUserID(userID uint) ([]model.Apartment, error) {
 	query := `
         SELECT 
-            a.id, a.user_id, a.complex, a.rooms, a.price, 
+            a.id, a.user_id, COALESCE(a.organization_id, 0), a.complex, a.rooms, a.price, 
             a.description, a.address, a.area, a.floor, 
             a.amenities::text, a.location, a.rules, 
             a.is_active, a.created_at, a.updated_at,
@@ -92,7 +94,7 @@ func (r *ApartmentRepository) GetByUserID(userID uint) ([]model.Apartment, error
             ) as availabilities
         FROM apartments a
         LEFT JOIN apartment_availability av ON a.id = av.apartment_id
-        WHERE a.user_id = $1
+        WHERE a.organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = $1)
         GROUP BY a.id
         ORDER BY a.created_at DESC
     `
@@ -111,7 +113,7 @@ func (r *ApartmentRepository) GetByUserID(userID uint) ([]model.Apartment, error
 		apt.Amenities = make(map[string]bool)
 
 		err := rows.Scan(
-			&apt.ID, &apt.UserID, &apt.Complex, &apt.Rooms, &apt.Price,
+			&apt.ID, &apt.UserID, &apt.OrganizationID, &apt.Complex, &apt.Rooms, &apt.Price,
 			&apt.Description, &apt.Address, &apt.Area, &apt.Floor,
 			&amenitiesJSON, &apt.Location, &apt.Rules,
 			&apt.IsActive, &apt.CreatedAt, &apt.UpdatedAt,
@@ -146,22 +148,80 @@ func (r *ApartmentRepository) GetByUserID(userID uint) ([]model.Apartment, error
 	return apartments, nil
 }
 
-func (r *ApartmentRepository) Update(userID uint, apartmentID string, apartment *model.UpdateApartmentInput) error {
-	// First verify ownership
-	var owner uint
-	var isActive bool
-	err := r.db.QueryRow("SELECT user_id, is_active FROM apartments WHERE id = $1", apartmentID).Scan(&owner, &isActive)
+// GetActiveByUserID возвращает активные объявления владельца без изображений
+// и только с периодами занятости, пересекающими интервал [from, to]
+func (r *ApartmentRepository) GetActiveByUserID(userID uint, from, to time.Time) ([]model.Apartment, error) {
+	query := `
+        SELECT 
+            a.id, a.user_id, a.complex, a.rooms, a.price, 
+            a.description, a.address, a.area, a.floor, 
+            a.amenities::text, a.location, a.rules, 
+            a.is_active, a.created_at, a.updated_at,
+            COALESCE(array_length(a.images, 1), 0) as image_count,
+            COALESCE(
+                json_agg(
+                    json_build_object(
+                        'id', av.id,
+                        'date_start', av.date_start,
+                        'date_end', av.date_end,
+                        'status', av.status
+                    ) ORDER BY av.date_start
+                ) FILTER (WHERE av.id IS NOT NULL),
+                '[]'
+            ) as availabilities
+        FROM apartments a
+        LEFT JOIN apartment_availability av ON a.id = av.apartment_id
+            AND av.date_end >= $2 AND av.date_start <= $3
+        WHERE a.user_id = $1 AND a.is_active = true
+        GROUP BY a.id
+        ORDER BY a.created_at DESC
+    `
+
+	rows, err := r.db.Query(query, userID, from, to)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("apartment not found")
+		return nil, fmt.Errorf("error querying apartments: %v", err)
+	}
+	defer rows.Close()
+
+	var apartments []model.Apartment
+	for rows.Next() {
+		var apt model.Apartment
+		var amenitiesJSON []byte
+		var availabilitiesJSON string
+		apt.Amenities = make(map[string]bool)
+
+		err := rows.Scan(
+			&apt.ID, &apt.UserID, &apt.Complex, &apt.Rooms, &apt.Price,
+			&apt.Description, &apt.Address, &apt.Area, &apt.Floor,
+			&amenitiesJSON, &apt.Location, &apt.Rules,
+			&apt.IsActive, &apt.CreatedAt, &apt.UpdatedAt,
+			&apt.ImageCount,
+			&availabilitiesJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning apartment: %v", err)
+		}
+
+		if err := json.Unmarshal(amenitiesJSON, &apt.Amenities); err != nil {
+			return nil, fmt.Errorf("error parsing amenities: %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(availabilitiesJSON), &apt.Availabilities); err != nil {
+			return nil, fmt.Errorf("error parsing availabilities: %v", err)
 		}
-		return fmt.Errorf("error checking apartment ownership: %v", err)
+
+		apartments = append(apartments, apt)
 	}
 
-	if owner != userID {
-		return fmt.Errorf("unauthorized: apartment does not belong to user")
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
 	}
 
+	return apartments, nil
+}
+
+// Update сохраняет изменения квартиры. Права проверяет сервис через GetAccess.
+func (r *ApartmentRepository) Update(apartmentID string, apartment *model.UpdateApartmentInput) error {
 	// Start transaction
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -182,7 +242,7 @@ func (r *ApartmentRepository) Update(userID uint, apartmentID string, apartment
             address = $5, area = $6, floor = $7, amenities = $8,
             location = $9, rules = $10, updated_at = $11, is_active = $12,
             image_count = $13
-        WHERE id = $14 AND user_id = $15
+        WHERE id = $14
         RETURNING id
     `
 
@@ -208,9 +268,11 @@ func (r *ApartmentRepository) Update(userID uint, apartmentID string, apartment
 		apartment.IsActive,
 		currentImageCount,
 		apartmentID,
-		userID,
 	).Scan(&id)
 
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("apartment not found")
+	}
 	if err != nil {
 		return fmt.Errorf("error updating apartment: %v", err)
 	}
@@ -223,13 +285,14 @@ func (r *ApartmentRepository) Update(userID uint, apartmentID string, apartment
 	return nil
 }
 
+// GetByID возвращает квартиру, если пользователь состоит в организации, которой она принадлежит
 func (r *ApartmentRepository) GetByID(userID uint, apartmentID string) (*model.Apartment, error) {
 	var apartment model.Apartment
 	var amenitiesJSON []byte
 
 	query := `
         SELECT 
-            a.id, a.user_id, a.complex, a.rooms, a.price, 
+            a.id, a.user_id, COALESCE(a.organization_id, 0), a.complex, a.rooms, a.price, 
             a.description, a.address, a.area, a.floor, 
             a.amenities, a.location, a.rules,
             a.is_active, a.created_at, a.updated_at,
@@ -250,7 +313,10 @@ func (r *ApartmentRepository) GetByID(userID uint, apartmentID string) (*model.A
             ) as availabilities
         FROM apartments a
         LEFT JOIN apartment_availability av ON a.id = av.apartment_id
-        WHERE a.id = $1 AND a.user_id = $2
+        WHERE a.id = $1 AND EXISTS (
+            SELECT 1 FROM organization_members m
+            WHERE m.organization_id = a.organization_id AND m.user_id = $2
+        )
         GROUP BY a.id
     `
 
@@ -258,6 +324,7 @@ func (r *ApartmentRepository) GetByID(userID uint, apartmentID string) (*model.A
 	err := r.db.QueryRow(query, apartmentID, userID).Scan(
 		&apartment.ID,
 		&apartment.UserID,
+		&apartment.OrganizationID,
 		&apartment.Complex,
 		&apartment.Rooms,
 		&apartment.Price,
@@ -277,6 +344,9 @@ func (r *ApartmentRepository) GetByID(userID uint, apartmentID string) (*model.A
 		&availabilitiesJSON,
 	)
 
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("apartment not found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error getting apartment: %v", err)
 	}
@@ -294,7 +364,7 @@ func (r *ApartmentRepository) GetByID(userID uint, apartmentID string) (*model.A
 	return &apartment, nil
 }
 
-func (r *ApartmentRepository) AddImages(userID uint, apartmentID string, imageData [][]byte, imageTypes []string) error {
+func (r *ApartmentRepository) AddImages(apartmentID string, imageData [][]byte, imageTypes []string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %v", err)
@@ -327,7 +397,7 @@ func (r *ApartmentRepository) AddImages(userID uint, apartmentID string, imageDa
         END,
         image_count = COALESCE(array_length(images, 1), 0) + $3,
         updated_at = CURRENT_TIMESTAMP
-        WHERE id = $4 AND user_id = $5
+        WHERE id = $4
         RETURNING id
     `
 
@@ -338,7 +408,6 @@ func (r *ApartmentRepository) AddImages(userID uint, apartmentID string, imageDa
 		pq.Array(optimizedTypes),
 		len(optimizedImages),
 		apartmentID,
-		userID,
 	).Scan(&id)
 
 	if err != nil {
@@ -390,10 +459,10 @@ func (r *ApartmentRepository) GetImage(apartmentID string, index int) ([]byte, s
 	return imageData, imageType, nil
 }
 
-func (r *ApartmentRepository) Delete(userID uint, apartmentID string) error {
-	query := `DELETE FROM apartments WHERE id = $1 AND user_id = $2`
+func (r *ApartmentRepository) Delete(apartmentID string) error {
+	query := `DELETE FROM apartments WHERE id = $1`
 
-	result, err := r.db.Exec(query, apartmentID, userID)
+	result, err := r.db.Exec(query, apartmentID)
 	if err != nil {
 		return fmt.Errorf("error deleting apartment: %v", err)
 	}
@@ -404,13 +473,13 @@ func (r *ApartmentRepository) Delete(userID uint, apartmentID string) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("apartment not found or not owned by user")
+		return fmt.Errorf("apartment not found")
 	}
 
 	return nil
 }
 
-func (r *ApartmentRepository) DeleteImage(userID uint, apartmentID string, index int) error {
+func (r *ApartmentRepository) DeleteImage(apartmentID string, index int) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %v", err)
@@ -421,7 +490,7 @@ func (r *ApartmentRepository) DeleteImage(userID uint, apartmentID string, index
 	query := `
 		SELECT images, image_types, COALESCE(image_count, 0)
 		FROM apartments
-		WHERE id = $1 AND user_id = $2
+		WHERE id = $1
 		FOR UPDATE
 	`
 
@@ -429,7 +498,7 @@ func (r *ApartmentRepository) DeleteImage(userID uint, apartmentID string, index
 	var imageTypes []string
 	var imageCount int
 
-	err = tx.QueryRow(query, apartmentID, userID).Scan(
+	err = tx.QueryRow(query, apartmentID).Scan(
 		pq.Array(&images),
 		pq.Array(&imageTypes),
 		&imageCount,
@@ -453,7 +522,7 @@ func (r *ApartmentRepository) DeleteImage(userID uint, apartmentID string, index
 		SET images = $1::bytea[],
 			image_types = $2::varchar[],
 			image_count = COALESCE(array_length($1::bytea[], 1), 0)
-		WHERE id = $3 AND user_id = $4
+		WHERE id = $3
 	`
 
 	result, err := tx.Exec(
@@ -461,7 +530,6 @@ func (r *ApartmentRepository) DeleteImage(userID uint, apartmentID string, index
 		pq.Array(images),
 		pq.Array(imageTypes),
 		apartmentID,
-		userID,
 	)
 	if err != nil {
 		return fmt.Errorf("error updating images: %v", err)
@@ -473,7 +541,7 @@ func (r *ApartmentRepository) DeleteImage(userID uint, apartmentID string, index
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("apartment not found or not owned by user")
+		return fmt.Errorf("apartment not found")
 	}
 
 	// Удаляем изображение из кэша
@@ -513,36 +581,63 @@ func (r *ApartmentRepository) CreateAvailability(apartmentID uint, availability
 	).Scan(&availability.ID)
 }
 
-func (r *ApartmentRepository) ToggleActive(userID uint, apartmentID string) error {
-	// First verify ownership and get current status
-	var owner uint
-	var isActive bool
-	err := r.db.QueryRow("SELECT user_id, is_active FROM apartments WHERE id = $1", apartmentID).Scan(&owner, &isActive)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("apartment not found")
-		}
-		return fmt.Errorf("error checking apartment ownership: %v", err)
-	}
+// HasConflict проверяет, пересекается ли период [start, end) с занятыми датами квартиры
+func (r *ApartmentRepository) HasConflict(apartmentID uint, start, end time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM apartment_availability
+			WHERE apartment_id = $1
+			AND status <> 'available'
+			AND date_start < $3 AND date_end > $2
+		)
+	`
 
-	if owner != userID {
-		return fmt.Errorf("unauthorized: apartment does not belong to user")
+	var conflict bool
+	if err := r.db.QueryRow(query, apartmentID, start, end).Scan(&conflict); err != nil {
+		return false, fmt.Errorf("error checking availability: %v", err)
 	}
 
-	// Toggle the status
+	return conflict, nil
+}
+
+func (r *ApartmentRepository) ToggleActive(apartmentID string) error {
 	query := `
         UPDATE apartments 
         SET is_active = NOT is_active,
             updated_at = CURRENT_TIMESTAMP
-        WHERE id = $1 AND user_id = $2
+        WHERE id = $1
         RETURNING is_active
     `
 
 	var newStatus bool
-	err = r.db.QueryRow(query, apartmentID, userID).Scan(&newStatus)
+	err := r.db.QueryRow(query, apartmentID).Scan(&newStatus)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("apartment not found")
+	}
 	if err != nil {
 		return fmt.Errorf("error updating apartment status: %v", err)
 	}
 
 	return nil
 }
+
+// GetAccess возвращает роль пользователя в организации квартиры. Если пользователь
+// в ней не состоит, квартира для него не существует.
+func (r *ApartmentRepository) GetAccess(userID uint, apartmentID string) (*model.ApartmentAccess, error) {
+	var access model.ApartmentAccess
+	err := r.db.QueryRow(`
+        SELECT a.id, a.organization_id, o.owner_id, m.role
+        FROM apartments a
+        JOIN organizations o ON o.id = a.organization_id
+        JOIN organization_members m ON m.organization_id = a.organization_id AND m.user_id = $2
+        WHERE a.id = $1
+    `, apartmentID, userID).Scan(&access.ApartmentID, &access.OrganizationID, &access.OwnerID, &access.Role)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("apartment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error checking apartment access: %v", err)
+	}
+
+	return &access, nil
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, scopes, expires_at, last_used_at, COALESCE(last_used_ip, ''), created_at`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }, k *model.APIKey, extra ...interface{}) error {
+	var expiresAt, lastUsedAt pq.NullTime
+	err := row.Scan(append([]interface{}{
+		&k.ID,
+		&k.UserID,
+		&k.Name,
+		&k.Prefix,
+		pq.Array(&k.Scopes),
+		&expiresAt,
+		&lastUsedAt,
+		&k.LastUsedIP,
+		&k.CreatedAt,
+	}, extra...)...)
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	return err
+}
+
+func (r *APIKeyRepository) Create(secretHash string, key *model.APIKey) error {
+	err := scanAPIKey(r.db.QueryRow(`
+        INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING `+apiKeyColumns,
+		key.UserID, key.Name, key.Prefix, secretHash, pq.Array(key.Scopes), key.ExpiresAt,
+	), key)
+	if err != nil {
+		return fmt.Errorf("error creating api key: %v", err)
+	}
+
+	return nil
+}
+
+// GetByUserID возвращает неотозванные ключи владельца, включая истёкшие
+func (r *APIKeyRepository) GetByUserID(userID uint) ([]model.APIKey, error) {
+	rows, err := r.db.Query(`
+        SELECT `+apiKeyColumns+`
+        FROM api_keys
+        WHERE user_id = $1 AND revoked_at IS NULL
+        ORDER BY created_at DESC
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying api keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		var key model.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("error scanning api key: %v", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) CountActive(userID uint) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+        SELECT COUNT(*) FROM api_keys
+        WHERE user_id = $1 AND revoked_at IS NULL
+            AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+    `, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting api keys: %v", err)
+	}
+
+	return count, nil
+}
+
+// GetByPrefix возвращает действующий ключ и хеш его секрета
+func (r *APIKeyRepository) GetByPrefix(prefix string) (*model.APIKey, string, error) {
+	var key model.APIKey
+	var secretHash string
+	err := scanAPIKey(r.db.QueryRow(`
+        SELECT `+apiKeyColumns+`, secret_hash
+        FROM api_keys
+        WHERE prefix = $1 AND revoked_at IS NULL
+            AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+    `, prefix), &key, &secretHash)
+	if err == sql.ErrNoRows {
+		return nil, "", fmt.Errorf("api key not found")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting api key: %v", err)
+	}
+
+	return &key, secretHash, nil
+}
+
+// Touch отмечает использование ключа не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+func (r *APIKeyRepository) Touch(id uint, ip string) error {
+	_, err := r.db.Exec(`
+        UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = $1
+        WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+    `, ip, id)
+	if err != nil {
+		return fmt.Errorf("error touching api key: %v", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) Revoke(userID, id uint) error {
+	result, err := r.db.Exec(`
+        UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+    `, id, userID)
+	if err != nil {
+		return fmt.Errorf("error revoking api key: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("api key not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type AuthTokenRepository struct {
+	db *sql.DB
+}
+
+func NewAuthTokenRepository(db *sql.DB) *AuthTokenRepository {
+	return &AuthTokenRepository{db: db}
+}
+
+// Create сохраняет новый токен. Прежние неиспользованные токены того же назначения
+// гасятся: действует только ссылка из последнего письма.
+func (r *AuthTokenRepository) Create(tokenHash string, token *model.AuthToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+        UPDATE auth_tokens SET used_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+    `, token.UserID, token.Purpose)
+	if err != nil {
+		return fmt.Errorf("error invalidating tokens: %v", err)
+	}
+
+	err = tx.QueryRow(`
+        INSERT INTO auth_tokens (token_hash, user_id, purpose, email, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING created_at
+    `, tokenHash, token.UserID, token.Purpose, token.Email, token.ExpiresAt).Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating token: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Consume помечает токен использованным и возвращает его. Использованный,
+// просроченный или чужого назначения токен считается не найденным.
+func (r *AuthTokenRepository) Consume(tokenHash, purpose string) (*model.AuthToken, error) {
+	var token model.AuthToken
+	err := r.db.QueryRow(`
+        UPDATE auth_tokens SET used_at = CURRENT_TIMESTAMP
+        WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        RETURNING user_id, purpose, email, expires_at, created_at
+    `, tokenHash, purpose).Scan(&token.UserID, &token.Purpose, &token.Email, &token.ExpiresAt, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error consuming token: %v", err)
+	}
+
+	return &token, nil
+}
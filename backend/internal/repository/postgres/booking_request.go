@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type BookingRequestRepository struct {
+	db *sql.DB
+}
+
+func NewBookingRequestRepository(db *sql.DB) *BookingRequestRepository {
+	return &BookingRequestRepository{db: db}
+}
+
+const bookingRequestColumns = `
+    id, user_id, apartment_id, conversation_id, COALESCE(guest_name, ''),
+    COALESCE(guest_phone, ''), date_start, date_end, nights, total_price,
+    status, source, availability_id, created_at, updated_at
+`
+
+func scanBookingRequest(row interface{ Scan(...interface{}) error }, req *model.BookingRequest) error {
+	var conversationID, availabilityID sql.NullInt64
+	err := row.Scan(
+		&req.ID,
+		&req.UserID,
+		&req.ApartmentID,
+		&conversationID,
+		&req.GuestName,
+		&req.GuestPhone,
+		&req.DateStart,
+		&req.DateEnd,
+		&req.Nights,
+		&req.TotalPrice,
+		&req.Status,
+		&req.Source,
+		&availabilityID,
+		&req.CreatedAt,
+		&req.UpdatedAt,
+	)
+	req.ConversationID = uint(conversationID.Int64)
+	req.AvailabilityID = uint(availabilityID.Int64)
+	return err
+}
+
+func (r *BookingRequestRepository) Create(req *model.BookingRequest) error {
+	query := `
+        INSERT INTO booking_requests (
+            user_id, apartment_id, conversation_id, guest_name, guest_phone,
+            date_start, date_end, nights, total_price, status, source
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id, created_at, updated_at
+    `
+
+	err := r.db.QueryRow(
+		query,
+		req.UserID,
+		req.ApartmentID,
+		nullableID(req.ConversationID),
+		req.GuestName,
+		req.GuestPhone,
+		req.DateStart,
+		req.DateEnd,
+		req.Nights,
+		req.TotalPrice,
+		req.Status,
+		req.Source,
+	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating booking request: %v", err)
+	}
+
+	return nil
+}
+
+// GetByUserID возвращает заявки владельца, пустой status означает все заявки
+func (r *BookingRequestRepository) GetByUserID(userID uint, status model.BookingRequestStatus) ([]model.BookingRequest, error) {
+	query := `SELECT ` + bookingRequestColumns + `
+        FROM booking_requests
+        WHERE user_id = $1 AND ($2::text = '' OR status = $2::text)
+        ORDER BY created_at DESC
+    `
+
+	rows, err := r.db.Query(query, userID, string(status))
+	if err != nil {
+		return nil, fmt.Errorf("error querying booking requests: %v", err)
+	}
+	defer rows.Close()
+
+	var requests []model.BookingRequest
+	for rows.Next() {
+		var req model.BookingRequest
+		if err := scanBookingRequest(rows, &req); err != nil {
+			return nil, fmt.Errorf("error scanning booking request: %v", err)
+		}
+		requests = append(requests, req)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return requests, nil
+}
+
+func (r *BookingRequestRepository) GetByID(userID uint, requestID string) (*model.BookingRequest, error) {
+	query := `SELECT ` + bookingRequestColumns + `
+        FROM booking_requests
+        WHERE id = $1 AND user_id = $2
+    `
+
+	var req model.BookingRequest
+	err := scanBookingRequest(r.db.QueryRow(query, requestID, userID), &req)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("booking request not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting booking request: %v", err)
+	}
+
+	return &req, nil
+}
+
+// GetLatestForGuest возвращает последнюю действующую заявку гостя из переписки
+// или nil, если заявок нет
+func (r *BookingRequestRepository) GetLatestForGuest(userID uint, conversationID uint, guestPhone string) (*model.BookingRequest, error) {
+	query := `SELECT ` + bookingRequestColumns + `
+        FROM booking_requests
+        WHERE user_id = $1 AND (conversation_id = $2 OR guest_phone = $3)
+            AND status IN ('pending', 'confirmed')
+        ORDER BY created_at DESC
+        LIMIT 1
+    `
+
+	var req model.BookingRequest
+	err := scanBookingRequest(r.db.QueryRow(query, userID, conversationID, guestPhone), &req)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting booking request: %v", err)
+	}
+
+	return &req, nil
+}
+
+func (r *BookingRequestRepository) UpdateStatus(userID uint, requestID uint, status model.BookingRequestStatus, availabilityID uint) error {
+	query := `
+        UPDATE booking_requests
+        SET status = $1, availability_id = COALESCE($2, availability_id), updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3 AND user_id = $4
+    `
+
+	result, err := r.db.Exec(query, status, nullableID(availabilityID), requestID, userID)
+	if err != nil {
+		return fmt.Errorf("error updating booking request: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("booking request not found")
+	}
+
+	return nil
+}
+
+// nullableID превращает нулевой ID в NULL для необязательных внешних ключей
+func nullableID(id uint) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return int64(id)
+}
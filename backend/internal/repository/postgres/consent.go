@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type ConsentRepository struct {
+	db *sql.DB
+}
+
+func NewConsentRepository(db *sql.DB) *ConsentRepository {
+	return &ConsentRepository{db: db}
+}
+
+// Get возвращает nil без ошибки, если гость ещё не давал и не отзывал согласие
+func (r *ConsentRepository) Get(userID uint, guestPhone string) (*model.GuestConsent, error) {
+	query := `
+        SELECT user_id, guest_phone, status, updated_at
+        FROM guest_consents
+        WHERE user_id = $1 AND guest_phone = $2
+    `
+
+	var consent model.GuestConsent
+	err := r.db.QueryRow(query, userID, guestPhone).Scan(
+		&consent.UserID,
+		&consent.GuestPhone,
+		&consent.Status,
+		&consent.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting consent: %v", err)
+	}
+
+	return &consent, nil
+}
+
+// Set сохраняет согласие и пишет запись в журнал. Если статус не изменился,
+// ничего не меняет и возвращает false.
+func (r *ConsentRepository) Set(entry *model.ConsentLogEntry) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+        INSERT INTO guest_consents (user_id, guest_phone, status)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, guest_phone) DO UPDATE SET
+            status = EXCLUDED.status,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE guest_consents.status <> EXCLUDED.status
+    `, entry.UserID, entry.GuestPhone, entry.Status)
+	if err != nil {
+		return false, fmt.Errorf("error saving consent: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	err = tx.QueryRow(`
+        INSERT INTO consent_log (user_id, guest_phone, status, source, message)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `, entry.UserID, entry.GuestPhone, entry.Status, entry.Source, entry.Message).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("error writing consent log: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+func (r *ConsentRepository) GetByUserID(userID uint) ([]model.GuestConsent, error) {
+	query := `
+        SELECT user_id, guest_phone, status, updated_at
+        FROM guest_consents
+        WHERE user_id = $1
+        ORDER BY updated_at DESC
+    `
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying consents: %v", err)
+	}
+	defer rows.Close()
+
+	var consents []model.GuestConsent
+	for rows.Next() {
+		var consent model.GuestConsent
+		if err := rows.Scan(&consent.UserID, &consent.GuestPhone, &consent.Status, &consent.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning consent: %v", err)
+		}
+		consents = append(consents, consent)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return consents, nil
+}
+
+func (r *ConsentRepository) GetLog(userID uint, guestPhone string) ([]model.ConsentLogEntry, error) {
+	query := `
+        SELECT id, user_id, guest_phone, status, source, message, created_at
+        FROM consent_log
+        WHERE user_id = $1 AND guest_phone = $2
+        ORDER BY created_at DESC, id DESC
+    `
+
+	rows, err := r.db.Query(query, userID, guestPhone)
+	if err != nil {
+		return nil, fmt.Errorf("error querying consent log: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []model.ConsentLogEntry
+	for rows.Next() {
+		var entry model.ConsentLogEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.GuestPhone,
+			&entry.Status,
+			&entry.Source,
+			&entry.Message,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning consent log: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type ConversationRepository struct {
+	db *sql.DB
+}
+
+func NewConversationRepository(db *sql.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+const conversationColumns = `
+    id, user_id, guest_phone, summary, summarized_until,
+    mode, paused_until, failure_count, escalation_reason, escalated_at,
+    last_message_at, created_at, updated_at
+`
+
+func scanConversation(row interface{ Scan(...interface{}) error }, conv *model.Conversation) error {
+	return row.Scan(
+		&conv.ID,
+		&conv.UserID,
+		&conv.GuestPhone,
+		&conv.Summary,
+		&conv.SummarizedUntil,
+		&conv.Mode,
+		&conv.PausedUntil,
+		&conv.FailureCount,
+		&conv.EscalationReason,
+		&conv.EscalatedAt,
+		&conv.LastMessageAt,
+		&conv.CreatedAt,
+		&conv.UpdatedAt,
+	)
+}
+
+func (r *ConversationRepository) GetOrCreate(userID uint, guestPhone string) (*model.Conversation, error) {
+	// DO UPDATE нужен, чтобы RETURNING вернул строку и для существующей переписки
+	query := `
+        INSERT INTO conversations (user_id, guest_phone)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id, guest_phone) DO UPDATE SET guest_phone = EXCLUDED.guest_phone
+        RETURNING ` + conversationColumns
+
+	var conv model.Conversation
+	if err := scanConversation(r.db.QueryRow(query, userID, guestPhone), &conv); err != nil {
+		return nil, fmt.Errorf("error getting conversation: %v", err)
+	}
+
+	return &conv, nil
+}
+
+func (r *ConversationRepository) GetByUserID(userID uint) ([]model.Conversation, error) {
+	query := `SELECT ` + conversationColumns + `
+        FROM conversations
+        WHERE user_id = $1
+        ORDER BY last_message_at DESC
+    `
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying conversations: %v", err)
+	}
+	defer rows.Close()
+
+	var conversations []model.Conversation
+	for rows.Next() {
+		var conv model.Conversation
+		if err := scanConversation(rows, &conv); err != nil {
+			return nil, fmt.Errorf("error scanning conversation: %v", err)
+		}
+		conversations = append(conversations, conv)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return conversations, nil
+}
+
+func (r *ConversationRepository) GetByID(userID uint, conversationID string) (*model.Conversation, error) {
+	query := `SELECT ` + conversationColumns + `
+        FROM conversations
+        WHERE id = $1 AND user_id = $2
+    `
+
+	var conv model.Conversation
+	err := scanConversation(r.db.QueryRow(query, conversationID, userID), &conv)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("conversation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting conversation: %v", err)
+	}
+
+	return &conv, nil
+}
+
+func (r *ConversationRepository) AddMessage(message *model.ConversationMessage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO conversation_messages (conversation_id, role, content, kind, media_id)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+
+	err = tx.QueryRow(query, message.ConversationID, message.Role, message.Content, message.Kind, message.MediaID).
+		Scan(&message.ID, &message.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating message: %v", err)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE conversations SET last_message_at = $1, updated_at = $1 WHERE id = $2`,
+		message.CreatedAt,
+		message.ConversationID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating conversation: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) GetMessages(conversationID uint) ([]model.ConversationMessage, error) {
+	query := `
+        SELECT id, conversation_id, role, content, kind, media_id, created_at
+        FROM conversation_messages
+        WHERE conversation_id = $1
+        ORDER BY id
+    `
+
+	return r.queryMessages(query, conversationID)
+}
+
+// GetRecentMessages возвращает не больше limit последних сообщений с ID больше afterID
+// в хронологическом порядке
+func (r *ConversationRepository) GetRecentMessages(conversationID uint, afterID uint, limit int) ([]model.ConversationMessage, error) {
+	query := `
+        SELECT id, conversation_id, role, content, kind, media_id, created_at FROM (
+            SELECT id, conversation_id, role, content, kind, media_id, created_at
+            FROM conversation_messages
+            WHERE conversation_id = $1 AND id > $2
+            ORDER BY id DESC
+            LIMIT $3
+        ) recent
+        ORDER BY id
+    `
+
+	return r.queryMessages(query, conversationID, afterID, limit)
+}
+
+func (r *ConversationRepository) CountMessagesAfter(conversationID uint, afterID uint) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM conversation_messages WHERE conversation_id = $1 AND id > $2`,
+		conversationID,
+		afterID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting messages: %v", err)
+	}
+
+	return count, nil
+}
+
+func (r *ConversationRepository) UpdateSummary(conversationID uint, summary string, summarizedUntil uint) error {
+	_, err := r.db.Exec(
+		`UPDATE conversations SET summary = $1, summarized_until = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		summary,
+		summarizedUntil,
+		conversationID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating summary: %v", err)
+	}
+
+	return nil
+}
+
+// SetMode переключает режим переписки, pausedUntil учитывается только для ModePaused
+func (r *ConversationRepository) SetMode(conversationID uint, mode model.ConversationMode, pausedUntil *time.Time) error {
+	query := `
+        UPDATE conversations
+        SET mode = $1,
+            paused_until = $2,
+            failure_count = CASE WHEN $3 THEN 0 ELSE failure_count END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4
+    `
+
+	// При возврате бота счётчик ошибок начинается заново
+	resetFailures := mode == model.ModeBot
+	if _, err := r.db.Exec(query, mode, pausedUntil, resetFailures, conversationID); err != nil {
+		return fmt.Errorf("error updating conversation mode: %v", err)
+	}
+
+	return nil
+}
+
+// Escalate передаёт переписку владельцу
+func (r *ConversationRepository) Escalate(conversationID uint, reason string) error {
+	query := `
+        UPDATE conversations
+        SET mode = 'human', paused_until = NULL, escalation_reason = $1,
+            escalated_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2
+    `
+
+	if _, err := r.db.Exec(query, reason, conversationID); err != nil {
+		return fmt.Errorf("error escalating conversation: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) IncrementFailures(conversationID uint) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		`UPDATE conversations SET failure_count = failure_count + 1 WHERE id = $1 RETURNING failure_count`,
+		conversationID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error updating failure count: %v", err)
+	}
+
+	return count, nil
+}
+
+func (r *ConversationRepository) ResetFailures(conversationID uint) error {
+	_, err := r.db.Exec(`UPDATE conversations SET failure_count = 0 WHERE id = $1 AND failure_count > 0`, conversationID)
+	if err != nil {
+		return fmt.Errorf("error resetting failure count: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) queryMessages(query string, args ...interface{}) ([]model.ConversationMessage, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying messages: %v", err)
+	}
+	defer rows.Close()
+
+	var messages []model.ConversationMessage
+	for rows.Next() {
+		var msg model.ConversationMessage
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.Kind, &msg.MediaID, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning message: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type MessageMediaRepository struct {
+	db *sql.DB
+}
+
+func NewMessageMediaRepository(db *sql.DB) *MessageMediaRepository {
+	return &MessageMediaRepository{db: db}
+}
+
+func (r *MessageMediaRepository) Create(media *model.MessageMedia) error {
+	query := `
+        INSERT INTO message_media (conversation_id, kind, mime_type, data, caption, latitude, longitude, transcript)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at
+    `
+
+	err := r.db.QueryRow(
+		query,
+		media.ConversationID,
+		media.Kind,
+		media.MimeType,
+		media.Data,
+		media.Caption,
+		media.Latitude,
+		media.Longitude,
+		media.Transcript,
+	).Scan(&media.ID, &media.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving media: %v", err)
+	}
+
+	return nil
+}
+
+// GetByID возвращает вложение, только если переписка принадлежит владельцу
+func (r *MessageMediaRepository) GetByID(userID uint, conversationID string, mediaID string) (*model.MessageMedia, error) {
+	query := `
+        SELECT m.id, m.conversation_id, m.kind, m.mime_type, m.data, m.caption,
+               m.latitude, m.longitude, m.transcript, m.created_at
+        FROM message_media m
+        JOIN conversations c ON c.id = m.conversation_id
+        WHERE m.id = $1 AND m.conversation_id = $2 AND c.user_id = $3
+    `
+
+	var media model.MessageMedia
+	err := r.db.QueryRow(query, mediaID, conversationID, userID).Scan(
+		&media.ID,
+		&media.ConversationID,
+		&media.Kind,
+		&media.MimeType,
+		&media.Data,
+		&media.Caption,
+		&media.Latitude,
+		&media.Longitude,
+		&media.Transcript,
+		&media.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("media not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting media: %v", err)
+	}
+
+	return &media, nil
+}
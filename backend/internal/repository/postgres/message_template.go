@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type MessageTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewMessageTemplateRepository(db *sql.DB) *MessageTemplateRepository {
+	return &MessageTemplateRepository{db: db}
+}
+
+const messageTemplateColumns = `
+    id, user_id, name, category, bodies, created_at, updated_at
+`
+
+func scanMessageTemplate(row interface{ Scan(...interface{}) error }, t *model.MessageTemplate) error {
+	var bodiesJSON []byte
+	err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Category,
+		&bodiesJSON,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(bodiesJSON, &t.Bodies); err != nil {
+		return fmt.Errorf("error parsing template bodies: %v", err)
+	}
+	return nil
+}
+
+func (r *MessageTemplateRepository) Create(t *model.MessageTemplate) error {
+	bodiesJSON, err := json.Marshal(t.Bodies)
+	if err != nil {
+		return fmt.Errorf("error marshaling template bodies: %v", err)
+	}
+
+	query := `
+        INSERT INTO message_templates (user_id, name, category, bodies)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at, updated_at
+    `
+
+	err = r.db.QueryRow(query, t.UserID, t.Name, t.Category, bodiesJSON).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating template: %v", err)
+	}
+
+	return nil
+}
+
+func (r *MessageTemplateRepository) GetByUserID(userID uint) ([]model.MessageTemplate, error) {
+	query := `SELECT ` + messageTemplateColumns + `
+        FROM message_templates
+        WHERE user_id = $1
+        ORDER BY category, name
+    `
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying templates: %v", err)
+	}
+	defer rows.Close()
+
+	var templates []model.MessageTemplate
+	for rows.Next() {
+		var t model.MessageTemplate
+		if err := scanMessageTemplate(rows, &t); err != nil {
+			return nil, fmt.Errorf("error scanning template: %v", err)
+		}
+		templates = append(templates, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return templates, nil
+}
+
+func (r *MessageTemplateRepository) GetByID(userID uint, templateID string) (*model.MessageTemplate, error) {
+	query := `SELECT ` + messageTemplateColumns + `
+        FROM message_templates
+        WHERE id = $1 AND user_id = $2
+    `
+
+	var t model.MessageTemplate
+	err := scanMessageTemplate(r.db.QueryRow(query, templateID, userID), &t)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("template not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting template: %v", err)
+	}
+
+	return &t, nil
+}
+
+func (r *MessageTemplateRepository) Update(t *model.MessageTemplate) error {
+	bodiesJSON, err := json.Marshal(t.Bodies)
+	if err != nil {
+		return fmt.Errorf("error marshaling template bodies: %v", err)
+	}
+
+	query := `
+        UPDATE message_templates
+        SET name = $1, category = $2, bodies = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4 AND user_id = $5
+        RETURNING updated_at
+    `
+
+	err = r.db.QueryRow(query, t.Name, t.Category, bodiesJSON, t.ID, t.UserID).Scan(&t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("template not found")
+	}
+	if err != nil {
+		return fmt.Errorf("error updating template: %v", err)
+	}
+
+	return nil
+}
+
+func (r *MessageTemplateRepository) Delete(userID uint, templateID string) error {
+	result, err := r.db.Exec(`DELETE FROM message_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting template: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("template not found")
+	}
+
+	return nil
+}
+
+// IsUsed проверяет, ссылаются ли на шаблон сценарии
+func (r *MessageTemplateRepository) IsUsed(templateID uint) (bool, error) {
+	var used bool
+	err := r.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM message_scenarios WHERE template_id = $1)`,
+		templateID,
+	).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("error checking template usage: %v", err)
+	}
+
+	return used, nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create сохраняет уведомление, если владелец не выключил этот вид в профиле.
+// Выключенное уведомление не сохраняется, n.ID остаётся нулевым.
+func (r *NotificationRepository) Create(n *model.Notification) error {
+	query := `
+        INSERT INTO notifications (user_id, type, title, body, data)
+        SELECT $1, $2::text, $3, $4, $5
+        FROM users
+        WHERE id = $1 AND COALESCE((notification_preferences->>($2::text))::boolean, true)
+        RETURNING id, created_at
+    `
+
+	dataJSON, err := json.Marshal(n.Data)
+	if err != nil {
+		return fmt.Errorf("error marshaling notification data: %v", err)
+	}
+
+	err = r.db.QueryRow(query, n.UserID, n.Type, n.Title, n.Body, dataJSON).Scan(&n.ID, &n.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error creating notification: %v", err)
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) GetByUserID(userID uint, unreadOnly bool) ([]model.Notification, error) {
+	query := `
+        SELECT id, user_id, type, title, body, data, read_at, created_at
+        FROM notifications
+        WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+        ORDER BY created_at DESC
+        LIMIT 100
+    `
+
+	rows, err := r.db.Query(query, userID, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("error querying notifications: %v", err)
+	}
+	defer rows.Close()
+
+	var notifications []model.Notification
+	for rows.Next() {
+		var n model.Notification
+		var dataJSON []byte
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &dataJSON, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning notification: %v", err)
+		}
+		if err := json.Unmarshal(dataJSON, &n.Data); err != nil {
+			return nil, fmt.Errorf("error parsing notification data: %v", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return notifications, nil
+}
+
+func (r *NotificationRepository) MarkRead(userID uint, notificationID string) error {
+	result, err := r.db.Exec(
+		`UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND read_at IS NULL`,
+		notificationID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating notification: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("notification not found")
+	}
+
+	return nil
+}
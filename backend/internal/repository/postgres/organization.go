@@ -0,0 +1,374 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type OrganizationRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+const organizationColumns = `o.id, o.name, o.owner_id, o.require_two_factor, o.created_at, o.updated_at, m.role`
+
+func scanOrganization(row interface{ Scan(...interface{}) error }, o *model.Organization) error {
+	return row.Scan(
+		&o.ID,
+		&o.Name,
+		&o.OwnerID,
+		&o.RequireTwoFactor,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+		&o.Role,
+	)
+}
+
+const invitationColumns = `id, organization_id, COALESCE(email, ''), COALESCE(phone, ''), role,
+            COALESCE(invited_by, 0), expires_at, accepted_at, created_at`
+
+func scanInvitation(row interface{ Scan(...interface{}) error }, inv *model.OrganizationInvitation) error {
+	var acceptedAt pq.NullTime
+	err := row.Scan(
+		&inv.ID,
+		&inv.OrganizationID,
+		&inv.Email,
+		&inv.Phone,
+		&inv.Role,
+		&inv.InvitedBy,
+		&inv.ExpiresAt,
+		&acceptedAt,
+		&inv.CreatedAt,
+	)
+	if acceptedAt.Valid {
+		inv.AcceptedAt = &acceptedAt.Time
+	}
+	return err
+}
+
+// Create сохраняет организацию, её владелец сразу становится участником с ролью owner
+func (r *OrganizationRepository) Create(org *model.Organization) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+        INSERT INTO organizations (name, owner_id, require_two_factor)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at, updated_at
+    `, org.Name, org.OwnerID, org.RequireTwoFactor).Scan(&org.ID, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating organization: %v", err)
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO organization_members (organization_id, user_id, role)
+        VALUES ($1, $2, $3)
+    `, org.ID, org.OwnerID, model.MemberOwner)
+	if err != nil {
+		return fmt.Errorf("error adding organization owner: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	org.Role = model.MemberOwner
+	return nil
+}
+
+// GetForUser возвращает организации, в которых состоит пользователь, с его ролью
+func (r *OrganizationRepository) GetForUser(userID uint) ([]model.Organization, error) {
+	rows, err := r.db.Query(`
+        SELECT `+organizationColumns+`
+        FROM organizations o
+        JOIN organization_members m ON m.organization_id = o.id
+        WHERE m.user_id = $1
+        ORDER BY o.created_at
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying organizations: %v", err)
+	}
+	defer rows.Close()
+
+	var orgs []model.Organization
+	for rows.Next() {
+		var org model.Organization
+		if err := scanOrganization(rows, &org); err != nil {
+			return nil, fmt.Errorf("error scanning organization: %v", err)
+		}
+		orgs = append(orgs, org)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return orgs, nil
+}
+
+// GetMembership возвращает организацию с ролью пользователя. Чужая организация
+// неотличима от несуществующей.
+func (r *OrganizationRepository) GetMembership(userID, organizationID uint) (*model.Organization, error) {
+	var org model.Organization
+	err := scanOrganization(r.db.QueryRow(`
+        SELECT `+organizationColumns+`
+        FROM organizations o
+        JOIN organization_members m ON m.organization_id = o.id AND m.user_id = $1
+        WHERE o.id = $2
+    `, userID, organizationID), &org)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("organization not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting organization: %v", err)
+	}
+
+	return &org, nil
+}
+
+// GetPersonal возвращает первую организацию, которой владеет пользователь, или nil
+func (r *OrganizationRepository) GetPersonal(userID uint) (*model.Organization, error) {
+	var org model.Organization
+	err := scanOrganization(r.db.QueryRow(`
+        SELECT `+organizationColumns+`
+        FROM organizations o
+        JOIN organization_members m ON m.organization_id = o.id AND m.user_id = o.owner_id
+        WHERE o.owner_id = $1
+        ORDER BY o.id
+        LIMIT 1
+    `, userID), &org)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting organization: %v", err)
+	}
+
+	return &org, nil
+}
+
+func (r *OrganizationRepository) Update(org *model.Organization) error {
+	err := r.db.QueryRow(`
+        UPDATE organizations SET name = $1, require_two_factor = $2, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3
+        RETURNING updated_at
+    `, org.Name, org.RequireTwoFactor, org.ID).Scan(&org.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("organization not found")
+	}
+	if err != nil {
+		return fmt.Errorf("error updating organization: %v", err)
+	}
+
+	return nil
+}
+
+func (r *OrganizationRepository) GetMembers(organizationID uint) ([]model.OrganizationMember, error) {
+	rows, err := r.db.Query(`
+        SELECT m.organization_id, m.user_id, COALESCE(u.email, ''), COALESCE(u.phone, ''),
+            m.role, t.confirmed_at IS NOT NULL, m.created_at
+        FROM organization_members m
+        JOIN users u ON u.id = m.user_id
+        LEFT JOIN user_totp t ON t.user_id = m.user_id
+        WHERE m.organization_id = $1
+        ORDER BY m.created_at
+    `, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying members: %v", err)
+	}
+	defer rows.Close()
+
+	var members []model.OrganizationMember
+	for rows.Next() {
+		var m model.OrganizationMember
+		err := rows.Scan(&m.OrganizationID, &m.UserID, &m.Email, &m.Phone, &m.Role, &m.TwoFactor, &m.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning member: %v", err)
+		}
+		members = append(members, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return members, nil
+}
+
+// SetRole меняет роль участника. Роль владельца не меняется.
+func (r *OrganizationRepository) SetRole(organizationID, userID uint, role string) error {
+	result, err := r.db.Exec(`
+        UPDATE organization_members SET role = $1
+        WHERE organization_id = $2 AND user_id = $3 AND role <> $4
+    `, role, organizationID, userID, model.MemberOwner)
+	if err != nil {
+		return fmt.Errorf("error updating member role: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	return nil
+}
+
+// RemoveMember исключает участника. Владельца исключить нельзя.
+func (r *OrganizationRepository) RemoveMember(organizationID, userID uint) error {
+	result, err := r.db.Exec(`
+        DELETE FROM organization_members
+        WHERE organization_id = $1 AND user_id = $2 AND role <> $3
+    `, organizationID, userID, model.MemberOwner)
+	if err != nil {
+		return fmt.Errorf("error removing member: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	return nil
+}
+
+func (r *OrganizationRepository) CreateInvitation(tokenHash string, invitation *model.OrganizationInvitation) error {
+	err := scanInvitation(r.db.QueryRow(`
+        INSERT INTO organization_invitations (organization_id, email, phone, role, token_hash, invited_by, expires_at)
+        VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7)
+        RETURNING `+invitationColumns,
+		invitation.OrganizationID, invitation.Email, invitation.Phone, invitation.Role,
+		tokenHash, invitation.InvitedBy, invitation.ExpiresAt,
+	), invitation)
+	if err != nil {
+		return fmt.Errorf("error creating invitation: %v", err)
+	}
+
+	return nil
+}
+
+// GetInvitations возвращает приглашения, которые ещё не приняты
+func (r *OrganizationRepository) GetInvitations(organizationID uint) ([]model.OrganizationInvitation, error) {
+	rows, err := r.db.Query(`
+        SELECT `+invitationColumns+`
+        FROM organization_invitations
+        WHERE organization_id = $1 AND accepted_at IS NULL
+        ORDER BY created_at DESC
+    `, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying invitations: %v", err)
+	}
+	defer rows.Close()
+
+	var invitations []model.OrganizationInvitation
+	for rows.Next() {
+		var inv model.OrganizationInvitation
+		if err := scanInvitation(rows, &inv); err != nil {
+			return nil, fmt.Errorf("error scanning invitation: %v", err)
+		}
+		invitations = append(invitations, inv)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return invitations, nil
+}
+
+func (r *OrganizationRepository) DeleteInvitation(organizationID, invitationID uint) error {
+	result, err := r.db.Exec(`
+        DELETE FROM organization_invitations
+        WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL
+    `, invitationID, organizationID)
+	if err != nil {
+		return fmt.Errorf("error deleting invitation: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+
+	return nil
+}
+
+// AcceptInvitation добавляет пользователя в организацию по токену из ссылки, если его
+// подтверждённый email или телефон совпадает с приглашённым. Если он уже участник,
+// его роль не меняется.
+func (r *OrganizationRepository) AcceptInvitation(tokenHash string, userID uint) (*model.OrganizationInvitation, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var inv model.OrganizationInvitation
+	err = scanInvitation(tx.QueryRow(`
+        UPDATE organization_invitations i SET accepted_at = CURRENT_TIMESTAMP, accepted_by = $1
+        WHERE i.token_hash = $2 AND i.accepted_at IS NULL AND i.expires_at > CURRENT_TIMESTAMP
+            AND EXISTS (
+                SELECT 1 FROM users u
+                WHERE u.id = $1
+                    AND ((u.email_verified_at IS NOT NULL AND u.email = i.email)
+                        OR (u.phone_verified_at IS NOT NULL AND u.phone = i.phone))
+            )
+        RETURNING `+invitationColumns,
+		userID, tokenHash,
+	), &inv)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("invitation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error accepting invitation: %v", err)
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO organization_members (organization_id, user_id, role)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (organization_id, user_id) DO NOTHING
+    `, inv.OrganizationID, userID, inv.Role)
+	if err != nil {
+		return nil, fmt.Errorf("error adding member: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &inv, nil
+}
+
+// RequiresTwoFactor - состоит ли пользователь в организации, где 2FA обязательна
+func (r *OrganizationRepository) RequiresTwoFactor(userID uint) (bool, error) {
+	var required bool
+	err := r.db.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM organization_members m
+            JOIN organizations o ON o.id = m.organization_id
+            WHERE m.user_id = $1 AND o.require_two_factor
+        )
+    `, userID).Scan(&required)
+	if err != nil {
+		return false, fmt.Errorf("error checking two-factor policy: %v", err)
+	}
+
+	return required, nil
+}
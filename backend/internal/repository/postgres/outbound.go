@@ -0,0 +1,294 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type OutboundRepository struct {
+	db *sql.DB
+}
+
+func NewOutboundRepository(db *sql.DB) *OutboundRepository {
+	return &OutboundRepository{db: db}
+}
+
+const outboundColumns = `
+    id, user_id, guest_phone, kind, body, data, mime_type, latitude, longitude, location_name,
+    idempotency_key, status, attempts, next_attempt_at, last_error, COALESCE(whatsapp_id, ''),
+    sent_at, delivered_at, read_at, created_at, updated_at
+`
+
+func scanOutbound(row interface{ Scan(...interface{}) error }, msg *model.OutboundMessage) error {
+	return row.Scan(
+		&msg.ID,
+		&msg.UserID,
+		&msg.GuestPhone,
+		&msg.Kind,
+		&msg.Body,
+		&msg.Data,
+		&msg.MimeType,
+		&msg.Latitude,
+		&msg.Longitude,
+		&msg.LocationName,
+		&msg.IdempotencyKey,
+		&msg.Status,
+		&msg.Attempts,
+		&msg.NextAttemptAt,
+		&msg.LastError,
+		&msg.WhatsAppID,
+		&msg.SentAt,
+		&msg.DeliveredAt,
+		&msg.ReadAt,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+	)
+}
+
+// Enqueue ставит сообщение в очередь. Если сообщение с таким ключом идемпотентности
+// уже есть, возвращает false и заполняет msg сохранённой записью.
+// Заданный NextAttemptAt откладывает первую попытку отправки.
+func (r *OutboundRepository) Enqueue(msg *model.OutboundMessage) (bool, error) {
+	var sendAfter *time.Time
+	if !msg.NextAttemptAt.IsZero() {
+		sendAfter = &msg.NextAttemptAt
+	}
+
+	query := `
+        INSERT INTO outbound_messages (
+            user_id, guest_phone, kind, body, data, mime_type, latitude, longitude, location_name,
+            idempotency_key, next_attempt_at
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, CURRENT_TIMESTAMP))
+        ON CONFLICT (user_id, idempotency_key) DO NOTHING
+        RETURNING ` + outboundColumns
+
+	err := scanOutbound(r.db.QueryRow(
+		query,
+		msg.UserID,
+		msg.GuestPhone,
+		msg.Kind,
+		msg.Body,
+		msg.Data,
+		msg.MimeType,
+		msg.Latitude,
+		msg.Longitude,
+		msg.LocationName,
+		msg.IdempotencyKey,
+		sendAfter,
+	), msg)
+	if err == nil {
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, fmt.Errorf("error enqueuing message: %v", err)
+	}
+
+	query = `SELECT ` + outboundColumns + ` FROM outbound_messages WHERE user_id = $1 AND idempotency_key = $2`
+	if err := scanOutbound(r.db.QueryRow(query, msg.UserID, msg.IdempotencyKey), msg); err != nil {
+		return false, fmt.Errorf("error getting queued message: %v", err)
+	}
+
+	return false, nil
+}
+
+// GetByUserID возвращает последние сообщения очереди владельца, пустой status означает все
+func (r *OutboundRepository) GetByUserID(userID uint, status model.OutboundStatus) ([]model.OutboundMessage, error) {
+	query := `SELECT ` + outboundColumns + `
+        FROM outbound_messages
+        WHERE user_id = $1 AND ($2::text = '' OR status = $2::text)
+        ORDER BY created_at DESC
+        LIMIT 200
+    `
+
+	return r.query(query, userID, string(status))
+}
+
+// ClaimDue забирает в отправку созревшие сообщения, как ScheduledMessageRepository.ClaimDue
+func (r *OutboundRepository) ClaimDue(limit int) ([]model.OutboundMessage, error) {
+	query := `
+        UPDATE outbound_messages
+        SET status = 'sending', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id IN (
+            SELECT id FROM outbound_messages
+            WHERE status = 'queued' AND next_attempt_at <= CURRENT_TIMESTAMP
+            ORDER BY next_attempt_at, id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + outboundColumns
+
+	messages, err := r.query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING не гарантирует порядок, а сообщения одному гостю должны уходить по очереди
+	for i := 1; i < len(messages); i++ {
+		for j := i; j > 0 && messages[j].ID < messages[j-1].ID; j-- {
+			messages[j], messages[j-1] = messages[j-1], messages[j]
+		}
+	}
+	return messages, nil
+}
+
+func (r *OutboundRepository) MarkSent(messageID uint, whatsAppID string) error {
+	_, err := r.db.Exec(
+		`UPDATE outbound_messages
+         SET status = 'sent', whatsapp_id = $1, last_error = '', data = NULL,
+             sent_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+         WHERE id = $2`,
+		whatsAppID,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("error marking message sent: %v", err)
+	}
+
+	return nil
+}
+
+// Retry возвращает сообщение в очередь после неудачной попытки
+func (r *OutboundRepository) Retry(messageID uint, nextAttemptAt time.Time, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE outbound_messages
+         SET status = 'queued', next_attempt_at = $1, last_error = $2, updated_at = CURRENT_TIMESTAMP
+         WHERE id = $3`,
+		nextAttemptAt,
+		lastError,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("error retrying message: %v", err)
+	}
+
+	return nil
+}
+
+// Defer откладывает сообщение из-за ограничения частоты, не расходуя попытку
+func (r *OutboundRepository) Defer(messageID uint, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE outbound_messages
+         SET status = 'queued', next_attempt_at = $1, attempts = GREATEST(attempts - 1, 0), updated_at = CURRENT_TIMESTAMP
+         WHERE id = $2`,
+		nextAttemptAt,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("error deferring message: %v", err)
+	}
+
+	return nil
+}
+
+func (r *OutboundRepository) Fail(messageID uint, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE outbound_messages SET status = 'failed', last_error = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		lastError,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("error failing message: %v", err)
+	}
+
+	return nil
+}
+
+// Requeue повторно ставит в очередь сообщение, отправка которого не удалась
+func (r *OutboundRepository) Requeue(userID uint, messageID string) error {
+	result, err := r.db.Exec(
+		`UPDATE outbound_messages
+         SET status = 'queued', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+         WHERE id = $1 AND user_id = $2 AND status = 'failed'`,
+		messageID,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error requeuing message: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("failed message not found")
+	}
+
+	return nil
+}
+
+// receiptPredecessors - статусы, из которых можно перейти по квитанции.
+// Квитанции приходят не по порядку, и "прочитано" не должно смениться на "доставлено".
+var receiptPredecessors = map[model.OutboundStatus][]string{
+	model.OutboundSent:      {"sending"},
+	model.OutboundDelivered: {"sending", "sent"},
+	model.OutboundRead:      {"sending", "sent", "delivered"},
+}
+
+// UpdateReceipt обновляет статус по квитанции WhatsApp
+func (r *OutboundRepository) UpdateReceipt(userID uint, whatsAppID string, status model.OutboundStatus) error {
+	from, ok := receiptPredecessors[status]
+	if !ok {
+		return fmt.Errorf("unsupported receipt status: %s", status)
+	}
+
+	query := `
+        UPDATE outbound_messages
+        SET status = $1,
+            delivered_at = CASE WHEN $1 IN ('delivered', 'read') THEN COALESCE(delivered_at, CURRENT_TIMESTAMP) ELSE delivered_at END,
+            read_at = CASE WHEN $1 = 'read' THEN CURRENT_TIMESTAMP ELSE read_at END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = $2 AND whatsapp_id = $3 AND status = ANY($4)
+    `
+
+	if _, err := r.db.Exec(query, string(status), userID, whatsAppID, pq.Array(from)); err != nil {
+		return fmt.Errorf("error updating receipt: %v", err)
+	}
+
+	return nil
+}
+
+// FailStale закрывает сообщения, зависшие в отправке. Повторно их не отправляем:
+// неизвестно, дошло ли сообщение до WhatsApp.
+func (r *OutboundRepository) FailStale(olderThan time.Time) (int64, error) {
+	result, err := r.db.Exec(
+		`UPDATE outbound_messages
+         SET status = 'failed', last_error = 'отправка прервана', updated_at = CURRENT_TIMESTAMP
+         WHERE status = 'sending' AND updated_at < $1`,
+		olderThan,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error failing stale messages: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *OutboundRepository) query(query string, args ...interface{}) ([]model.OutboundMessage, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying outbound messages: %v", err)
+	}
+	defer rows.Close()
+
+	var messages []model.OutboundMessage
+	for rows.Next() {
+		var msg model.OutboundMessage
+		if err := scanOutbound(rows, &msg); err != nil {
+			return nil, fmt.Errorf("error scanning outbound message: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type PhoneCodeRepository struct {
+	db *sql.DB
+}
+
+func NewPhoneCodeRepository(db *sql.DB) *PhoneCodeRepository {
+	return &PhoneCodeRepository{db: db}
+}
+
+const phoneCodeColumns = `id, phone, channel, code_hash, attempts, expires_at, created_at`
+
+func scanPhoneCode(row interface{ Scan(...interface{}) error }, c *model.PhoneCode) error {
+	return row.Scan(
+		&c.ID,
+		&c.Phone,
+		&c.Channel,
+		&c.CodeHash,
+		&c.Attempts,
+		&c.ExpiresAt,
+		&c.CreatedAt,
+	)
+}
+
+// Create сохраняет новый код, прежние неиспользованные коды номера перестают действовать
+func (r *PhoneCodeRepository) Create(code *model.PhoneCode) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`UPDATE phone_codes SET used_at = CURRENT_TIMESTAMP WHERE phone = $1 AND used_at IS NULL`, code.Phone); err != nil {
+		return fmt.Errorf("error invalidating phone codes: %v", err)
+	}
+
+	err = scanPhoneCode(tx.QueryRow(`
+        INSERT INTO phone_codes (phone, channel, code_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING `+phoneCodeColumns,
+		code.Phone, code.Channel, code.CodeHash, code.ExpiresAt,
+	), code)
+	if err != nil {
+		return fmt.Errorf("error creating phone code: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// GetLatest возвращает последний выданный номеру код, даже использованный, или nil
+func (r *PhoneCodeRepository) GetLatest(phone string) (*model.PhoneCode, error) {
+	query := `SELECT ` + phoneCodeColumns + ` FROM phone_codes WHERE phone = $1 ORDER BY created_at DESC LIMIT 1`
+
+	var code model.PhoneCode
+	err := scanPhoneCode(r.db.QueryRow(query, phone), &code)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting phone code: %v", err)
+	}
+
+	return &code, nil
+}
+
+func (r *PhoneCodeRepository) CountSince(phone string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM phone_codes WHERE phone = $1 AND created_at >= $2`, phone, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting phone codes: %v", err)
+	}
+	return count, nil
+}
+
+// GetActive возвращает действующий код номера
+func (r *PhoneCodeRepository) GetActive(phone string) (*model.PhoneCode, error) {
+	query := `
+        SELECT ` + phoneCodeColumns + `
+        FROM phone_codes
+        WHERE phone = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        ORDER BY created_at DESC
+        LIMIT 1
+    `
+
+	var code model.PhoneCode
+	err := scanPhoneCode(r.db.QueryRow(query, phone), &code)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("phone code not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting phone code: %v", err)
+	}
+
+	return &code, nil
+}
+
+// AddAttempt учитывает попытку ввода и возвращает, сколько их было всего
+func (r *PhoneCodeRepository) AddAttempt(id uint) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(`
+        UPDATE phone_codes SET attempts = attempts + 1
+        WHERE id = $1 AND used_at IS NULL
+        RETURNING attempts
+    `, id).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("phone code not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error updating phone code: %v", err)
+	}
+	return attempts, nil
+}
+
+// MarkUsed гасит код. Если его уже погасил параллельный запрос, код считается не найденным.
+func (r *PhoneCodeRepository) MarkUsed(id uint) error {
+	result, err := r.db.Exec(`UPDATE phone_codes SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("error using phone code: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("phone code not found")
+	}
+
+	return nil
+}
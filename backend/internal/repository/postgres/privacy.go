@@ -0,0 +1,343 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type PrivacyRepository struct {
+	db *sql.DB
+}
+
+func NewPrivacyRepository(db *sql.DB) *PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+// exportSection - раздел выгрузки и запрос его записей. Двоичные данные
+// (фото, вложения) и секреты (хеши паролей, токенов, ключей) в выгрузку не попадают.
+type exportSection struct {
+	name  string
+	query string
+}
+
+// conversationExportColumns - переписка вместе с сообщениями
+const conversationExportColumns = `
+        c.id, c.guest_phone, c.summary, c.mode, c.created_at, c.last_message_at,
+        (SELECT COALESCE(json_agg(json_build_object(
+            'role', m.role, 'kind', m.kind, 'content', m.content, 'created_at', m.created_at
+        ) ORDER BY m.id), '[]')
+        FROM conversation_messages m WHERE m.conversation_id = c.id) AS messages`
+
+// guestPhoneMatches сравнивает номер гостя по цифрам: владельцы записывают номера
+// по-разному, "+7 701 123-45-67" и "87011234567" - один гость
+const guestPhoneMatches = `regexp_replace(guest_phone, '\D', '', 'g') = ANY($2)`
+
+var accountExportSections = []exportSection{
+	{"profile", `
+        SELECT id, email, pending_email, email_verified_at, phone, phone_verified_at, name,
+            language, timezone, notification_preferences, company_name, bin, plan,
+            deletion_scheduled_at, created_at, updated_at
+        FROM users WHERE id = $1`},
+	{"organizations", `
+        SELECT o.id, o.name, o.owner_id, m.role, m.created_at AS joined_at
+        FROM organization_members m JOIN organizations o ON o.id = m.organization_id
+        WHERE m.user_id = $1`},
+	{"apartments", `
+        SELECT id, organization_id, complex, rooms, price, description, address, area, floor,
+            amenities, location, rules, is_active, COALESCE(array_length(images, 1), 0) AS image_count,
+            created_at, updated_at
+        FROM apartments WHERE user_id = $1`},
+	{"apartment_guest_info", `
+        SELECT g.* FROM apartment_guest_info g
+        JOIN apartments a ON a.id = g.apartment_id WHERE a.user_id = $1`},
+	{"bookings", `
+        SELECT av.id, av.apartment_id, av.date_start, av.date_end, av.status, av.source,
+            av.booking_id, av.guest_name, av.guest_phone, av.created_at
+        FROM apartment_availability av
+        JOIN apartments a ON a.id = av.apartment_id WHERE a.user_id = $1`},
+	{"booking_requests", `
+        SELECT id, apartment_id, conversation_id, guest_name, guest_phone, date_start, date_end,
+            nights, total_price, status, source, created_at, updated_at
+        FROM booking_requests WHERE user_id = $1`},
+	{"conversations", `SELECT ` + conversationExportColumns + ` FROM conversations c WHERE c.user_id = $1`},
+	{"guest_consents", `SELECT guest_phone, status, updated_at FROM guest_consents WHERE user_id = $1`},
+	{"consent_log", `SELECT guest_phone, status, source, message, created_at FROM consent_log WHERE user_id = $1`},
+	{"ai_config", `
+        SELECT prompt, tone, language, temperature, max_tokens, model, business_hours,
+            quiet_hours, enabled, created_at, updated_at
+        FROM ai_configs WHERE user_id = $1`},
+	{"message_templates", `SELECT id, name, category, bodies, created_at, updated_at FROM message_templates WHERE user_id = $1`},
+	{"message_scenarios", `
+        SELECT id, name, trigger, offset_hours, send_time, template, template_id, enabled, created_at
+        FROM message_scenarios WHERE user_id = $1`},
+	{"scheduled_messages", `
+        SELECT id, scenario_id, booking_request_id, guest_phone, send_at, status, body, sent_at, created_at
+        FROM scheduled_messages WHERE user_id = $1`},
+	{"outbound_messages", `
+        SELECT id, guest_phone, kind, body, location_name, status, sent_at, delivered_at, read_at, created_at
+        FROM outbound_messages WHERE user_id = $1`},
+	{"notifications", `SELECT id, type, title, body, data, read_at, created_at FROM notifications WHERE user_id = $1`},
+	{"sessions", `
+        SELECT user_agent, ip, created_at, last_seen_at, expires_at, revoked_at, revoke_reason
+        FROM sessions WHERE user_id = $1`},
+	{"api_keys", `
+        SELECT name, prefix, scopes, expires_at, last_used_at, last_used_ip, revoked_at, created_at
+        FROM api_keys WHERE user_id = $1`},
+	{"llm_usage", `
+        SELECT purpose, model, prompt_tokens, completion_tokens, created_at
+        FROM llm_usage WHERE user_id = $1`},
+}
+
+var guestExportSections = []exportSection{
+	{"conversations", `SELECT ` + conversationExportColumns + `
+        FROM conversations c WHERE c.user_id = $1 AND regexp_replace(c.guest_phone, '\D', '', 'g') = ANY($2)`},
+	{"bookings", `
+        SELECT av.apartment_id, a.complex, av.date_start, av.date_end, av.status, av.source,
+            av.guest_name, av.guest_phone, av.created_at
+        FROM apartment_availability av
+        JOIN apartments a ON a.id = av.apartment_id
+        WHERE a.user_id = $1 AND regexp_replace(av.guest_phone, '\D', '', 'g') = ANY($2)`},
+	{"booking_requests", `
+        SELECT id, apartment_id, guest_name, guest_phone, date_start, date_end, nights,
+            total_price, status, created_at
+        FROM booking_requests WHERE user_id = $1 AND ` + guestPhoneMatches},
+	{"guest_consents", `SELECT guest_phone, status, updated_at FROM guest_consents WHERE user_id = $1 AND ` + guestPhoneMatches},
+	{"consent_log", `
+        SELECT guest_phone, status, source, message, created_at
+        FROM consent_log WHERE user_id = $1 AND ` + guestPhoneMatches},
+	{"scheduled_messages", `
+        SELECT guest_phone, send_at, status, body, sent_at
+        FROM scheduled_messages WHERE user_id = $1 AND ` + guestPhoneMatches},
+	{"outbound_messages", `
+        SELECT guest_phone, kind, body, location_name, status, sent_at, delivered_at, read_at, created_at
+        FROM outbound_messages WHERE user_id = $1 AND ` + guestPhoneMatches},
+}
+
+func (r *PrivacyRepository) export(sections []exportSection, args ...interface{}) (model.DataExport, error) {
+	export := make(model.DataExport, len(sections))
+	for _, section := range sections {
+		var data []byte
+		err := r.db.QueryRow(`SELECT COALESCE(json_agg(t), '[]') FROM (`+section.query+`) t`, args...).Scan(&data)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting %s: %v", section.name, err)
+		}
+		export[section.name] = json.RawMessage(data)
+	}
+
+	return export, nil
+}
+
+// ExportAccount собирает всё, что хранится о владельце
+func (r *PrivacyRepository) ExportAccount(userID uint) (model.DataExport, error) {
+	return r.export(accountExportSections, userID)
+}
+
+// ExportGuest собирает данные гостя у владельца. phones - варианты номера только из цифр.
+func (r *PrivacyRepository) ExportGuest(userID uint, phones []string) (model.DataExport, error) {
+	return r.export(guestExportSections, userID, pq.Array(phones))
+}
+
+func (r *PrivacyRepository) ScheduleDeletion(userID uint, at time.Time) error {
+	result, err := r.db.Exec(`
+        UPDATE users SET deletion_scheduled_at = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $2 AND deleted_at IS NULL
+    `, at, userID)
+	if err != nil {
+		return fmt.Errorf("error scheduling account deletion: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *PrivacyRepository) CancelDeletion(userID uint) error {
+	result, err := r.db.Exec(`
+        UPDATE users SET deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND deletion_scheduled_at IS NOT NULL
+    `, userID)
+	if err != nil {
+		return fmt.Errorf("error cancelling account deletion: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("deletion request not found")
+	}
+
+	return nil
+}
+
+// GetDueDeletions возвращает аккаунты, срок удаления которых наступил
+func (r *PrivacyRepository) GetDueDeletions(limit int) ([]uint, error) {
+	rows, err := r.db.Query(`
+        SELECT id FROM users
+        WHERE deletion_scheduled_at <= CURRENT_TIMESTAMP AND deleted_at IS NULL
+        ORDER BY deletion_scheduled_at
+        LIMIT $1
+    `, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying due deletions: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []uint
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning user id: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return ids, nil
+}
+
+// EraseAccount обезличивает аккаунт, срок удаления которого наступил. Переписка, гости,
+// настройки и способы входа удаляются; квартиры и брони остаются без имён и телефонов,
+// чтобы не ломать учёт. Квартиры снимаются с публикации, организации владельца закрываются.
+func (r *PrivacyRepository) EraseAccount(userID uint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Сначала запоминаем контакты: по ним удаляются коды входа и приглашения
+	var email, phone string
+	err = tx.QueryRow(`
+        SELECT COALESCE(email, ''), COALESCE(phone, '') FROM users
+        WHERE id = $1 AND deletion_scheduled_at <= CURRENT_TIMESTAMP AND deleted_at IS NULL
+        FOR UPDATE
+    `, userID).Scan(&email, &phone)
+	if err == sql.ErrNoRows {
+		// Владелец отменил удаление
+		return fmt.Errorf("deletion request not found")
+	}
+	if err != nil {
+		return fmt.Errorf("error locking user: %v", err)
+	}
+
+	statements := []string{
+		`DELETE FROM ai_tool_calls WHERE user_id = $1`,
+		`DELETE FROM conversations WHERE user_id = $1`,
+		`DELETE FROM outbound_messages WHERE user_id = $1`,
+		`DELETE FROM scheduled_messages WHERE user_id = $1`,
+		`DELETE FROM message_scenarios WHERE user_id = $1`,
+		`DELETE FROM message_templates WHERE user_id = $1`,
+		`DELETE FROM guest_consents WHERE user_id = $1`,
+		`DELETE FROM consent_log WHERE user_id = $1`,
+		`DELETE FROM ai_configs WHERE user_id = $1`,
+		`DELETE FROM notifications WHERE user_id = $1`,
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM auth_tokens WHERE user_id = $1`,
+		`DELETE FROM api_keys WHERE user_id = $1`,
+		`DELETE FROM user_totp WHERE user_id = $1`,
+		`DELETE FROM recovery_codes WHERE user_id = $1`,
+		`DELETE FROM two_factor_challenges WHERE user_id = $1`,
+		`UPDATE booking_requests SET guest_name = NULL, guest_phone = NULL WHERE user_id = $1`,
+		`UPDATE apartment_availability SET guest_name = NULL, guest_phone = NULL
+            WHERE apartment_id IN (SELECT id FROM apartments WHERE user_id = $1)`,
+		`DELETE FROM apartment_guest_info WHERE apartment_id IN (SELECT id FROM apartments WHERE user_id = $1)`,
+		`UPDATE apartments SET is_active = false, images = NULL, image_types = NULL, image_count = 0,
+            updated_at = CURRENT_TIMESTAMP
+            WHERE user_id = $1`,
+		`DELETE FROM organization_invitations WHERE organization_id IN (SELECT id FROM organizations WHERE owner_id = $1)`,
+		`DELETE FROM organization_members
+            WHERE user_id = $1 OR organization_id IN (SELECT id FROM organizations WHERE owner_id = $1)`,
+		`UPDATE organizations SET name = '', require_two_factor = false, updated_at = CURRENT_TIMESTAMP
+            WHERE owner_id = $1`,
+		`UPDATE users SET email = NULL, pending_email = NULL, email_verified_at = NULL, password_hash = '',
+            phone = NULL, phone_verified_at = NULL, name = '', avatar = NULL, avatar_type = NULL,
+            company_name = '', bin = '', notification_preferences = '{}',
+            deletion_scheduled_at = NULL, deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userID); err != nil {
+			return fmt.Errorf("error erasing account: %v", err)
+		}
+	}
+
+	if phone != "" {
+		if _, err := tx.Exec(`DELETE FROM phone_codes WHERE phone = $1`, phone); err != nil {
+			return fmt.Errorf("error erasing phone codes: %v", err)
+		}
+	}
+	if _, err := tx.Exec(`
+        DELETE FROM organization_invitations
+        WHERE accepted_at IS NULL AND (email = NULLIF($1, '') OR phone = NULLIF($2, ''))
+    `, email, phone); err != nil {
+		return fmt.Errorf("error erasing invitations: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// EraseGuest удаляет переписку, сообщения, согласия гостя и уведомления о нём
+// и стирает его имя и телефон в бронях.
+// Возвращает, сколько записей затронуто.
+func (r *PrivacyRepository) EraseGuest(userID uint, phones []string) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		// Уведомления о переписке и недоставленных сообщениях содержат номер гостя
+		`DELETE FROM notifications WHERE user_id = $1 AND (
+            data->>'conversation_id' IN (
+                SELECT id::text FROM conversations WHERE user_id = $1 AND ` + guestPhoneMatches + `)
+            OR data->>'outbound_message_id' IN (
+                SELECT id::text FROM outbound_messages WHERE user_id = $1 AND ` + guestPhoneMatches + `))`,
+		`DELETE FROM ai_tool_calls WHERE conversation_id IN (
+            SELECT id FROM conversations WHERE user_id = $1 AND ` + guestPhoneMatches + `)`,
+		`DELETE FROM conversations WHERE user_id = $1 AND ` + guestPhoneMatches,
+		`DELETE FROM outbound_messages WHERE user_id = $1 AND ` + guestPhoneMatches,
+		`DELETE FROM scheduled_messages WHERE user_id = $1 AND ` + guestPhoneMatches,
+		`DELETE FROM guest_consents WHERE user_id = $1 AND ` + guestPhoneMatches,
+		`DELETE FROM consent_log WHERE user_id = $1 AND ` + guestPhoneMatches,
+		`UPDATE booking_requests SET guest_name = NULL, guest_phone = NULL, updated_at = CURRENT_TIMESTAMP
+            WHERE user_id = $1 AND ` + guestPhoneMatches,
+		`UPDATE apartment_availability SET guest_name = NULL, guest_phone = NULL, updated_at = CURRENT_TIMESTAMP
+            WHERE apartment_id IN (SELECT id FROM apartments WHERE user_id = $1) AND ` + guestPhoneMatches,
+	}
+
+	var total int64
+	for _, statement := range statements {
+		result, err := tx.Exec(statement, userID, pq.Array(phones))
+		if err != nil {
+			return 0, fmt.Errorf("error erasing guest data: %v", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("error getting rows affected: %v", err)
+		}
+		total += rows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return total, nil
+}
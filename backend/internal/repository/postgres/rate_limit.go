@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/pkg/ratelimit"
+)
+
+const (
+	// rateLimitRetention - дольше этого события не хранятся, окна ограничений должны быть короче
+	rateLimitRetention  = 24 * time.Hour
+	rateLimitSweepEvery = 1000
+)
+
+// RateLimitRepository - общее хранилище ratelimit.Store для нескольких экземпляров сервера
+type RateLimitRepository struct {
+	db  *sql.DB
+	ops int64
+}
+
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+var _ ratelimit.Store = (*RateLimitRepository)(nil)
+
+func (r *RateLimitRepository) Hit(ctx context.Context, key string, now time.Time, window time.Duration) (ratelimit.Window, error) {
+	r.sweep(ctx, now)
+
+	since := now.Add(-window)
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_hits WHERE key = $1 AND hit_at <= $2`, key, since); err != nil {
+		return ratelimit.Window{}, fmt.Errorf("error pruning rate limit hits: %v", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `INSERT INTO rate_limit_hits (key, hit_at) VALUES ($1, $2)`, key, now); err != nil {
+		return ratelimit.Window{}, fmt.Errorf("error saving rate limit hit: %v", err)
+	}
+
+	return r.Peek(ctx, key, now, window)
+}
+
+// HitUnder держит блокировку ключа до конца транзакции, поэтому параллельный вызов
+// с тем же ключом ждёт и видит уже записанное событие
+func (r *RateLimitRepository) HitUnder(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (ratelimit.Window, bool, error) {
+	r.sweep(ctx, now)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ratelimit.Window{}, false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+		return ratelimit.Window{}, false, fmt.Errorf("error locking rate limit key: %v", err)
+	}
+
+	w, err := peekRateLimit(ctx, tx, key, now, window)
+	if err != nil {
+		return ratelimit.Window{}, false, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+        INSERT INTO rate_limit_hits (key, hit_at)
+        SELECT $1::varchar, $2::timestamptz
+        WHERE (SELECT COUNT(*) FROM rate_limit_hits WHERE key = $1 AND hit_at > $3) < $4
+    `, key, now, now.Add(-window), limit)
+	if err != nil {
+		return ratelimit.Window{}, false, fmt.Errorf("error saving rate limit hit: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return ratelimit.Window{}, false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ratelimit.Window{}, false, fmt.Errorf("error committing transaction: %v", err)
+	}
+	return w, rows > 0, nil
+}
+
+func (r *RateLimitRepository) Peek(ctx context.Context, key string, now time.Time, window time.Duration) (ratelimit.Window, error) {
+	return peekRateLimit(ctx, r.db, key, now, window)
+}
+
+func peekRateLimit(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, key string, now time.Time, window time.Duration) (ratelimit.Window, error) {
+	var w ratelimit.Window
+	var oldest, newest pq.NullTime
+	err := q.QueryRowContext(ctx, `
+        SELECT COUNT(*), MIN(hit_at), MAX(hit_at)
+        FROM rate_limit_hits
+        WHERE key = $1 AND hit_at > $2
+    `, key, now.Add(-window)).Scan(&w.Count, &oldest, &newest)
+	if err != nil {
+		return ratelimit.Window{}, fmt.Errorf("error counting rate limit hits: %v", err)
+	}
+
+	w.Oldest = oldest.Time
+	w.Newest = newest.Time
+	return w, nil
+}
+
+func (r *RateLimitRepository) Reset(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_hits WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("error resetting rate limit: %v", err)
+	}
+	return nil
+}
+
+// sweep время от времени удаляет старые события ключей, к которым больше не обращаются
+func (r *RateLimitRepository) sweep(ctx context.Context, now time.Time) {
+	if atomic.AddInt64(&r.ops, 1)%rateLimitSweepEvery != 0 {
+		return
+	}
+	r.db.ExecContext(ctx, `DELETE FROM rate_limit_hits WHERE hit_at < $1`, now.Add(-rateLimitRetention))
+}
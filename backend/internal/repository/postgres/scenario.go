@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type ScenarioRepository struct {
+	db *sql.DB
+}
+
+func NewScenarioRepository(db *sql.DB) *ScenarioRepository {
+	return &ScenarioRepository{db: db}
+}
+
+const scenarioColumns = `
+    id, user_id, name, trigger, offset_hours, send_time, template, template_id, enabled, created_at, updated_at
+`
+
+func scanScenario(row interface{ Scan(...interface{}) error }, scenario *model.Scenario) error {
+	return row.Scan(
+		&scenario.ID,
+		&scenario.UserID,
+		&scenario.Name,
+		&scenario.Trigger,
+		&scenario.OffsetHours,
+		&scenario.SendTime,
+		&scenario.Template,
+		&scenario.TemplateID,
+		&scenario.Enabled,
+		&scenario.CreatedAt,
+		&scenario.UpdatedAt,
+	)
+}
+
+func (r *ScenarioRepository) Create(scenario *model.Scenario) error {
+	query := `
+        INSERT INTO message_scenarios (user_id, name, trigger, offset_hours, send_time, template, template_id, enabled)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at, updated_at
+    `
+
+	err := r.db.QueryRow(
+		query,
+		scenario.UserID,
+		scenario.Name,
+		scenario.Trigger,
+		scenario.OffsetHours,
+		scenario.SendTime,
+		scenario.Template,
+		scenario.TemplateID,
+		scenario.Enabled,
+	).Scan(&scenario.ID, &scenario.CreatedAt, &scenario.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating scenario: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ScenarioRepository) GetByUserID(userID uint) ([]model.Scenario, error) {
+	query := `SELECT ` + scenarioColumns + `
+        FROM message_scenarios
+        WHERE user_id = $1
+        ORDER BY id
+    `
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying scenarios: %v", err)
+	}
+	defer rows.Close()
+
+	var scenarios []model.Scenario
+	for rows.Next() {
+		var scenario model.Scenario
+		if err := scanScenario(rows, &scenario); err != nil {
+			return nil, fmt.Errorf("error scanning scenario: %v", err)
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return scenarios, nil
+}
+
+func (r *ScenarioRepository) GetByID(userID uint, scenarioID string) (*model.Scenario, error) {
+	query := `SELECT ` + scenarioColumns + `
+        FROM message_scenarios
+        WHERE id = $1 AND user_id = $2
+    `
+
+	var scenario model.Scenario
+	err := scanScenario(r.db.QueryRow(query, scenarioID, userID), &scenario)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("scenario not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting scenario: %v", err)
+	}
+
+	return &scenario, nil
+}
+
+func (r *ScenarioRepository) Update(scenario *model.Scenario) error {
+	query := `
+        UPDATE message_scenarios
+        SET name = $1, trigger = $2, offset_hours = $3, send_time = $4,
+            template = $5, template_id = $6, enabled = $7, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $8 AND user_id = $9
+        RETURNING updated_at
+    `
+
+	err := r.db.QueryRow(
+		query,
+		scenario.Name,
+		scenario.Trigger,
+		scenario.OffsetHours,
+		scenario.SendTime,
+		scenario.Template,
+		scenario.TemplateID,
+		scenario.Enabled,
+		scenario.ID,
+		scenario.UserID,
+	).Scan(&scenario.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("scenario not found")
+	}
+	if err != nil {
+		return fmt.Errorf("error updating scenario: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ScenarioRepository) Delete(userID uint, scenarioID string) error {
+	result, err := r.db.Exec(`DELETE FROM message_scenarios WHERE id = $1 AND user_id = $2`, scenarioID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting scenario: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("scenario not found")
+	}
+
+	return nil
+}
+
+// GetGuestInfo возвращает сведения для заселения вместе с адресом квартиры, если
+// пользователь состоит в её организации. Если их ещё не заполняли, подставляются значения по умолчанию.
+func (r *ScenarioRepository) GetGuestInfo(userID uint, apartmentID string) (*model.ApartmentGuestInfo, error) {
+	query := `
+        SELECT
+            a.id, COALESCE(a.address, ''), COALESCE(a.complex, ''),
+            COALESCE(g.check_in_time, '14:00'), COALESCE(g.check_out_time, '12:00'),
+            COALESCE(g.door_code, ''), COALESCE(g.instructions, ''),
+            COALESCE(g.updated_at, a.updated_at)
+        FROM apartments a
+        LEFT JOIN apartment_guest_info g ON g.apartment_id = a.id
+        WHERE a.id = $1 AND EXISTS (
+            SELECT 1 FROM organization_members m
+            WHERE m.organization_id = a.organization_id AND m.user_id = $2
+        )
+    `
+
+	var info model.ApartmentGuestInfo
+	err := r.db.QueryRow(query, apartmentID, userID).Scan(
+		&info.ApartmentID,
+		&info.Address,
+		&info.Complex,
+		&info.CheckInTime,
+		&info.CheckOutTime,
+		&info.DoorCode,
+		&info.Instructions,
+		&info.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("apartment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting guest info: %v", err)
+	}
+
+	return &info, nil
+}
+
+func (r *ScenarioRepository) UpsertGuestInfo(info *model.ApartmentGuestInfo) error {
+	query := `
+        INSERT INTO apartment_guest_info (apartment_id, check_in_time, check_out_time, door_code, instructions)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (apartment_id) DO UPDATE SET
+            check_in_time = EXCLUDED.check_in_time,
+            check_out_time = EXCLUDED.check_out_time,
+            door_code = EXCLUDED.door_code,
+            instructions = EXCLUDED.instructions,
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING updated_at
+    `
+
+	err := r.db.QueryRow(
+		query,
+		info.ApartmentID,
+		info.CheckInTime,
+		info.CheckOutTime,
+		info.DoorCode,
+		info.Instructions,
+	).Scan(&info.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving guest info: %v", err)
+	}
+
+	return nil
+}
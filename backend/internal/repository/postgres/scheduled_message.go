@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type ScheduledMessageRepository struct {
+	db *sql.DB
+}
+
+func NewScheduledMessageRepository(db *sql.DB) *ScheduledMessageRepository {
+	return &ScheduledMessageRepository{db: db}
+}
+
+const scheduledMessageColumns = `
+    id, user_id, scenario_id, booking_request_id, guest_phone, send_at, status,
+    attempts, body, last_error, sent_at, created_at, updated_at
+`
+
+func scanScheduledMessage(row interface{ Scan(...interface{}) error }, msg *model.ScheduledMessage) error {
+	return row.Scan(
+		&msg.ID,
+		&msg.UserID,
+		&msg.ScenarioID,
+		&msg.BookingRequestID,
+		&msg.GuestPhone,
+		&msg.SendAt,
+		&msg.Status,
+		&msg.Attempts,
+		&msg.Body,
+		&msg.LastError,
+		&msg.SentAt,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+	)
+}
+
+// Schedule ставит сообщение в очередь. Возвращает false, если сообщение
+// этого сценария по этой заявке уже было запланировано.
+func (r *ScheduledMessageRepository) Schedule(msg *model.ScheduledMessage) (bool, error) {
+	query := `
+        INSERT INTO scheduled_messages (user_id, scenario_id, booking_request_id, guest_phone, send_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (scenario_id, booking_request_id) DO NOTHING
+        RETURNING ` + scheduledMessageColumns
+
+	err := scanScheduledMessage(r.db.QueryRow(
+		query,
+		msg.UserID,
+		msg.ScenarioID,
+		msg.BookingRequestID,
+		msg.GuestPhone,
+		msg.SendAt,
+	), msg)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error scheduling message: %v", err)
+	}
+
+	return true, nil
+}
+
+func (r *ScheduledMessageRepository) GetByUserID(userID uint) ([]model.ScheduledMessage, error) {
+	query := `SELECT ` + scheduledMessageColumns + `
+        FROM scheduled_messages
+        WHERE user_id = $1
+        ORDER BY send_at DESC
+        LIMIT 200
+    `
+
+	return r.query(query, userID)
+}
+
+// ClaimDue забирает в отправку созревшие сообщения. SKIP LOCKED позволяет
+// нескольким экземплярам сервиса не брать одни и те же строки.
+func (r *ScheduledMessageRepository) ClaimDue(limit int) ([]model.ScheduledMessage, error) {
+	query := `
+        UPDATE scheduled_messages
+        SET status = 'sending', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id IN (
+            SELECT id FROM scheduled_messages
+            WHERE status = 'pending' AND send_at <= CURRENT_TIMESTAMP
+            ORDER BY send_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + scheduledMessageColumns
+
+	return r.query(query, limit)
+}
+
+func (r *ScheduledMessageRepository) MarkSent(messageID uint, body string) error {
+	_, err := r.db.Exec(
+		`UPDATE scheduled_messages
+         SET status = 'sent', body = $1, last_error = '', sent_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+         WHERE id = $2`,
+		body,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("error marking message sent: %v", err)
+	}
+
+	return nil
+}
+
+// Reschedule возвращает сообщение в очередь для повторной попытки
+func (r *ScheduledMessageRepository) Reschedule(messageID uint, sendAt time.Time, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE scheduled_messages
+         SET status = 'pending', send_at = $1, last_error = $2, updated_at = CURRENT_TIMESTAMP
+         WHERE id = $3`,
+		sendAt,
+		lastError,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("error rescheduling message: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ScheduledMessageRepository) Finish(messageID uint, status model.ScheduledMessageStatus, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE scheduled_messages SET status = $1, last_error = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		status,
+		lastError,
+		messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating message status: %v", err)
+	}
+
+	return nil
+}
+
+// FailStale закрывает сообщения, зависшие в отправке, например после падения сервиса.
+// Повторно их не отправляем: неизвестно, ушло ли сообщение гостю.
+func (r *ScheduledMessageRepository) FailStale(olderThan time.Time) (int64, error) {
+	result, err := r.db.Exec(
+		`UPDATE scheduled_messages
+         SET status = 'failed', last_error = 'отправка прервана', updated_at = CURRENT_TIMESTAMP
+         WHERE status = 'sending' AND updated_at < $1`,
+		olderThan,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error failing stale messages: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *ScheduledMessageRepository) query(query string, args ...interface{}) ([]model.ScheduledMessage, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying scheduled messages: %v", err)
+	}
+	defer rows.Close()
+
+	var messages []model.ScheduledMessage
+	for rows.Next() {
+		var msg model.ScheduledMessage
+		if err := scanScheduledMessage(rows, &msg); err != nil {
+			return nil, fmt.Errorf("error scanning scheduled message: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_seen_at, expires_at`
+
+func scanSession(row interface{ Scan(...interface{}) error }, s *model.Session) error {
+	return row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.UserAgent,
+		&s.IP,
+		&s.CreatedAt,
+		&s.LastSeenAt,
+		&s.ExpiresAt,
+	)
+}
+
+// Create сохраняет новую сессию вместе с первым refresh-токеном
+func (r *SessionRepository) Create(session *model.Session, tokenHash string, tokenExpiresAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = scanSession(tx.QueryRow(`
+        INSERT INTO sessions (id, user_id, user_agent, ip, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING `+sessionColumns,
+		session.ID, session.UserID, session.UserAgent, session.IP, session.ExpiresAt,
+	), session)
+	if err != nil {
+		return fmt.Errorf("error creating session: %v", err)
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO refresh_tokens (token_hash, session_id, expires_at)
+        VALUES ($1, $2, $3)
+    `, tokenHash, session.ID, tokenExpiresAt)
+	if err != nil {
+		return fmt.Errorf("error saving refresh token: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Rotate меняет refresh-токен на новый. Если токен уже был использован, сессия
+// отзывается и возвращается с флагом reused = true.
+func (r *SessionRepository) Rotate(oldHash, newHash string, expiresAt time.Time, meta model.SessionMeta) (*model.Session, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var sessionID string
+	var usedAt, revokedAt pq.NullTime
+	var tokenExpiresAt, sessionExpiresAt time.Time
+	err = tx.QueryRow(`
+        SELECT rt.session_id, rt.used_at, rt.expires_at, s.revoked_at, s.expires_at
+        FROM refresh_tokens rt
+        JOIN sessions s ON s.id = rt.session_id
+        WHERE rt.token_hash = $1
+        FOR UPDATE
+    `, oldHash).Scan(&sessionID, &usedAt, &tokenExpiresAt, &revokedAt, &sessionExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, false, fmt.Errorf("refresh token not found")
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting refresh token: %v", err)
+	}
+
+	now := time.Now()
+	if revokedAt.Valid || now.After(sessionExpiresAt) {
+		return nil, false, fmt.Errorf("refresh token not found")
+	}
+
+	if usedAt.Valid {
+		var session model.Session
+		err = scanSession(tx.QueryRow(`
+            UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP, revoke_reason = $1
+            WHERE id = $2
+            RETURNING `+sessionColumns,
+			model.RevokeTokenReuse, sessionID,
+		), &session)
+		if err != nil {
+			return nil, false, fmt.Errorf("error revoking session: %v", err)
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, false, fmt.Errorf("error committing transaction: %v", err)
+		}
+		return &session, true, nil
+	}
+
+	if now.After(tokenExpiresAt) {
+		return nil, false, fmt.Errorf("refresh token not found")
+	}
+
+	if _, err = tx.Exec(`UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE token_hash = $1`, oldHash); err != nil {
+		return nil, false, fmt.Errorf("error using refresh token: %v", err)
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO refresh_tokens (token_hash, session_id, expires_at)
+        VALUES ($1, $2, $3)
+    `, newHash, sessionID, expiresAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("error saving refresh token: %v", err)
+	}
+
+	var session model.Session
+	err = scanSession(tx.QueryRow(`
+        UPDATE sessions
+        SET last_seen_at = CURRENT_TIMESTAMP, expires_at = $1, user_agent = $2, ip = $3
+        WHERE id = $4
+        RETURNING `+sessionColumns,
+		expiresAt, meta.UserAgent, meta.IP, sessionID,
+	), &session)
+	if err != nil {
+		return nil, false, fmt.Errorf("error updating session: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return &session, false, nil
+}
+
+// Touch отмечает активность сессии и проверяет, что она не отозвана и не истекла
+func (r *SessionRepository) Touch(userID uint, sessionID string) error {
+	result, err := r.db.Exec(`
+        UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+    `, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("error touching session: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) GetActiveByUserID(userID uint) ([]model.Session, error) {
+	query := `
+        SELECT ` + sessionColumns + `
+        FROM sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+        ORDER BY last_seen_at DESC
+    `
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sessions: %v", err)
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var session model.Session
+		if err := scanSession(rows, &session); err != nil {
+			return nil, fmt.Errorf("error scanning session: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Revoke(userID uint, sessionID, reason string) error {
+	result, err := r.db.Exec(`
+        UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP, revoke_reason = $1
+        WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+    `, reason, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("error revoking session: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+// RevokeByToken отзывает сессию, которой принадлежит refresh-токен
+func (r *SessionRepository) RevokeByToken(tokenHash, reason string) (*model.Session, error) {
+	var session model.Session
+	err := scanSession(r.db.QueryRow(`
+        UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP, revoke_reason = $1
+        WHERE revoked_at IS NULL AND id = (SELECT session_id FROM refresh_tokens WHERE token_hash = $2)
+        RETURNING `+sessionColumns,
+		reason, tokenHash,
+	), &session)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error revoking session: %v", err)
+	}
+
+	return &session, nil
+}
+
+// RevokeAll отзывает все активные сессии владельца и возвращает их id
+func (r *SessionRepository) RevokeAll(userID uint, reason string) ([]string, error) {
+	rows, err := r.db.Query(`
+        UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP, revoke_reason = $1
+        WHERE user_id = $2 AND revoked_at IS NULL
+        RETURNING id
+    `, reason, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking sessions: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning session id: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type ToolCallRepository struct {
+	db *sql.DB
+}
+
+func NewToolCallRepository(db *sql.DB) *ToolCallRepository {
+	return &ToolCallRepository{db: db}
+}
+
+func (r *ToolCallRepository) Create(call *model.ToolCall) error {
+	query := `
+        INSERT INTO ai_tool_calls (
+            user_id, conversation_id, tool, arguments, result, error, duration_ms
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `
+
+	err := r.db.QueryRow(
+		query,
+		call.UserID,
+		nullableID(call.ConversationID),
+		call.Tool,
+		call.Arguments,
+		call.Result,
+		call.Error,
+		call.DurationMs,
+	).Scan(&call.ID, &call.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error logging tool call: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type ApartmentTranslationRepository struct {
+	db *sql.DB
+}
+
+func NewApartmentTranslationRepository(db *sql.DB) *ApartmentTranslationRepository {
+	return &ApartmentTranslationRepository{db: db}
+}
+
+const translationColumns = `apartment_id, language, description, rules, status, generated, created_at, updated_at`
+
+func scanTranslation(row interface{ Scan(...interface{}) error }, t *model.ApartmentTranslation) error {
+	return row.Scan(
+		&t.ApartmentID,
+		&t.Language,
+		&t.Description,
+		&t.Rules,
+		&t.Status,
+		&t.Generated,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+}
+
+// Get возвращает nil без ошибки, если перевода на этот язык ещё нет
+func (r *ApartmentTranslationRepository) Get(apartmentID uint, language string) (*model.ApartmentTranslation, error) {
+	query := `SELECT ` + translationColumns + ` FROM apartment_translations WHERE apartment_id = $1 AND language = $2`
+
+	var t model.ApartmentTranslation
+	err := scanTranslation(r.db.QueryRow(query, apartmentID, language), &t)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting translation: %v", err)
+	}
+
+	return &t, nil
+}
+
+func (r *ApartmentTranslationRepository) GetByApartmentID(apartmentID uint) ([]model.ApartmentTranslation, error) {
+	query := `SELECT ` + translationColumns + ` FROM apartment_translations WHERE apartment_id = $1 ORDER BY language`
+
+	rows, err := r.db.Query(query, apartmentID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying translations: %v", err)
+	}
+	defer rows.Close()
+
+	var translations []model.ApartmentTranslation
+	for rows.Next() {
+		var t model.ApartmentTranslation
+		if err := scanTranslation(rows, &t); err != nil {
+			return nil, fmt.Errorf("error scanning translation: %v", err)
+		}
+		translations = append(translations, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return translations, nil
+}
+
+// GetPublished возвращает опубликованные переводы квартир, сгруппированные по квартире
+func (r *ApartmentTranslationRepository) GetPublished(apartmentIDs []uint) (map[uint][]model.ApartmentTranslation, error) {
+	translations := make(map[uint][]model.ApartmentTranslation)
+	if len(apartmentIDs) == 0 {
+		return translations, nil
+	}
+
+	ids := make([]int64, len(apartmentIDs))
+	for i, id := range apartmentIDs {
+		ids[i] = int64(id)
+	}
+
+	query := `SELECT ` + translationColumns + ` FROM apartment_translations
+        WHERE apartment_id = ANY($1) AND status = $2
+        ORDER BY apartment_id, language`
+
+	rows, err := r.db.Query(query, pq.Array(ids), model.TranslationPublished)
+	if err != nil {
+		return nil, fmt.Errorf("error querying published translations: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t model.ApartmentTranslation
+		if err := scanTranslation(rows, &t); err != nil {
+			return nil, fmt.Errorf("error scanning translation: %v", err)
+		}
+		translations[t.ApartmentID] = append(translations[t.ApartmentID], t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return translations, nil
+}
+
+// Upsert сохраняет текст как черновик: после любой правки перевод нужно опубликовать заново
+func (r *ApartmentTranslationRepository) Upsert(t *model.ApartmentTranslation) error {
+	query := `
+        INSERT INTO apartment_translations (apartment_id, language, description, rules, status, generated)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (apartment_id, language) DO UPDATE SET
+            description = EXCLUDED.description,
+            rules = EXCLUDED.rules,
+            status = EXCLUDED.status,
+            generated = EXCLUDED.generated,
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING ` + translationColumns
+
+	t.Status = model.TranslationDraft
+	err := scanTranslation(r.db.QueryRow(query, t.ApartmentID, t.Language, t.Description, t.Rules, t.Status, t.Generated), t)
+	if err != nil {
+		return fmt.Errorf("error saving translation: %v", err)
+	}
+
+	return nil
+}
+
+func (r *ApartmentTranslationRepository) SetStatus(apartmentID uint, language string, status model.TranslationStatus) error {
+	result, err := r.db.Exec(`
+        UPDATE apartment_translations
+        SET status = $1, updated_at = CURRENT_TIMESTAMP
+        WHERE apartment_id = $2 AND language = $3
+    `, status, apartmentID, language)
+	if err != nil {
+		return fmt.Errorf("error updating translation status: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("translation not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// Get возвращает nil без ошибки, если владелец ещё не начинал настройку 2FA
+func (r *TwoFactorRepository) Get(userID uint) (*model.TwoFactor, error) {
+	var tf model.TwoFactor
+	var confirmedAt pq.NullTime
+	err := r.db.QueryRow(`
+        SELECT user_id, secret, confirmed_at, last_used_step
+        FROM user_totp WHERE user_id = $1
+    `, userID).Scan(&tf.UserID, &tf.Secret, &confirmedAt, &tf.LastUsedStep)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting two-factor settings: %v", err)
+	}
+
+	if confirmedAt.Valid {
+		tf.Enabled = true
+		tf.ConfirmedAt = &confirmedAt.Time
+	}
+	return &tf, nil
+}
+
+// SaveSecret начинает настройку заново. Подтверждённую 2FA не перезаписывает.
+func (r *TwoFactorRepository) SaveSecret(userID uint, secret string) error {
+	result, err := r.db.Exec(`
+        INSERT INTO user_totp (user_id, secret)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE SET
+            secret = EXCLUDED.secret,
+            last_used_step = 0,
+            created_at = CURRENT_TIMESTAMP
+        WHERE user_totp.confirmed_at IS NULL
+    `, userID, secret)
+	if err != nil {
+		return fmt.Errorf("error saving two-factor secret: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("two-factor already enabled")
+	}
+
+	return nil
+}
+
+// Confirm включает 2FA и сохраняет первый набор резервных кодов
+func (r *TwoFactorRepository) Confirm(userID uint, step int64, recoveryHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+        UPDATE user_totp SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = $1
+        WHERE user_id = $2 AND confirmed_at IS NULL
+    `, step, userID)
+	if err != nil {
+		return fmt.Errorf("error confirming two-factor: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("two-factor setup not found")
+	}
+
+	if err = replaceRecoveryCodes(tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// UseStep запоминает шаг использованного кода. Повтор того же или более старого кода отклоняется.
+func (r *TwoFactorRepository) UseStep(userID uint, step int64) error {
+	result, err := r.db.Exec(`
+        UPDATE user_totp SET last_used_step = $1
+        WHERE user_id = $2 AND confirmed_at IS NOT NULL AND last_used_step < $1
+    `, step, userID)
+	if err != nil {
+		return fmt.Errorf("error using two-factor code: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("two-factor code not found")
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) UseRecoveryCode(userID uint, codeHash string) error {
+	result, err := r.db.Exec(`
+        UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+    `, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("error using recovery code: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("recovery code not found")
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err = replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID uint, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error deleting recovery codes: %v", err)
+	}
+
+	_, err := tx.Exec(`
+        INSERT INTO recovery_codes (user_id, code_hash)
+        SELECT $1, unnest($2::text[])
+    `, userID, pq.Array(codeHashes))
+	if err != nil {
+		return fmt.Errorf("error saving recovery codes: %v", err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(userID uint) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting recovery codes: %v", err)
+	}
+	return count, nil
+}
+
+// Delete отключает 2FA вместе с резервными кодами
+func (r *TwoFactorRepository) Delete(userID uint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error deleting recovery codes: %v", err)
+	}
+	if _, err = tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error deleting two-factor settings: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) CreateChallenge(tokenHash string, challenge *model.TwoFactorChallenge) error {
+	_, err := r.db.Exec(`
+        INSERT INTO two_factor_challenges (token_hash, user_id, purpose, expires_at)
+        VALUES ($1, $2, $3, $4)
+    `, tokenHash, challenge.UserID, challenge.Purpose, challenge.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("error creating two-factor challenge: %v", err)
+	}
+	return nil
+}
+
+// GetChallenge возвращает действующий промежуточный вход
+func (r *TwoFactorRepository) GetChallenge(tokenHash string) (*model.TwoFactorChallenge, error) {
+	var challenge model.TwoFactorChallenge
+	err := r.db.QueryRow(`
+        SELECT user_id, purpose, attempts, expires_at
+        FROM two_factor_challenges
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+    `, tokenHash).Scan(&challenge.UserID, &challenge.Purpose, &challenge.Attempts, &challenge.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("challenge not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting two-factor challenge: %v", err)
+	}
+
+	return &challenge, nil
+}
+
+func (r *TwoFactorRepository) AddChallengeAttempt(tokenHash string) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(`
+        UPDATE two_factor_challenges SET attempts = attempts + 1
+        WHERE token_hash = $1 AND used_at IS NULL
+        RETURNING attempts
+    `, tokenHash).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("challenge not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error updating two-factor challenge: %v", err)
+	}
+	return attempts, nil
+}
+
+func (r *TwoFactorRepository) UseChallenge(tokenHash string) error {
+	result, err := r.db.Exec(`
+        UPDATE two_factor_challenges SET used_at = CURRENT_TIMESTAMP
+        WHERE token_hash = $1 AND used_at IS NULL
+    `, tokenHash)
+	if err != nil {
+		return fmt.Errorf("error using two-factor challenge: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("challenge not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+type UsageRepository struct {
+	db *sql.DB
+}
+
+func NewUsageRepository(db *sql.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// usageGroups - допустимые разрезы отчёта, чтобы не подставлять в запрос произвольный текст
+var usageGroups = map[string]string{
+	"model":   "model",
+	"purpose": "purpose",
+	"day":     "to_char(created_at, 'YYYY-MM-DD')",
+}
+
+func (r *UsageRepository) Create(usage *model.LLMUsage) error {
+	query := `
+        INSERT INTO llm_usage (
+            user_id, conversation_id, purpose, model, prompt_tokens, completion_tokens, latency_ms, error
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at
+    `
+
+	err := r.db.QueryRow(
+		query,
+		usage.UserID,
+		usage.ConversationID,
+		usage.Purpose,
+		usage.Model,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.LatencyMs,
+		usage.Error,
+	).Scan(&usage.ID, &usage.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error recording llm usage: %v", err)
+	}
+
+	return nil
+}
+
+func (r *UsageRepository) GetPlan(userID uint) (model.Plan, error) {
+	var plan model.Plan
+	err := r.db.QueryRow(`SELECT plan FROM users WHERE id = $1`, userID).Scan(&plan)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting plan: %v", err)
+	}
+
+	return plan, nil
+}
+
+// TokensSince возвращает число токенов, потраченных владельцем с момента since
+func (r *UsageRepository) TokensSince(userID uint, since time.Time) (int, error) {
+	var tokens int
+	err := r.db.QueryRow(
+		`SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0) FROM llm_usage WHERE user_id = $1 AND created_at >= $2`,
+		userID,
+		since,
+	).Scan(&tokens)
+	if err != nil {
+		return 0, fmt.Errorf("error counting tokens: %v", err)
+	}
+
+	return tokens, nil
+}
+
+// Breakdown группирует вызовы за период [from, to) по модели, назначению или дню.
+// Строки дополнительно разбиты по модели, чтобы сервис мог посчитать стоимость.
+func (r *UsageRepository) Breakdown(userID uint, from, to time.Time, groupBy string) ([]model.UsageBreakdown, error) {
+	column, ok := usageGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported usage grouping: %s", groupBy)
+	}
+
+	query := `
+        SELECT ` + column + ` AS key, model,
+               COUNT(*),
+               COUNT(*) FILTER (WHERE error <> ''),
+               COALESCE(SUM(prompt_tokens), 0),
+               COALESCE(SUM(completion_tokens), 0),
+               COALESCE(AVG(latency_ms), 0)::INTEGER
+        FROM llm_usage
+        WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+        GROUP BY key, model
+        ORDER BY key, model
+    `
+
+	rows, err := r.db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error querying llm usage: %v", err)
+	}
+	defer rows.Close()
+
+	var items []model.UsageBreakdown
+	for rows.Next() {
+		var item model.UsageBreakdown
+		err := rows.Scan(
+			&item.Key,
+			&item.Model,
+			&item.Calls,
+			&item.Failed,
+			&item.PromptTokens,
+			&item.CompletionTokens,
+			&item.AvgLatencyMs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning llm usage: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return items, nil
+}
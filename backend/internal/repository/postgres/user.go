@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
+	"github.com/lib/pq"
 	"github.com/yourusername/uilet/internal/model"
 )
 
@@ -15,10 +17,47 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+const userColumns = `id, COALESCE(email, ''), password_hash, email_verified_at IS NOT NULL,
+            COALESCE(pending_email, ''), COALESCE(phone, ''), phone_verified_at IS NOT NULL,
+            name, avatar IS NOT NULL, language, timezone, notification_preferences,
+            company_name, bin, deletion_scheduled_at, deleted_at IS NOT NULL, created_at, updated_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }, u *model.User) error {
+	var preferencesJSON []byte
+	var deletionScheduledAt pq.NullTime
+	err := row.Scan(
+		&u.ID,
+		&u.Email,
+		&u.PasswordHash,
+		&u.EmailVerified,
+		&u.PendingEmail,
+		&u.Phone,
+		&u.PhoneVerified,
+		&u.Name,
+		&u.HasAvatar,
+		&u.Language,
+		&u.Timezone,
+		&preferencesJSON,
+		&u.CompanyName,
+		&u.BIN,
+		&deletionScheduledAt,
+		&u.Deleted,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if deletionScheduledAt.Valid {
+		u.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
+	return json.Unmarshal(preferencesJSON, &u.NotificationPreferences)
+}
+
 func (r *UserRepository) Create(user *model.User) error {
 	query := `
-        INSERT INTO users (email, password_hash, created_at, updated_at)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO users (email, password_hash, phone, phone_verified_at, language, created_at, updated_at)
+        VALUES (NULLIF($1, ''), $2, NULLIF($3, ''), CASE WHEN $4 THEN CURRENT_TIMESTAMP END, COALESCE(NULLIF($5, ''), 'ru'), $6, $7)
         RETURNING id
     `
 
@@ -26,6 +65,9 @@ func (r *UserRepository) Create(user *model.User) error {
 		query,
 		user.Email,
 		user.PasswordHash,
+		user.Phone,
+		user.PhoneVerified,
+		user.Language,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
@@ -39,18 +81,7 @@ func (r *UserRepository) Create(user *model.User) error {
 
 func (r *UserRepository) GetByEmail(email string) (*model.User, error) {
 	user := &model.User{}
-	query := `
-        SELECT id, email, password_hash, created_at, updated_at
-        FROM users WHERE email = $1
-    `
-
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.PasswordHash,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = $1`, email), user)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
@@ -65,17 +96,11 @@ func (r *UserRepository) GetByEmail(email string) (*model.User, error) {
 
 func (r *UserRepository) GetByID(id uint) (*model.User, error) {
 	user := &model.User{}
-	query := `
-        SELECT id, email, created_at, updated_at
-        FROM users WHERE id = $1
-    `
+	err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id), user)
 
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %v", err)
@@ -84,12 +109,165 @@ func (r *UserRepository) GetByID(id uint) (*model.User, error) {
 	return user, nil
 }
 
+// GetByPhone ищет владельца по подтверждённому номеру
+func (r *UserRepository) GetByPhone(phone string) (*model.User, error) {
+	user := &model.User{}
+	err := scanUser(r.db.QueryRow(`
+        SELECT `+userColumns+`
+        FROM users WHERE phone = $1 AND phone_verified_at IS NOT NULL
+    `, phone), user)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+
+	return user, nil
+}
+
+// UpdateProfile снимает подтверждение номера, если он изменился
 func (r *UserRepository) UpdateProfile(userID uint, input model.UpdateProfileInput) error {
-	_, err := r.db.Exec(
-		"UPDATE users SET name = $1, phone = $2 WHERE id = $3",
+	preferencesJSON, err := json.Marshal(input.NotificationPreferences)
+	if err != nil {
+		return fmt.Errorf("error marshaling notification preferences: %v", err)
+	}
+
+	result, err := r.db.Exec(`
+        UPDATE users SET name = $1, phone = NULLIF($2, ''),
+            phone_verified_at = CASE WHEN phone IS NOT DISTINCT FROM NULLIF($2, '') THEN phone_verified_at END,
+            language = $3, timezone = $4, notification_preferences = $5,
+            company_name = $6, bin = $7, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $8`,
 		input.Name,
 		input.Phone,
+		input.Language,
+		input.Timezone,
+		preferencesJSON,
+		input.CompanyName,
+		input.BIN,
 		userID,
 	)
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to update profile: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// SetAvatar сохраняет аватар; пустые data удаляют его
+func (r *UserRepository) SetAvatar(userID uint, data []byte, contentType string) error {
+	_, err := r.db.Exec(`
+        UPDATE users SET avatar = $1, avatar_type = NULLIF($2, ''), updated_at = CURRENT_TIMESTAMP
+        WHERE id = $3
+    `, data, contentType, userID)
+	if err != nil {
+		return fmt.Errorf("failed to save avatar: %v", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) GetAvatar(userID uint) ([]byte, string, error) {
+	var data []byte
+	var contentType string
+	err := r.db.QueryRow(`
+        SELECT avatar, COALESCE(avatar_type, '') FROM users WHERE id = $1 AND avatar IS NOT NULL
+    `, userID).Scan(&data, &contentType)
+	if err == sql.ErrNoRows {
+		return nil, "", fmt.Errorf("avatar not found")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get avatar: %v", err)
+	}
+
+	return data, contentType, nil
+}
+
+// SetPendingEmail запоминает новый адрес до подтверждения
+func (r *UserRepository) SetPendingEmail(userID uint, email string) error {
+	_, err := r.db.Exec(`
+        UPDATE users SET pending_email = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+    `, email, userID)
+	if err != nil {
+		return fmt.Errorf("failed to save pending email: %v", err)
+	}
+
+	return nil
+}
+
+// ChangeEmail делает подтверждённый новый адрес основным, если владелец не запросил
+// после этого другой. Возвращает прежний адрес.
+func (r *UserRepository) ChangeEmail(userID uint, email string) (string, error) {
+	var previous string
+	err := r.db.QueryRow(`
+        UPDATE users u SET email = $1, email_verified_at = CURRENT_TIMESTAMP,
+            pending_email = NULL, updated_at = CURRENT_TIMESTAMP
+        FROM (SELECT id, COALESCE(email, '') AS email FROM users WHERE id = $2) old
+        WHERE u.id = old.id AND u.pending_email = $1
+        RETURNING old.email
+    `, email, userID).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("user not found")
+	}
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return "", fmt.Errorf("email already in use")
+		}
+		return "", fmt.Errorf("failed to change email: %v", err)
+	}
+
+	return previous, nil
+}
+
+// MarkEmailVerified подтверждает email, если владелец не сменил его после отправки письма
+func (r *UserRepository) MarkEmailVerified(userID uint, email string) error {
+	result, err := r.db.Exec(`
+        UPDATE users SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND email = $2
+    `, userID, email)
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdatePassword(userID uint, passwordHash string) error {
+	result, err := r.db.Exec(
+		"UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		passwordHash,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
 }
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/hash"
+	"github.com/yourusername/uilet/pkg/mail"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+	emailChangeTTL       = 48 * time.Hour
+	// accountEmailsPerHour - сколько писем со ссылками можно отправить одному владельцу за час
+	accountEmailsPerHour = 5
+	emailSendTimeout     = 30 * time.Second
+)
+
+var (
+	ErrInvalidEmailToken    = errors.New("ссылка недействительна или устарела, запросите новую")
+	ErrEmailAlreadyVerified = errors.New("email уже подтверждён")
+	ErrAccountEmailLimit    = errors.New("слишком много писем, попробуйте позже")
+	ErrEmailTaken           = errors.New("этот email уже используется другим аккаунтом")
+	ErrWrongPassword        = errors.New("неверный пароль")
+)
+
+// AccountService отвечает за письма со ссылками: подтверждение и смена email, сброс пароля.
+// Токены одноразовые, живут недолго и хранятся в базе только в виде хеша.
+type AccountService struct {
+	users         *postgres.UserRepository
+	tokens        *postgres.AuthTokenRepository
+	phoneCodes    *postgres.PhoneCodeRepository
+	sender        mail.Sender
+	hasher        *hash.PasswordHasher
+	sessions      *SessionService
+	notifications *NotificationService
+	appURL        string
+	limiter       *hourlyLimiter
+}
+
+func NewAccountService(users *postgres.UserRepository, tokens *postgres.AuthTokenRepository, phoneCodes *postgres.PhoneCodeRepository, sender mail.Sender, hasher *hash.PasswordHasher, sessions *SessionService, notifications *NotificationService, appURL string) *AccountService {
+	return &AccountService{
+		users:         users,
+		tokens:        tokens,
+		phoneCodes:    phoneCodes,
+		sender:        sender,
+		hasher:        hasher,
+		sessions:      sessions,
+		notifications: notifications,
+		appURL:        strings.TrimRight(appURL, "/"),
+		limiter:       newHourlyLimiter(accountEmailsPerHour),
+	}
+}
+
+// SendVerification отправляет письмо со ссылкой подтверждения email
+func (s *AccountService) SendVerification(userID uint, language string) error {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return errors.New("в профиле не указан email")
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	if !s.limiter.allow(user.ID, time.Now()) {
+		return ErrAccountEmailLimit
+	}
+
+	return s.sendLink(user, user.Email, model.TokenEmailVerification, emailVerificationTTL, "/verify-email", language)
+}
+
+func (s *AccountService) VerifyEmail(token string) error {
+	t, err := s.tokens.Consume(hashToken(token), model.TokenEmailVerification)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidEmailToken
+		}
+		return err
+	}
+
+	if err := s.users.MarkEmailVerified(t.UserID, t.Email); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			// Владелец сменил email после отправки письма
+			return ErrInvalidEmailToken
+		}
+		return err
+	}
+	return nil
+}
+
+// RequestPasswordReset отправляет ссылку для сброса пароля. Ответ не зависит от того,
+// есть ли такой владелец, чтобы по нему нельзя было проверять чужие email.
+func (s *AccountService) RequestPasswordReset(input model.ForgotPasswordInput) error {
+	email := strings.TrimSpace(strings.ToLower(input.Email))
+	if !isValidEmail(email) {
+		return errors.New("некорректный формат email")
+	}
+
+	user, err := s.users.GetByEmail(email)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return err
+	}
+	if !s.limiter.allow(user.ID, time.Now()) {
+		log.Printf("Password reset limit reached for user %d", user.ID)
+		return nil
+	}
+
+	return s.sendLink(user, user.Email, model.TokenPasswordReset, passwordResetTTL, "/forgot-password", input.Language)
+}
+
+// ResetPassword задаёт новый пароль по ссылке из письма и завершает все сессии
+func (s *AccountService) ResetPassword(input model.ResetPasswordInput) error {
+	// Пароль проверяем до того, как погасить токен: с ошибкой в пароле ссылка остаётся рабочей
+	if err := validatePassword(input.Password); err != nil {
+		return err
+	}
+
+	passwordHash, err := s.hasher.Hash(input.Password)
+	if err != nil {
+		return fmt.Errorf("ошибка при хешировании пароля")
+	}
+
+	t, err := s.tokens.Consume(hashToken(input.Token), model.TokenPasswordReset)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidEmailToken
+		}
+		return err
+	}
+
+	// Ссылка, отправленная на прежний адрес, после смены email не действует
+	user, err := s.users.GetByID(t.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Email != t.Email {
+		return ErrInvalidEmailToken
+	}
+
+	if err := s.users.UpdatePassword(t.UserID, passwordHash); err != nil {
+		return err
+	}
+
+	// Переход по ссылке из письма заодно подтверждает, что почта принадлежит владельцу
+	if err := s.users.MarkEmailVerified(t.UserID, t.Email); err != nil && !strings.Contains(err.Error(), "not found") {
+		log.Printf("Failed to mark email verified for user %d: %v", t.UserID, err)
+	}
+
+	if _, err := s.sessions.LogoutAll(t.UserID); err != nil {
+		log.Printf("Failed to revoke sessions after password reset for user %d: %v", t.UserID, err)
+	}
+
+	s.notifications.Notify(
+		t.UserID,
+		model.NotificationSecurity,
+		"Пароль изменён",
+		"Пароль сброшен по ссылке из письма, все устройства вышли из аккаунта. Если это были не вы, сразу сбросьте пароль ещё раз.",
+		nil,
+	)
+	return nil
+}
+
+// RequestEmailChange отправляет ссылку подтверждения на новый адрес. Вход по прежнему
+// email работает, пока владелец не перейдёт по ссылке. Без пароля нужен код,
+// отправленный на номер владельца.
+func (s *AccountService) RequestEmailChange(userID uint, input model.ChangeEmailInput) error {
+	email := strings.TrimSpace(strings.ToLower(input.Email))
+	if !isValidEmail(email) {
+		return errors.New("некорректный формат email")
+	}
+
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	// У владельцев, вошедших по номеру телефона, пароля может не быть:
+	// тогда личность подтверждается свежим кодом на этот номер
+	switch {
+	case user.PasswordHash != "":
+		if !s.hasher.CheckPassword(input.Password, user.PasswordHash) {
+			return ErrWrongPassword
+		}
+	case user.PhoneVerified:
+		if err := consumePhoneCode(s.phoneCodes, user.Phone, input.PhoneCode); err != nil {
+			return err
+		}
+	default:
+		return ErrWrongPassword
+	}
+	if email == user.Email {
+		return errors.New("это уже ваш email")
+	}
+	if _, err := s.users.GetByEmail(email); err == nil {
+		return ErrEmailTaken
+	} else if !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+	if !s.limiter.allow(user.ID, time.Now()) {
+		return ErrAccountEmailLimit
+	}
+
+	if err := s.users.SetPendingEmail(user.ID, email); err != nil {
+		return err
+	}
+	return s.sendLink(user, email, model.TokenEmailChange, emailChangeTTL, "/confirm-email-change", "")
+}
+
+// ConfirmEmailChange делает новый адрес основным по ссылке из письма
+// и предупреждает об этом прежний адрес
+func (s *AccountService) ConfirmEmailChange(token string) error {
+	t, err := s.tokens.Consume(hashToken(token), model.TokenEmailChange)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidEmailToken
+		}
+		return err
+	}
+
+	previous, err := s.users.ChangeEmail(t.UserID, t.Email)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			// Владелец успел запросить смену на другой адрес
+			return ErrInvalidEmailToken
+		case strings.Contains(err.Error(), "already in use"):
+			return ErrEmailTaken
+		}
+		return err
+	}
+
+	s.notifications.Notify(
+		t.UserID,
+		model.NotificationSecurity,
+		"Email изменён",
+		fmt.Sprintf("Email аккаунта изменён на %s. Если это были не вы, обратитесь в поддержку.", t.Email),
+		nil,
+	)
+
+	if previous != "" {
+		user, err := s.users.GetByID(t.UserID)
+		if err != nil {
+			return err
+		}
+		msg, err := renderEmail(emailEmailChanged, user.Language, previous, emailData{Email: t.Email})
+		if err != nil {
+			return fmt.Errorf("failed to render email: %v", err)
+		}
+		s.deliver(t.UserID, emailEmailChanged, msg)
+	}
+	return nil
+}
+
+// sendLink создаёт токен и отправляет письмо со ссылкой на адрес to.
+// Без явного языка письмо пишется на языке из профиля.
+func (s *AccountService) sendLink(user *model.User, to, purpose string, ttl time.Duration, path, language string) error {
+	if language == "" {
+		language = user.Language
+	}
+
+	token, tokenHash, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.tokens.Create(tokenHash, &model.AuthToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     to,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := renderEmail(purpose, language, to, emailData{
+		Link:  s.appURL + path + "?token=" + url.QueryEscape(token),
+		Hours: int(ttl.Hours()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render email: %v", err)
+	}
+
+	s.deliver(user.ID, purpose, msg)
+	return nil
+}
+
+// NotifyExistingAccount сообщает владельцу, что кто-то регистрируется с его email.
+// Так повторная регистрация не раскрывает, что адрес уже занят.
+func (s *AccountService) NotifyExistingAccount(user *model.User, language string) error {
+	if !s.limiter.allow(user.ID, time.Now()) {
+		return nil
+	}
+	if language == "" {
+		language = user.Language
+	}
+
+	msg, err := renderEmail(emailAccountExists, language, user.Email, emailData{
+		Link: s.appURL + "/forgot-password",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render email: %v", err)
+	}
+
+	s.deliver(user.ID, emailAccountExists, msg)
+	return nil
+}
+
+// SendInvitation отправляет приглашение в организацию. Лимит приглашений
+// проверяет OrganizationService.
+func (s *AccountService) SendInvitation(invitedBy uint, to, language, organization, role, link string) error {
+	names, ok := roleNames[language]
+	if !ok {
+		names = roleNames[model.DefaultAILanguage]
+	}
+
+	msg, err := renderEmail(emailInvitation, language, to, emailData{
+		Link:         link,
+		Hours:        int(invitationTTL.Hours()),
+		Organization: organization,
+		Role:         names[role],
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render email: %v", err)
+	}
+
+	s.deliver(invitedBy, emailInvitation, msg)
+	return nil
+}
+
+// SendDeletionScheduled подтверждает запрос на удаление аккаунта письмом
+// с датой удаления по часовому поясу владельца
+func (s *AccountService) SendDeletionScheduled(user *model.User, at time.Time) error {
+	if user.Email == "" {
+		return nil
+	}
+	if location, err := time.LoadLocation(user.Timezone); err == nil {
+		at = at.In(location)
+	}
+
+	msg, err := renderEmail(emailAccountDeletion, user.Language, user.Email, emailData{
+		Link: s.appURL + "/profile",
+		Date: at.Format("02.01.2006"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render email: %v", err)
+	}
+
+	s.deliver(user.ID, emailAccountDeletion, msg)
+	return nil
+}
+
+// deliver отправляет письмо в фоне: SMTP-сервер может отвечать долго
+func (s *AccountService) deliver(userID uint, kind string, msg mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+		defer cancel()
+		if err := s.sender.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send %s email to user %d: %v", kind, userID, err)
+		}
+	}()
+}
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/llm"
+)
+
+const (
+	// Сколько раз подряд модель может вызывать инструменты до финального ответа
+	maxAgentSteps   = 5
+	maxPhotosToSend = 5
+)
+
+// agentSession - контекст, в котором агент выполняет инструменты:
+// все действия совершаются от имени владельца userID
+type agentSession struct {
+	userID       uint
+	conversation *model.Conversation
+	// send отправляет гостю сообщение в тот же чат, nil - отправка недоступна
+	send func(text string) error
+	// sendImage и sendLocation отправляют фото и точку на карте, nil - недоступно
+	sendImage    func(data []byte, mimeType, caption string) error
+	sendLocation func(latitude, longitude float64, name, address string) error
+	// escalate передаёт переписку владельцу после ответа, nil - передача недоступна
+	escalate func(reason string)
+	// dryRun - тестовый режим: инструменты не создают заявок и ничего не отправляют
+	dryRun bool
+	// facts собирает цены и даты из инструментов для проверки ответа, nil - не собирать
+	facts *replyFacts
+}
+
+func (s agentSession) conversationID() uint {
+	if s.conversation == nil {
+		return 0
+	}
+	return s.conversation.ID
+}
+
+type agentTool struct {
+	def llm.Tool
+	run func(a *AgentService, ctx context.Context, session agentSession, args json.RawMessage) (interface{}, error)
+}
+
+// AgentService - ИИ-агент, который может искать квартиры, проверять даты,
+// считать стоимость и создавать заявки через вызов инструментов
+type AgentService struct {
+	llm        llm.LLM
+	listings   *ListingContextBuilder
+	bookings   *BookingService
+	apartments *ApartmentService
+	toolLog    *postgres.ToolCallRepository
+	publicURL  string
+	tools      map[string]agentTool
+}
+
+func NewAgentService(llmClient llm.LLM, listings *ListingContextBuilder, bookings *BookingService, apartments *ApartmentService, toolLog *postgres.ToolCallRepository, publicURL string) *AgentService {
+	a := &AgentService{
+		llm:        llmClient,
+		listings:   listings,
+		bookings:   bookings,
+		apartments: apartments,
+		toolLog:    toolLog,
+		publicURL:  publicURL,
+		tools:      make(map[string]agentTool),
+	}
+	for _, tool := range agentTools {
+		a.tools[tool.def.Name] = tool
+	}
+	return a
+}
+
+// Run выполняет запрос, исполняя вызовы инструментов, пока модель не даст итоговый ответ
+func (a *AgentService) Run(ctx context.Context, req llm.Request, session agentSession) (*llm.Response, error) {
+	return runAgent(ctx, a.llm, req, func(call llm.ToolCall) string {
+		return a.execute(ctx, session, call)
+	})
+}
+
+// runAgent - цикл агента: модель вызывает инструменты через execute, пока не даст итоговый ответ
+func runAgent(ctx context.Context, client llm.LLM, req llm.Request, execute func(call llm.ToolCall) string) (*llm.Response, error) {
+	for _, tool := range agentTools {
+		req.Tools = append(req.Tools, tool.def)
+	}
+
+	for step := 0; step < maxAgentSteps; step++ {
+		resp, err := client.Complete(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.ToolCalls) == 0 {
+			return resp, nil
+		}
+
+		req.Messages = append(req.Messages, resp.Message())
+		for _, call := range resp.ToolCalls {
+			req.Messages = append(req.Messages, llm.Message{
+				Role:       llm.RoleTool,
+				ToolCallID: call.ID,
+				Content:    execute(call),
+			})
+		}
+	}
+
+	// Лимит шагов исчерпан - просим ответить без инструментов
+	req.Tools = nil
+	return client.Complete(ctx, req)
+}
+
+// execute выполняет инструмент и возвращает результат в JSON для модели.
+// Ошибки тоже отдаются модели, чтобы она могла объяснить их гостю.
+func (a *AgentService) execute(ctx context.Context, session agentSession, call llm.ToolCall) string {
+	started := time.Now()
+
+	var result interface{}
+	var err error
+	if tool, ok := a.tools[call.Name]; ok {
+		result, err = tool.run(a, ctx, session, json.RawMessage(call.Arguments))
+	} else {
+		err = fmt.Errorf("unknown tool %q", call.Name)
+	}
+
+	var output []byte
+	if err != nil {
+		output, _ = json.Marshal(map[string]string{"error": err.Error()})
+	} else if output, err = json.Marshal(result); err != nil {
+		output, _ = json.Marshal(map[string]string{"error": "failed to encode result"})
+	}
+
+	entry := &model.ToolCall{
+		UserID:         session.userID,
+		ConversationID: session.conversationID(),
+		Tool:           call.Name,
+		Arguments:      call.Arguments,
+		Result:         string(output),
+		DurationMs:     int(time.Since(started).Milliseconds()),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if logErr := a.toolLog.Create(entry); logErr != nil {
+		log.Printf("Error logging tool call %s: %v", call.Name, logErr)
+	}
+
+	if session.facts != nil && err == nil {
+		session.facts.addJSON(call.Arguments)
+		session.facts.addJSON(string(output))
+	}
+
+	return string(output)
+}
+
+type stayArgs struct {
+	ApartmentID uint   `json:"apartment_id"`
+	DateStart   string `json:"date_start"`
+	DateEnd     string `json:"date_end"`
+}
+
+var stayParameters = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"apartment_id": {"type": "integer", "description": "ID квартиры"},
+		"date_start": {"type": "string", "description": "Дата заезда, ГГГГ-ММ-ДД"},
+		"date_end": {"type": "string", "description": "Дата выезда, ГГГГ-ММ-ДД"}
+	},
+	"required": ["apartment_id", "date_start", "date_end"]
+}`)
+
+var agentTools = []agentTool{
+	{
+		def: llm.Tool{
+			Name:        "search_apartments",
+			Description: "Найти квартиры владельца по числу комнат, бюджету за сутки и датам",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"rooms": {"type": "integer", "description": "Число комнат"},
+					"max_price": {"type": "integer", "description": "Максимальная цена за сутки в тенге"},
+					"date_start": {"type": "string", "description": "Дата заезда, ГГГГ-ММ-ДД"},
+					"date_end": {"type": "string", "description": "Дата выезда, ГГГГ-ММ-ДД"}
+				}
+			}`),
+		},
+		run: (*AgentService).searchApartments,
+	},
+	{
+		def: llm.Tool{
+			Name:        "check_availability",
+			Description: "Проверить, свободна ли квартира на даты",
+			Parameters:  stayParameters,
+		},
+		run: (*AgentService).checkAvailability,
+	},
+	{
+		def: llm.Tool{
+			Name:        "get_quote",
+			Description: "Рассчитать стоимость проживания в квартире на даты",
+			Parameters:  stayParameters,
+		},
+		run: (*AgentService).getQuote,
+	},
+	{
+		def: llm.Tool{
+			Name:        "create_booking_request",
+			Description: "Создать заявку на бронирование. Заявка ждёт подтверждения владельца, сообщите об этом гостю.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"apartment_id": {"type": "integer", "description": "ID квартиры"},
+					"date_start": {"type": "string", "description": "Дата заезда, ГГГГ-ММ-ДД"},
+					"date_end": {"type": "string", "description": "Дата выезда, ГГГГ-ММ-ДД"},
+					"guest_name": {"type": "string", "description": "Имя гостя"}
+				},
+				"required": ["apartment_id", "date_start", "date_end"]
+			}`),
+		},
+		run: (*AgentService).createBookingRequest,
+	},
+	{
+		def: llm.Tool{
+			Name:        "send_photos",
+			Description: "Отправить гостю фотографии квартиры",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"apartment_id": {"type": "integer", "description": "ID квартиры"}
+				},
+				"required": ["apartment_id"]
+			}`),
+		},
+		run: (*AgentService).sendPhotos,
+	},
+	{
+		def: llm.Tool{
+			Name:        "send_location",
+			Description: "Отправить гостю точку на карте с адресом квартиры",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"apartment_id": {"type": "integer", "description": "ID квартиры"}
+				},
+				"required": ["apartment_id"]
+			}`),
+		},
+		run: (*AgentService).sendLocation,
+	},
+	{
+		def: llm.Tool{
+			Name:        "escalate_to_owner",
+			Description: "Передать переписку владельцу, если вы не уверены в ответе, вопрос вне ваших полномочий или гость недоволен",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"reason": {"type": "string", "description": "Коротко, почему нужен владелец"}
+				},
+				"required": ["reason"]
+			}`),
+		},
+		run: (*AgentService).escalateToOwner,
+	},
+}
+
+func (a *AgentService) searchApartments(ctx context.Context, session agentSession, raw json.RawMessage) (interface{}, error) {
+	apartments, err := a.listings.Apartments(session.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchListings(apartments, raw)
+}
+
+// searchListings отбирает квартиры по аргументам search_apartments
+func searchListings(apartments []model.Apartment, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Rooms     int    `json:"rooms"`
+		MaxPrice  int    `json:"max_price"`
+		DateStart string `json:"date_start"`
+		DateEnd   string `json:"date_end"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	var start, end time.Time
+	if args.DateStart != "" && args.DateEnd != "" {
+		var err error
+		if start, end, err = parseStay(args.DateStart, args.DateEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	type found struct {
+		ID      uint   `json:"id"`
+		Summary string `json:"summary"`
+		Total   int    `json:"total,omitempty"`
+	}
+	results := []found{}
+	for _, apt := range apartments {
+		if args.Rooms > 0 && apt.Rooms != args.Rooms {
+			continue
+		}
+		if args.MaxPrice > 0 && apt.Price > args.MaxPrice {
+			continue
+		}
+		item := found{ID: apt.ID, Summary: describeApartment(apt)}
+		if !start.IsZero() {
+			if !isFree(apt, start, end) {
+				continue
+			}
+			item.Total = QuoteStay(apt, start, end).Total
+		}
+		results = append(results, item)
+	}
+
+	return results, nil
+}
+
+func (a *AgentService) checkAvailability(ctx context.Context, session agentSession, raw json.RawMessage) (interface{}, error) {
+	var args stayArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	available, err := a.bookings.IsAvailable(session.userID, args.ApartmentID, args.DateStart, args.DateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"apartment_id": args.ApartmentID, "available": available}, nil
+}
+
+func (a *AgentService) getQuote(ctx context.Context, session agentSession, raw json.RawMessage) (interface{}, error) {
+	var args stayArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	return a.bookings.Quote(session.userID, args.ApartmentID, args.DateStart, args.DateEnd)
+}
+
+func (a *AgentService) createBookingRequest(ctx context.Context, session agentSession, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		stayArgs
+		GuestName string `json:"guest_name"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	input := model.CreateBookingRequestInput{
+		ApartmentID: args.ApartmentID,
+		DateStart:   args.DateStart,
+		DateEnd:     args.DateEnd,
+		GuestName:   args.GuestName,
+	}
+	if session.conversation != nil {
+		input.GuestPhone = session.conversation.GuestPhone
+	}
+
+	if session.dryRun {
+		quote, err := a.bookings.Quote(session.userID, input.ApartmentID, input.DateStart, input.DateEnd)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"status": "test_mode", "quote": quote}, nil
+	}
+
+	req, err := a.bookings.CreateRequest(session.userID, session.conversationID(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"booking_request_id": req.ID,
+		"status":             req.Status,
+		"total_price":        req.TotalPrice,
+		"note":               "Заявка ожидает подтверждения владельца",
+	}, nil
+}
+
+func (a *AgentService) sendPhotos(ctx context.Context, session agentSession, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		ApartmentID uint `json:"apartment_id"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	apt, err := a.bookings.findApartment(session.userID, args.ApartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if apt.ImageCount == 0 {
+		return nil, errors.New("у квартиры нет фотографий")
+	}
+
+	count := apt.ImageCount
+	if count > maxPhotosToSend {
+		count = maxPhotosToSend
+	}
+
+	if session.dryRun || (session.send == nil && session.sendImage == nil) {
+		return map[string]interface{}{"status": "test_mode", "photos": count}, nil
+	}
+
+	// Без отправки файлов остаётся отправить ссылки на фотографии
+	if session.sendImage == nil {
+		text := fmt.Sprintf("Фотографии квартиры #%d:", apt.ID)
+		for i := 0; i < count; i++ {
+			text += fmt.Sprintf("\n%s/api/apartments/%d/images/%d", a.publicURL, apt.ID, i)
+		}
+		if err := session.send(text); err != nil {
+			return nil, fmt.Errorf("failed to send photos: %v", err)
+		}
+		return map[string]interface{}{"status": "sent", "photos": count}, nil
+	}
+
+	sent := 0
+	for i := 0; i < count; i++ {
+		data, contentType, err := a.apartments.GetImage(fmt.Sprint(apt.ID), fmt.Sprint(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load photo: %v", err)
+		}
+
+		caption := ""
+		if i == 0 {
+			caption = fmt.Sprintf("Квартира #%d", apt.ID)
+		}
+		if err := session.sendImage(data, contentType, caption); err != nil {
+			if sent == 0 {
+				return nil, fmt.Errorf("failed to send photos: %v", err)
+			}
+			break
+		}
+		sent++
+	}
+
+	return map[string]interface{}{"status": "sent", "photos": sent}, nil
+}
+
+func (a *AgentService) sendLocation(ctx context.Context, session agentSession, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		ApartmentID uint `json:"apartment_id"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	apt, err := a.bookings.findApartment(session.userID, args.ApartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	lat, lon, hasPin := parseCoordinates(apt.Location)
+	if !hasPin && apt.Address == "" {
+		return nil, errors.New("у квартиры не указан адрес")
+	}
+
+	if session.dryRun || (session.send == nil && session.sendLocation == nil) {
+		return map[string]interface{}{"status": "test_mode", "address": apt.Address}, nil
+	}
+
+	if hasPin && session.sendLocation != nil {
+		name := "Квартира"
+		if apt.Complex != "" {
+			name = "ЖК " + apt.Complex
+		}
+		if err := session.sendLocation(lat, lon, name, apt.Address); err != nil {
+			return nil, fmt.Errorf("failed to send location: %v", err)
+		}
+		return map[string]interface{}{"status": "sent", "address": apt.Address}, nil
+	}
+
+	// Координат нет - отправляем адрес со ссылкой на карту
+	if session.send == nil {
+		return nil, errors.New("отправка геолокации недоступна")
+	}
+	query := apt.Address
+	if hasPin {
+		query = fmt.Sprintf("%f,%f", lat, lon)
+	}
+	if err := session.send(fmt.Sprintf("Адрес: %s\n%s", apt.Address, mapsLink(query))); err != nil {
+		return nil, fmt.Errorf("failed to send location: %v", err)
+	}
+	return map[string]interface{}{"status": "sent", "address": apt.Address}, nil
+}
+
+func (a *AgentService) escalateToOwner(ctx context.Context, session agentSession, raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	if session.dryRun || session.escalate == nil {
+		return map[string]string{"status": "test_mode"}, nil
+	}
+
+	session.escalate(args.Reason)
+	return map[string]string{"status": "escalated", "note": "Сообщите гостю, что владелец скоро ответит"}, nil
+}
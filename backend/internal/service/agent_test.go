@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yourusername/uilet/pkg/llm"
+)
+
+func TestRunAgentExecutesToolCalls(t *testing.T) {
+	fake := llm.NewFake()
+	fake.Script(
+		llm.Response{ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "get_quote", Arguments: `{"apartment_id":1}`}}},
+		llm.Response{Content: "Проживание стоит 30 000 ₸."},
+	)
+
+	var executed []llm.ToolCall
+	resp, err := runAgent(context.Background(), fake, llm.Request{
+		Messages: llm.Conversation("system", llm.Message{Role: llm.RoleUser, Content: "Сколько стоит?"}),
+	}, func(call llm.ToolCall) string {
+		executed = append(executed, call)
+		return `{"total":30000}`
+	})
+	if err != nil {
+		t.Fatalf("runAgent: %v", err)
+	}
+
+	if resp.Content != "Проживание стоит 30 000 ₸." {
+		t.Errorf("reply = %q", resp.Content)
+	}
+	if len(executed) != 1 || executed[0].Name != "get_quote" {
+		t.Fatalf("executed = %+v, want one get_quote call", executed)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("model called %d times, want 2", len(requests))
+	}
+	if len(requests[0].Tools) != len(agentTools) {
+		t.Errorf("first request has %d tools, want %d", len(requests[0].Tools), len(agentTools))
+	}
+
+	second := requests[1].Messages
+	last := second[len(second)-1]
+	if last.Role != llm.RoleTool || last.ToolCallID != "call-1" || last.Content != `{"total":30000}` {
+		t.Errorf("tool result message = %+v", last)
+	}
+	if call := second[len(second)-2]; call.Role != llm.RoleAssistant || len(call.ToolCalls) != 1 {
+		t.Errorf("assistant tool call message = %+v", call)
+	}
+}
+
+func TestRunAgentStopsAfterMaxSteps(t *testing.T) {
+	fake := llm.NewFake()
+	for i := 0; i < maxAgentSteps; i++ {
+		fake.Script(llm.Response{ToolCalls: []llm.ToolCall{{ID: "call", Name: "search_apartments", Arguments: `{}`}}})
+	}
+	fake.Script(llm.Response{Content: "Уточню и вернусь с ответом."})
+
+	steps := 0
+	resp, err := runAgent(context.Background(), fake, llm.Request{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "Есть квартиры?"}},
+	}, func(call llm.ToolCall) string {
+		steps++
+		return `[]`
+	})
+	if err != nil {
+		t.Fatalf("runAgent: %v", err)
+	}
+
+	if steps != maxAgentSteps {
+		t.Errorf("executed %d tool calls, want %d", steps, maxAgentSteps)
+	}
+	if resp.Content != "Уточню и вернусь с ответом." {
+		t.Errorf("reply = %q", resp.Content)
+	}
+
+	requests := fake.Requests()
+	if final := requests[len(requests)-1]; len(final.Tools) != 0 {
+		t.Errorf("final request has %d tools, want none", len(final.Tools))
+	}
+}
+
+func TestRunAgentReturnsModelError(t *testing.T) {
+	fake := llm.NewFake()
+	fake.Err = errors.New("model unavailable")
+
+	_, err := runAgent(context.Background(), fake, llm.Request{}, func(call llm.ToolCall) string {
+		t.Fatalf("tool %s executed after model error", call.Name)
+		return ""
+	})
+	if !errors.Is(err, fake.Err) {
+		t.Fatalf("err = %v, want %v", err, fake.Err)
+	}
+}
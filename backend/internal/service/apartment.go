@@ -8,35 +8,59 @@ import (
 	"github.com/yourusername/uilet/internal/repository/postgres"
 )
 
+// ApartmentService управляет квартирами организаций. Права участника проверяются
+// по его роли, а слушатели OnChange получают владельца организации: его WhatsApp
+// и ИИ обслуживают гостей этих квартир.
 type ApartmentService struct {
-	repo *postgres.ApartmentRepository
+	repo          *postgres.ApartmentRepository
+	translations  *postgres.ApartmentTranslationRepository
+	organizations *OrganizationService
+	listeners     []func(userID uint)
 }
 
-func NewApartmentService(repo *postgres.ApartmentRepository) *ApartmentService {
-	return &ApartmentService{repo: repo}
+func NewApartmentService(repo *postgres.ApartmentRepository, translations *postgres.ApartmentTranslationRepository, organizations *OrganizationService) *ApartmentService {
+	return &ApartmentService{repo: repo, translations: translations, organizations: organizations}
+}
+
+// OnChange регистрирует обработчик, вызываемый после любых изменений объявлений владельца
+func (s *ApartmentService) OnChange(listener func(userID uint)) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *ApartmentService) notify(userID uint) {
+	for _, listener := range s.listeners {
+		listener(userID)
+	}
 }
 
 func (s *ApartmentService) Create(userID uint, input model.CreateApartmentInput) (uint, error) {
+	org, err := s.organizations.ForNewApartment(userID, input.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+
 	apartment := &model.Apartment{
-		UserID:      userID,
-		Complex:     input.Complex,
-		Rooms:       input.Rooms,
-		Price:       input.Price,
-		Description: input.Description,
-		Address:     input.Address,
-		Area:        input.Area,
-		Floor:       input.Floor,
-		Amenities:   input.Amenities,
-		Location:    input.Location,
-		Rules:       input.Rules,
-		IsActive:    true,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		UserID:         org.OwnerID,
+		OrganizationID: org.ID,
+		Complex:        input.Complex,
+		Rooms:          input.Rooms,
+		Price:          input.Price,
+		Description:    input.Description,
+		Address:        input.Address,
+		Area:           input.Area,
+		Floor:          input.Floor,
+		Amenities:      input.Amenities,
+		Location:       input.Location,
+		Rules:          input.Rules,
+		IsActive:       true,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
 	if err := s.repo.Create(apartment); err != nil {
 		return 0, fmt.Errorf("failed to create apartment: %v", err)
 	}
+	s.notify(org.OwnerID)
 
 	return apartment.ID, nil
 }
@@ -46,6 +70,9 @@ func (s *ApartmentService) GetByUserID(userID uint) ([]model.Apartment, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get apartments: %v", err)
 	}
+	if err := s.withTranslations(apartments); err != nil {
+		return nil, err
+	}
 	return apartments, nil
 }
 
@@ -54,17 +81,51 @@ func (s *ApartmentService) GetApartmentDetails(userID uint, apartmentID string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get apartment details: %v", err)
 	}
+	translations, err := s.translations.GetPublished([]uint{apartment.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get translations: %v", err)
+	}
+	apartment.Translations = translations[apartment.ID]
 	return apartment, nil
 }
 
+// withTranslations добавляет к квартирам опубликованные переводы описаний
+func (s *ApartmentService) withTranslations(apartments []model.Apartment) error {
+	ids := make([]uint, len(apartments))
+	for i := range apartments {
+		ids[i] = apartments[i].ID
+	}
+
+	translations, err := s.translations.GetPublished(ids)
+	if err != nil {
+		return fmt.Errorf("failed to get translations: %v", err)
+	}
+	for i := range apartments {
+		apartments[i].Translations = translations[apartments[i].ID]
+	}
+	return nil
+}
+
 func (s *ApartmentService) Update(userID uint, apartmentID string, input model.UpdateApartmentInput) error {
-	if err := s.repo.Update(userID, apartmentID, &input); err != nil {
+	access, err := authorizeApartment(s.repo, userID, apartmentID, model.PermApartmentEdit)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(apartmentID, &input); err != nil {
 		return fmt.Errorf("failed to update apartment: %v", err)
 	}
+	s.notify(access.OwnerID)
 	return nil
 }
 
-func (s *ApartmentService) UpdateAvailabilities(apartmentID uint, availabilities []model.AvailabilityInput) error {
+func (s *ApartmentService) UpdateAvailabilities(userID uint, apartmentID uint, availabilities []model.AvailabilityInput) error {
+	access, err := authorizeApartment(s.repo, userID, fmt.Sprint(apartmentID), model.PermApartmentEdit)
+	if err != nil {
+		return err
+	}
+	defer s.notify(access.OwnerID)
+
 	// Сначала удаляем все существующие записи о доступности для этой квартиры
 	if err := s.repo.DeleteAvailabilities(apartmentID); err != nil {
 		return fmt.Errorf("failed to delete old availabilities: %v", err)
@@ -97,7 +158,11 @@ func (s *ApartmentService) UpdateAvailabilities(apartmentID uint, availabilities
 }
 
 func (s *ApartmentService) AddImages(userID uint, apartmentID string, imageData [][]byte, imageTypes []string) error {
-	if err := s.repo.AddImages(userID, apartmentID, imageData, imageTypes); err != nil {
+	if _, err := authorizeApartment(s.repo, userID, apartmentID, model.PermApartmentEdit); err != nil {
+		return err
+	}
+
+	if err := s.repo.AddImages(apartmentID, imageData, imageTypes); err != nil {
 		return fmt.Errorf("failed to add images: %v", err)
 	}
 	return nil
@@ -110,16 +175,48 @@ func (s *ApartmentService) GetImage(apartmentID string, imageIndex string) ([]by
 }
 
 func (s *ApartmentService) Delete(userID uint, apartmentID string) error {
-	return s.repo.Delete(userID, apartmentID)
+	access, err := authorizeApartment(s.repo, userID, apartmentID, model.PermApartmentDelete)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(apartmentID); err != nil {
+		return err
+	}
+	s.notify(access.OwnerID)
+	return nil
 }
 
 func (s *ApartmentService) DeleteImage(userID uint, apartmentID string, index int) error {
-	if err := s.repo.DeleteImage(userID, apartmentID, index); err != nil {
+	if _, err := authorizeApartment(s.repo, userID, apartmentID, model.PermApartmentEdit); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteImage(apartmentID, index); err != nil {
 		return fmt.Errorf("failed to delete image: %v", err)
 	}
 	return nil
 }
 
 func (s *ApartmentService) ToggleActive(userID uint, apartmentID string) error {
-	return s.repo.ToggleActive(userID, apartmentID)
+	access, err := authorizeApartment(s.repo, userID, apartmentID, model.PermApartmentEdit)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.ToggleActive(apartmentID); err != nil {
+		return err
+	}
+	s.notify(access.OwnerID)
+	return nil
+}
+
+// AddBooking отмечает период в календаре квартиры как занятый
+func (s *ApartmentService) AddBooking(userID uint, apartmentID uint, availability *model.Availability) error {
+	availability.Status = model.StatusBooked
+	if err := s.repo.CreateAvailability(apartmentID, availability); err != nil {
+		return fmt.Errorf("failed to create availability: %v", err)
+	}
+	s.notify(userID)
+	return nil
 }
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+const (
+	// apiKeyPrefix отличает API-ключ от JWT в заголовке Authorization и помогает
+	// сканерам секретов находить ключи, случайно попавшие в код
+	apiKeyPrefix = "uilet"
+	// maxAPIKeys - сколько действующих ключей может быть у владельца
+	maxAPIKeys = 20
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("недействительный API-ключ")
+	ErrAPIKeyLimit    = fmt.Errorf("можно создать не больше %d ключей, отзовите ненужные", maxAPIKeys)
+	ErrDuplicateScope = errors.New("права ключа повторяются")
+)
+
+// APIKeyService выдаёт личные API-ключи и проверяет их в запросах интеграций.
+// Ключ имеет вид uilet_<prefix>_<secret>: по prefix ключ ищется в базе, секрет сверяется с хешем.
+type APIKeyService struct {
+	repo          *postgres.APIKeyRepository
+	notifications *NotificationService
+}
+
+func NewAPIKeyService(repo *postgres.APIKeyRepository, notifications *NotificationService) *APIKeyService {
+	return &APIKeyService{repo: repo, notifications: notifications}
+}
+
+func (s *APIKeyService) Create(userID uint, input model.CreateAPIKeyInput) (*model.CreatedAPIKey, error) {
+	seen := make(map[string]bool)
+	for _, scope := range input.Scopes {
+		if seen[scope] {
+			return nil, ErrDuplicateScope
+		}
+		seen[scope] = true
+	}
+
+	count, err := s.repo.CountActive(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeys {
+		return nil, ErrAPIKeyLimit
+	}
+
+	prefix, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	secret, secretHash, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.APIKey{
+		UserID: userID,
+		Name:   strings.TrimSpace(input.Name),
+		Prefix: apiKeyPrefix + "_" + prefix,
+		Scopes: input.Scopes,
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.Create(secretHash, key); err != nil {
+		return nil, err
+	}
+
+	s.notifications.Notify(
+		userID,
+		model.NotificationSecurity,
+		"Создан API-ключ",
+		fmt.Sprintf("Создан API-ключ «%s» с правами: %s. Если это были не вы, отзовите его в профиле.", key.Name, strings.Join(key.Scopes, ", ")),
+		map[string]string{"api_key_id": fmt.Sprint(key.ID)},
+	)
+
+	return &model.CreatedAPIKey{APIKey: *key, Key: key.Prefix + "_" + secret}, nil
+}
+
+func (s *APIKeyService) GetByUserID(userID uint) ([]model.APIKey, error) {
+	keys, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %v", err)
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(userID, id uint) error {
+	return s.repo.Revoke(userID, id)
+}
+
+// IsAPIKey - похожа ли строка на API-ключ, а не на JWT
+func (s *APIKeyService) IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix+"_")
+}
+
+// AuthenticateAPIKey проверяет ключ и возвращает владельца и права ключа
+func (s *APIKeyService) AuthenticateAPIKey(token, ip string) (uint, []string, error) {
+	// Секрет в base64url может содержать "_", поэтому делим не больше чем на три части
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return 0, nil, ErrInvalidAPIKey
+	}
+
+	key, secretHash, err := s.repo.GetByPrefix(parts[0] + "_" + parts[1])
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return 0, nil, ErrInvalidAPIKey
+		}
+		return 0, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(parts[2])), []byte(secretHash)) != 1 {
+		return 0, nil, ErrInvalidAPIKey
+	}
+
+	if err := s.repo.Touch(key.ID, ip); err != nil {
+		log.Printf("Failed to record api key %d usage: %v", key.ID, err)
+	}
+	return key.UserID, key.Scopes, nil
+}
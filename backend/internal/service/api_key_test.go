@@ -0,0 +1,88 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	// Секрет в base64url может содержать "_", ключ должен делиться только по первым двум
+	const secret = "s3c_r3t-x"
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+		lookup  string
+	}{
+		{"valid key", "uilet_abc123_" + secret, nil, "uilet_abc123"},
+		{"wrong secret", "uilet_abc123_wrong", ErrInvalidAPIKey, "uilet_abc123"},
+		{"secret cut at underscore", "uilet_abc123_s3c", ErrInvalidAPIKey, "uilet_abc123"},
+		{"unknown prefix", "uilet_zzz999_" + secret, ErrInvalidAPIKey, "uilet_zzz999"},
+		{"other product prefix", "other_abc123_" + secret, ErrInvalidAPIKey, ""},
+		{"no secret", "uilet_abc123", ErrInvalidAPIKey, ""},
+		{"jwt", "eyJhbGciOiJIUzI1NiJ9.e30.sig", ErrInvalidAPIKey, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t)
+			fake.on("FROM api_keys", func(args []driver.Value) fakeResult {
+				if args[0] != "uilet_abc123" {
+					return fakeResult{}
+				}
+				return fakeResult{rows: [][]driver.Value{{
+					int64(3), int64(7), "CRM", "uilet_abc123", []byte("{apartments:read,bookings:read}"),
+					nil, nil, "", time.Now(), hashToken(secret),
+				}}}
+			})
+			fake.on("UPDATE api_keys SET last_used_at", func(args []driver.Value) fakeResult {
+				return fakeResult{rowsAffected: 1}
+			})
+			s := NewAPIKeyService(postgres.NewAPIKeyRepository(db), nil)
+
+			userID, scopes, err := s.AuthenticateAPIKey(tt.token, "10.0.0.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if userID != 7 || !reflect.DeepEqual(scopes, []string{"apartments:read", "bookings:read"}) {
+					t.Errorf("AuthenticateAPIKey = %d, %v; want owner 7 with the key scopes", userID, scopes)
+				}
+				if n := len(fake.executed("SET last_used_at")); n != 1 {
+					t.Errorf("key usage recorded %d times, want 1", n)
+				}
+			}
+
+			lookups := fake.executed("FROM api_keys")
+			if tt.lookup == "" {
+				if len(lookups) != 0 {
+					t.Errorf("malformed key looked up in the database")
+				}
+				return
+			}
+			if len(lookups) != 1 || lookups[0].args[0] != tt.lookup {
+				t.Errorf("lookups = %v, want one by prefix %q", lookups, tt.lookup)
+			}
+		})
+	}
+}
+
+func TestIsAPIKey(t *testing.T) {
+	s := NewAPIKeyService(nil, nil)
+	tests := map[string]bool{
+		"uilet_abc123_secret":          true,
+		"eyJhbGciOiJIUzI1NiJ9.e30.sig": false,
+		"uilet":                        false,
+		"":                             false,
+	}
+	for token, want := range tests {
+		if got := s.IsAPIKey(token); got != want {
+			t.Errorf("IsAPIKey(%q) = %v, want %v", token, got, want)
+		}
+	}
+}
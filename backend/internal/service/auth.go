@@ -1,32 +1,64 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/yourusername/uilet/internal/model"
 	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/internal/utils"
 	"github.com/yourusername/uilet/pkg/hash"
-	"github.com/yourusername/uilet/pkg/jwt"
 )
 
+// MaxAvatarSize - наибольший размер загружаемого аватара
+const MaxAvatarSize = 5 << 20
+
+var (
+	ErrAvatarType       = errors.New("аватар должен быть изображением JPEG, PNG или GIF")
+	ErrEmailNotVerified = errors.New("подтвердите email: мы отправили письмо со ссылкой")
+)
+
+var allowedAvatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
 type AuthService struct {
-	repo         *postgres.UserRepository
-	hasher       *hash.PasswordHasher
-	tokenManager *jwt.TokenManager
+	repo      *postgres.UserRepository
+	hasher    *hash.PasswordHasher
+	twoFactor *TwoFactorService
+	accounts  *AccountService
+	guard     *LoginGuard
+	// dummyHash сравнивается с паролем, когда email не найден, чтобы время ответа
+	// не выдавало, зарегистрирован ли адрес
+	dummyHash string
 }
 
-func NewAuthService(repo *postgres.UserRepository, hasher *hash.PasswordHasher, tokenManager *jwt.TokenManager) *AuthService {
+func NewAuthService(repo *postgres.UserRepository, hasher *hash.PasswordHasher, twoFactor *TwoFactorService, accounts *AccountService, guard *LoginGuard) *AuthService {
+	dummyHash, err := hasher.Hash("uilet-timing-equalizer")
+	if err != nil {
+		log.Printf("Failed to prepare dummy password hash: %v", err)
+	}
+
 	return &AuthService{
-		repo:         repo,
-		hasher:       hasher,
-		tokenManager: tokenManager,
+		repo:      repo,
+		hasher:    hasher,
+		twoFactor: twoFactor,
+		accounts:  accounts,
+		guard:     guard,
+		dummyHash: dummyHash,
 	}
 }
 
+// SignUp регистрирует владельца. Ответ одинаков для нового и уже занятого email:
+// владельцу существующего аккаунта уходит письмо со ссылкой на вход и сброс пароля.
 func (s *AuthService) SignUp(input model.SignUpInput) error {
 	// Валидация email
 	email := strings.TrimSpace(strings.ToLower(input.Email))
@@ -39,52 +71,86 @@ func (s *AuthService) SignUp(input model.SignUpInput) error {
 		return err
 	}
 
-	// Проверка существования пользователя
-	if _, err := s.repo.GetByEmail(email); err == nil {
-		return errors.New("пользователь с таким email уже существует")
-	}
-
-	// Хеширование пароля
+	// Пароль хешируем до проверки email, чтобы оба исхода занимали одинаковое время
 	passwordHash, err := s.hasher.Hash(input.Password)
 	if err != nil {
 		return fmt.Errorf("ошибка при хешировании пароля")
 	}
 
+	// Проверка существования пользователя
+	if existing, err := s.repo.GetByEmail(email); err == nil {
+		if err := s.accounts.NotifyExistingAccount(existing, input.Language); err != nil {
+			log.Printf("Failed to notify existing user %d about sign-up attempt: %v", existing.ID, err)
+		}
+		return nil
+	}
+
 	user := &model.User{
 		Email:        email,
 		PasswordHash: passwordHash,
+		Language:     input.Language,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
 	if err := s.repo.Create(user); err != nil {
+		// Адрес успел занять параллельный запрос
+		if existing, getErr := s.repo.GetByEmail(email); getErr == nil {
+			if err := s.accounts.NotifyExistingAccount(existing, input.Language); err != nil {
+				log.Printf("Failed to notify existing user %d about sign-up attempt: %v", existing.ID, err)
+			}
+			return nil
+		}
 		return fmt.Errorf("ошибка при создании пользователя")
 	}
 
+	// Регистрация не зависит от почты: письмо уйдёт повторно при попытке входа
+	if err := s.accounts.SendVerification(user.ID, input.Language); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	return nil
 }
 
-func (s *AuthService) SignIn(input model.SignInInput) (string, error) {
+// SignIn проверяет пароль. С неподтверждённым email вход отклоняется, а письмо
+// со ссылкой уходит повторно. Если у владельца включена 2FA, вместо токенов
+// возвращается промежуточный токен для второго шага.
+func (s *AuthService) SignIn(input model.SignInInput, meta model.SessionMeta) (*model.SignInResult, error) {
 	email := strings.TrimSpace(strings.ToLower(input.Email))
 	if !isValidEmail(email) {
-		return "", errors.New("некорректный формат email")
+		return nil, errors.New("некорректный формат email")
+	}
+
+	ctx := context.Background()
+	account := "email:" + email
+	if err := s.guard.Check(ctx, account, meta.IP); err != nil {
+		return nil, err
 	}
 
 	user, err := s.repo.GetByEmail(email)
 	if err != nil {
-		return "", errors.New("неверный email или пароль")
+		s.hasher.CheckPassword(input.Password, s.dummyHash)
+		s.guard.Fail(ctx, account)
+		return nil, errors.New("неверный email или пароль")
 	}
 
 	if !s.hasher.CheckPassword(input.Password, user.PasswordHash) {
-		return "", errors.New("неверный email или пароль")
+		s.guard.Fail(ctx, account)
+		return nil, errors.New("неверный email или пароль")
 	}
 
-	token, err := s.tokenManager.GenerateToken(user.ID)
-	if err != nil {
-		return "", fmt.Errorf("ошибка при создании токена")
+	s.guard.Succeed(ctx, account)
+
+	// Без подтверждения email аккаунт мог зарегистрировать кто угодно, знающий адрес.
+	// Пароль уже проверен, так что ответ не выдаёт, зарегистрирован ли email.
+	if !user.EmailVerified {
+		if err := s.accounts.SendVerification(user.ID, user.Language); err != nil {
+			log.Printf("Failed to resend verification email to user %d: %v", user.ID, err)
+		}
+		return nil, ErrEmailNotVerified
 	}
 
-	return token, nil
+	return s.twoFactor.Begin(user.ID, meta)
 }
 
 func (s *AuthService) GetUserByID(userID uint) (*model.User, error) {
@@ -95,8 +161,80 @@ func (s *AuthService) GetUserByID(userID uint) (*model.User, error) {
 	return user, nil
 }
 
-func (s *AuthService) UpdateProfile(userID uint, input model.UpdateProfileInput) error {
-	return s.repo.UpdateProfile(userID, input)
+// UpdateProfile проверяет и сохраняет профиль целиком
+func (s *AuthService) UpdateProfile(userID uint, input model.UpdateProfileInput) (*model.User, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.CompanyName = strings.TrimSpace(input.CompanyName)
+	input.BIN = strings.TrimSpace(input.BIN)
+
+	if input.Phone != "" {
+		phone, err := normalizePhone(input.Phone)
+		if err != nil {
+			return nil, err
+		}
+		input.Phone = phone
+	}
+
+	if input.Language == "" {
+		input.Language = model.DefaultAILanguage
+	}
+	if input.Timezone == "" {
+		input.Timezone = model.DefaultTimezone
+	}
+	if _, err := time.LoadLocation(input.Timezone); err != nil || !strings.Contains(input.Timezone, "/") {
+		return nil, errors.New("неизвестный часовой пояс")
+	}
+
+	if input.BIN != "" && !isValidBIN(input.BIN) {
+		return nil, errors.New("некорректный БИН/ИИН")
+	}
+
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Подтверждённый номер - способ входа, менять его можно только через вход по новому номеру
+	if user.PhoneVerified && input.Phone != user.Phone {
+		return nil, errors.New("подтверждённый номер нельзя изменить в профиле")
+	}
+
+	if input.NotificationPreferences == nil {
+		input.NotificationPreferences = user.NotificationPreferences
+	}
+	for kind := range input.NotificationPreferences {
+		if !isNotificationKind(kind) {
+			return nil, fmt.Errorf("неизвестный вид уведомлений: %s", kind)
+		}
+	}
+
+	if err := s.repo.UpdateProfile(userID, input); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(userID)
+}
+
+// SetAvatar проверяет формат изображения и сохраняет аватар уменьшенным
+func (s *AuthService) SetAvatar(userID uint, data []byte) error {
+	contentType := http.DetectContentType(data)
+	if !allowedAvatarTypes[contentType] {
+		return ErrAvatarType
+	}
+
+	optimized, err := utils.OptimizeImage(data)
+	if err != nil {
+		return ErrAvatarType
+	}
+
+	return s.repo.SetAvatar(userID, optimized, contentType)
+}
+
+func (s *AuthService) GetAvatar(userID uint) ([]byte, string, error) {
+	return s.repo.GetAvatar(userID)
+}
+
+func (s *AuthService) DeleteAvatar(userID uint) error {
+	return s.repo.SetAvatar(userID, nil, "")
 }
 
 // Вспомогательные функции валидации
@@ -133,8 +271,80 @@ func validatePassword(password string) error {
 	return nil
 }
 
-func isValidPhone(phone string) bool {
-	pattern := `^(\+7|8)[0-9]{10}$`
-	match, _ := regexp.MatchString(pattern, phone)
-	return match
+var errInvalidPhone = errors.New("некорректный номер телефона")
+
+// normalizePhone приводит номер к E.164, например +77011234567. Номер без кода страны
+// считается казахстанским: "8 701 123 45 67" и "701 123 45 67" дают +77011234567.
+func normalizePhone(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+
+	var digits strings.Builder
+	plus := false
+	for i, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			plus = true
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", errInvalidPhone
+		}
+	}
+
+	d := digits.String()
+	switch {
+	case plus:
+	case strings.HasPrefix(d, "00"):
+		d = d[2:]
+	case len(d) == 11 && d[0] == '8':
+		d = "7" + d[1:]
+	case len(d) == 10:
+		d = "7" + d
+	}
+
+	// E.164: до 15 цифр, код страны не начинается с 0; в зоне +7 номера из 11 цифр
+	if len(d) < 8 || len(d) > 15 || d[0] == '0' || (d[0] == '7' && len(d) != 11) {
+		return "", errInvalidPhone
+	}
+	return "+" + d, nil
+}
+
+func isNotificationKind(kind string) bool {
+	for _, k := range model.NotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidBIN проверяет БИН/ИИН: 12 цифр, последняя - контрольная.
+// Контрольная цифра - сумма первых 11 цифр с весами 1..11 по модулю 11; если вышло 10,
+// считается с весами 3..11, 1, 2, а повторные 10 означают недействительный номер.
+func isValidBIN(bin string) bool {
+	if len(bin) != 12 {
+		return false
+	}
+	digits := make([]int, 12)
+	for i, r := range bin {
+		if r < '0' || r > '9' {
+			return false
+		}
+		digits[i] = int(r - '0')
+	}
+
+	checksum := func(firstWeight int) int {
+		sum := 0
+		for i := 0; i < 11; i++ {
+			sum += digits[i] * ((firstWeight+i-1)%11 + 1)
+		}
+		return sum % 11
+	}
+
+	control := checksum(1)
+	if control == 10 {
+		control = checksum(3)
+	}
+	return control != 10 && control == digits[11]
 }
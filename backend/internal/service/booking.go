@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+type BookingService struct {
+	repo          *postgres.BookingRequestRepository
+	apartmentRepo *postgres.ApartmentRepository
+	apartments    *ApartmentService
+	listings      *ListingContextBuilder
+	notifications *NotificationService
+	onConfirm     []func(req *model.BookingRequest)
+}
+
+func NewBookingService(repo *postgres.BookingRequestRepository, apartmentRepo *postgres.ApartmentRepository, apartments *ApartmentService, listings *ListingContextBuilder, notifications *NotificationService) *BookingService {
+	return &BookingService{
+		repo:          repo,
+		apartmentRepo: apartmentRepo,
+		apartments:    apartments,
+		listings:      listings,
+		notifications: notifications,
+	}
+}
+
+// OnConfirm регистрирует обработчик, вызываемый после подтверждения заявки
+func (s *BookingService) OnConfirm(listener func(req *model.BookingRequest)) {
+	s.onConfirm = append(s.onConfirm, listener)
+}
+
+// findApartment ищет активную квартиру владельца
+func (s *BookingService) findApartment(userID uint, apartmentID uint) (*model.Apartment, error) {
+	apartments, err := s.listings.Apartments(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range apartments {
+		if apartments[i].ID == apartmentID {
+			return &apartments[i], nil
+		}
+	}
+	return nil, fmt.Errorf("apartment not found")
+}
+
+func (s *BookingService) Quote(userID uint, apartmentID uint, dateStart, dateEnd string) (*Quote, error) {
+	apt, err := s.findApartment(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := parseStay(dateStart, dateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := QuoteStay(*apt, start, end)
+	return &quote, nil
+}
+
+// IsAvailable сверяется с актуальным календарём в базе, а не с кэшем
+func (s *BookingService) IsAvailable(userID uint, apartmentID uint, dateStart, dateEnd string) (bool, error) {
+	if _, err := s.findApartment(userID, apartmentID); err != nil {
+		return false, err
+	}
+
+	start, end, err := parseStay(dateStart, dateEnd)
+	if err != nil {
+		return false, err
+	}
+
+	conflict, err := s.apartmentRepo.HasConflict(apartmentID, start, end)
+	if err != nil {
+		return false, err
+	}
+	return !conflict, nil
+}
+
+// CreateRequest создаёт заявку в статусе "ожидает подтверждения владельца"
+func (s *BookingService) CreateRequest(userID uint, conversationID uint, input model.CreateBookingRequestInput) (*model.BookingRequest, error) {
+	quote, err := s.Quote(userID, input.ApartmentID, input.DateStart, input.DateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := s.IsAvailable(userID, input.ApartmentID, input.DateStart, input.DateEnd)
+	if err != nil {
+		return nil, err
+	}
+	if !available {
+		return nil, errors.New("квартира занята на эти даты")
+	}
+
+	req := &model.BookingRequest{
+		UserID:         userID,
+		ApartmentID:    input.ApartmentID,
+		ConversationID: conversationID,
+		GuestName:      strings.TrimSpace(input.GuestName),
+		GuestPhone:     strings.TrimSpace(input.GuestPhone),
+		DateStart:      quote.DateStart,
+		DateEnd:        quote.DateEnd,
+		Nights:         quote.Nights,
+		TotalPrice:     quote.Total,
+		Status:         model.BookingPending,
+		Source:         "whatsapp_ai",
+	}
+
+	if err := s.repo.Create(req); err != nil {
+		return nil, fmt.Errorf("failed to create booking request: %v", err)
+	}
+
+	s.notifications.Notify(
+		userID,
+		model.NotificationBookingRequest,
+		"Новая заявка на бронирование",
+		fmt.Sprintf("Квартира #%d, %s – %s, %d ₸", req.ApartmentID,
+			req.DateStart.Format("02.01.2006"), req.DateEnd.Format("02.01.2006"), req.TotalPrice),
+		map[string]string{"booking_request_id": fmt.Sprint(req.ID)},
+	)
+
+	return req, nil
+}
+
+func (s *BookingService) GetByUserID(userID uint, status model.BookingRequestStatus) ([]model.BookingRequest, error) {
+	requests, err := s.repo.GetByUserID(userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking requests: %v", err)
+	}
+	return requests, nil
+}
+
+// Confirm подтверждает заявку и занимает даты в календаре квартиры
+func (s *BookingService) Confirm(userID uint, requestID string) (*model.BookingRequest, error) {
+	req, err := s.pending(userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	conflict, err := s.apartmentRepo.HasConflict(req.ApartmentID, req.DateStart, req.DateEnd)
+	if err != nil {
+		return nil, err
+	}
+	if conflict {
+		return nil, errors.New("квартира уже занята на эти даты")
+	}
+
+	availability := &model.Availability{
+		DateStart:  req.DateStart,
+		DateEnd:    req.DateEnd,
+		Source:     "uilet",
+		GuestName:  req.GuestName,
+		GuestPhone: req.GuestPhone,
+	}
+	if err := s.apartments.AddBooking(userID, req.ApartmentID, availability); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateStatus(userID, req.ID, model.BookingConfirmed, availability.ID); err != nil {
+		return nil, err
+	}
+
+	req.Status = model.BookingConfirmed
+	req.AvailabilityID = availability.ID
+	for _, listener := range s.onConfirm {
+		listener(req)
+	}
+	return req, nil
+}
+
+func (s *BookingService) Reject(userID uint, requestID string) (*model.BookingRequest, error) {
+	req, err := s.pending(userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateStatus(userID, req.ID, model.BookingRejected, 0); err != nil {
+		return nil, err
+	}
+
+	req.Status = model.BookingRejected
+	return req, nil
+}
+
+func (s *BookingService) pending(userID uint, requestID string) (*model.BookingRequest, error) {
+	req, err := s.repo.GetByID(userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != model.BookingPending {
+		return nil, errors.New("заявка уже обработана")
+	}
+	return req, nil
+}
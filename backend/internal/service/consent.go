@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+const (
+	optOutReply = "Вы отписались от автоматических сообщений. Чтобы снова их получать, напишите START."
+	optInReply  = "Вы снова подписаны на автоматические сообщения."
+)
+
+// Ключевые слова сравниваются со всем сообщением целиком, чтобы "стоп, а парковка есть?"
+// не отписывало гостя
+var (
+	optOutKeywords = map[string]bool{
+		"stop": true, "unsubscribe": true,
+		"стоп": true, "отписаться": true,
+		"тоқта": true, "токта": true,
+	}
+	optInKeywords = map[string]bool{
+		"start": true, "subscribe": true,
+		"старт": true, "подписаться": true,
+		"бастау": true,
+	}
+)
+
+// phoneTimezones - часовой пояс гостя по коду страны. Проверяются от длинного
+// префикса к короткому: +7 7xx и +7 6xx - Казахстан, остальные +7 - Россия.
+var phoneTimezones = map[string]string{
+	"77":  "Asia/Almaty",
+	"76":  "Asia/Almaty",
+	"7":   "Europe/Moscow",
+	"998": "Asia/Tashkent",
+	"996": "Asia/Bishkek",
+	"992": "Asia/Dushanbe",
+	"993": "Asia/Ashgabat",
+	"994": "Asia/Baku",
+	"995": "Asia/Tbilisi",
+	"374": "Asia/Yerevan",
+	"375": "Europe/Minsk",
+	"380": "Europe/Kyiv",
+	"971": "Asia/Dubai",
+	"90":  "Europe/Istanbul",
+	"86":  "Asia/Shanghai",
+	"82":  "Asia/Seoul",
+	"49":  "Europe/Berlin",
+	"44":  "Europe/London",
+}
+
+// ConsentService ведёт согласие гостей на автоматические сообщения и тихие часы.
+// Ответы владельца вручную согласием не ограничиваются.
+type ConsentService struct {
+	repo   *postgres.ConsentRepository
+	aiRepo *postgres.AIConfigRepository
+}
+
+func NewConsentService(repo *postgres.ConsentRepository, aiRepo *postgres.AIConfigRepository) *ConsentService {
+	return &ConsentService{
+		repo:   repo,
+		aiRepo: aiRepo,
+	}
+}
+
+// HandleGuestMessage отмечает согласие по сообщению гостя. Первое сообщение
+// считается согласием на ответы, STOP и START меняют его явно.
+// Возвращает текст подтверждения, если сообщение было командой подписки.
+func (s *ConsentService) HandleGuestMessage(userID uint, guestPhone, text string) (string, error) {
+	entry := &model.ConsentLogEntry{
+		UserID:     userID,
+		GuestPhone: guestPhone,
+		Message:    text,
+	}
+
+	if status, reply, ok := consentCommand(text); ok {
+		entry.Status, entry.Source = status, model.ConsentFromKeyword
+		if _, err := s.repo.Set(entry); err != nil {
+			return "", err
+		}
+		return reply, nil
+	}
+
+	consent, err := s.repo.Get(userID, guestPhone)
+	if err != nil {
+		return "", err
+	}
+	if consent == nil {
+		entry.Status, entry.Source = model.ConsentOptedIn, model.ConsentFromFirstMessage
+		if _, err := s.repo.Set(entry); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// Allowed проверяет, можно ли отправлять гостю автоматические сообщения
+func (s *ConsentService) Allowed(userID uint, guestPhone string) (bool, error) {
+	consent, err := s.repo.Get(userID, guestPhone)
+	if err != nil {
+		return false, err
+	}
+	return consent == nil || consent.Status != model.ConsentOptedOut, nil
+}
+
+// Prepare готовит автоматическое сообщение к постановке в очередь: возвращает false,
+// если гость отписался, а в тихие часы переносит отправку на их окончание
+func (s *ConsentService) Prepare(msg *model.OutboundMessage) (bool, error) {
+	allowed, err := s.Allowed(msg.UserID, msg.GuestPhone)
+	if err != nil || !allowed {
+		return false, err
+	}
+
+	if until, ok := s.quietUntil(msg.UserID, msg.GuestPhone, time.Now()); ok && until.After(msg.NextAttemptAt) {
+		msg.NextAttemptAt = until
+	}
+	return true, nil
+}
+
+func (s *ConsentService) GetByUserID(userID uint) ([]model.GuestConsent, error) {
+	consents, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consents: %v", err)
+	}
+	return consents, nil
+}
+
+func (s *ConsentService) GetLog(userID uint, guestPhone string) ([]model.ConsentLogEntry, error) {
+	entries, err := s.repo.GetLog(userID, strings.TrimPrefix(strings.TrimSpace(guestPhone), "+"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consent log: %v", err)
+	}
+	return entries, nil
+}
+
+// Update меняет согласие гостя по решению владельца
+func (s *ConsentService) Update(userID uint, guestPhone string, input model.UpdateConsentInput) (*model.GuestConsent, error) {
+	guestPhone = strings.TrimPrefix(strings.TrimSpace(guestPhone), "+")
+	if guestPhone == "" {
+		return nil, errors.New("укажите номер гостя")
+	}
+
+	entry := &model.ConsentLogEntry{
+		UserID:     userID,
+		GuestPhone: guestPhone,
+		Status:     input.Status,
+		Source:     model.ConsentFromOwner,
+		Message:    strings.TrimSpace(input.Note),
+	}
+	if _, err := s.repo.Set(entry); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Get(userID, guestPhone)
+}
+
+// quietUntil возвращает окончание тихих часов, если у гостя сейчас тихие часы
+func (s *ConsentService) quietUntil(userID uint, guestPhone string, now time.Time) (time.Time, bool) {
+	config, err := s.aiRepo.GetByUserID(userID)
+	if err != nil || config == nil || config.QuietHours.Start == "" || config.QuietHours.End == "" {
+		return time.Time{}, false
+	}
+
+	hours := model.BusinessHours{
+		Start:    config.QuietHours.Start,
+		End:      config.QuietHours.End,
+		Timezone: guestTimezone(guestPhone, config.BusinessHours.Timezone),
+	}
+	if !withinBusinessHours(hours, now) {
+		return time.Time{}, false
+	}
+
+	loc, err := time.LoadLocation(hours.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	until := atClock(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC), hours.End, hours.End, loc)
+	if !until.After(now) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// guestTimezone определяет часовой пояс по номеру гостя, иначе берёт пояс владельца
+func guestTimezone(phone, fallback string) string {
+	phone = strings.TrimPrefix(phone, "+")
+	for n := 3; n >= 1; n-- {
+		if len(phone) > n {
+			if tz, ok := phoneTimezones[phone[:n]]; ok {
+				return tz
+			}
+		}
+	}
+	if fallback == "" {
+		return model.DefaultTimezone
+	}
+	return fallback
+}
+
+// consentCommand распознаёт STOP или START и возвращает новое согласие
+// вместе с подтверждением для гостя
+func consentCommand(text string) (model.ConsentStatus, string, bool) {
+	keyword := normalizeKeyword(text)
+	switch {
+	case optOutKeywords[keyword]:
+		return model.ConsentOptedOut, optOutReply, true
+	case optInKeywords[keyword]:
+		return model.ConsentOptedIn, optInReply, true
+	}
+	return "", "", false
+}
+
+func normalizeKeyword(text string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(text)), " .!")
+}
+
+func validateQuietHours(hours model.QuietHours) error {
+	if (hours.Start == "") != (hours.End == "") {
+		return errors.New("нужно указать и начало, и конец тихих часов")
+	}
+	if hours.Start != "" {
+		if _, err := time.Parse("15:04", hours.Start); err != nil {
+			return errors.New("некорректное время начала тихих часов")
+		}
+		if _, err := time.Parse("15:04", hours.End); err != nil {
+			return errors.New("некорректное время окончания тихих часов")
+		}
+	}
+	return nil
+}
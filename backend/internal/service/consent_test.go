@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+func TestNormalizeKeyword(t *testing.T) {
+	tests := map[string]string{
+		"STOP":      "stop",
+		"  Стоп!  ": "стоп",
+		"старт.":    "старт",
+		"Тоқта":     "тоқта",
+		"стоп, а парковка есть?": "стоп, а парковка есть?",
+	}
+	for text, want := range tests {
+		if got := normalizeKeyword(text); got != want {
+			t.Errorf("normalizeKeyword(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestConsentCommand(t *testing.T) {
+	tests := []struct {
+		text   string
+		status model.ConsentStatus
+		reply  string
+		ok     bool
+	}{
+		{"STOP", model.ConsentOptedOut, optOutReply, true},
+		{"Отписаться.", model.ConsentOptedOut, optOutReply, true},
+		{"тоқта", model.ConsentOptedOut, optOutReply, true},
+		{"Start", model.ConsentOptedIn, optInReply, true},
+		{"бастау!", model.ConsentOptedIn, optInReply, true},
+		{"стоп, а парковка есть?", "", "", false},
+		{"Здравствуйте", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		status, reply, ok := consentCommand(tt.text)
+		if status != tt.status || reply != tt.reply || ok != tt.ok {
+			t.Errorf("consentCommand(%q) = %q, %q, %v; want %q, %q, %v", tt.text, status, reply, ok, tt.status, tt.reply, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/llm"
+)
+
+const (
+	// Сколько последних сообщений и токенов истории отправлять модели
+	historyMaxMessages = 20
+	historyTokenBudget = 1500
+
+	// Когда несжатых сообщений больше summarizeThreshold, старые сворачиваются
+	// в summary, а последние keepAfterSummary остаются как есть
+	summarizeThreshold = 30
+	keepAfterSummary   = 10
+)
+
+const summarizePrompt = `Ты ведёшь заметки для помощника по аренде квартир.
+Кратко перескажи переписку с гостем в 3-5 предложениях.
+Обязательно сохрани даты, количество гостей, имена, цены, выбранные квартиры и договорённости.`
+
+type ConversationService struct {
+	repo          *postgres.ConversationRepository
+	notifications *NotificationService
+	llm           llm.LLM
+}
+
+func NewConversationService(repo *postgres.ConversationRepository, notifications *NotificationService, llmClient llm.LLM) *ConversationService {
+	return &ConversationService{repo: repo, notifications: notifications, llm: llmClient}
+}
+
+// Record сохраняет сообщение в переписку владельца с гостем, создавая её при необходимости
+func (s *ConversationService) Record(userID uint, guestPhone string, role model.MessageRole, content string) (*model.Conversation, error) {
+	conv, err := s.GetOrCreate(userID, guestPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AddMessage(conv, role, content); err != nil {
+		return nil, err
+	}
+
+	return conv, nil
+}
+
+func (s *ConversationService) GetOrCreate(userID uint, guestPhone string) (*model.Conversation, error) {
+	conv, err := s.repo.GetOrCreate(userID, guestPhone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %v", err)
+	}
+	return conv, nil
+}
+
+func (s *ConversationService) AddMessage(conv *model.Conversation, role model.MessageRole, content string) error {
+	_, err := s.addMessage(conv, role, content, model.KindText, nil)
+	return err
+}
+
+func (s *ConversationService) AddOwnerMessage(conv *model.Conversation, content string) (*model.ConversationMessage, error) {
+	return s.addMessage(conv, model.RoleOwner, content, model.KindText, nil)
+}
+
+// AddMediaMessage сохраняет сообщение с вложением. content - текстовое описание
+// вложения, которое видит ИИ; mediaID может быть nil, если файл не сохраняется.
+func (s *ConversationService) AddMediaMessage(conv *model.Conversation, role model.MessageRole, kind model.MessageKind, content string, mediaID *uint) error {
+	_, err := s.addMessage(conv, role, content, kind, mediaID)
+	return err
+}
+
+func (s *ConversationService) addMessage(conv *model.Conversation, role model.MessageRole, content string, kind model.MessageKind, mediaID *uint) (*model.ConversationMessage, error) {
+	message := &model.ConversationMessage{
+		ConversationID: conv.ID,
+		Role:           role,
+		Content:        content,
+		Kind:           kind,
+		MediaID:        mediaID,
+	}
+	if err := s.repo.AddMessage(message); err != nil {
+		return nil, fmt.Errorf("failed to save message: %v", err)
+	}
+
+	conv.LastMessageAt = message.CreatedAt
+	return message, nil
+}
+
+// History возвращает последние сообщения переписки для модели,
+// укладываясь в historyMaxMessages и historyTokenBudget
+func (s *ConversationService) History(conv *model.Conversation) ([]llm.Message, error) {
+	messages, err := s.repo.GetRecentMessages(conv.ID, conv.SummarizedUntil, historyMaxMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %v", err)
+	}
+
+	budget := historyTokenBudget
+	start := len(messages)
+	for start > 0 {
+		cost := llm.EstimateTokens(messages[start-1].Content) + 4
+		// Последнее сообщение гостя отправляем всегда, даже если оно длинное
+		if cost > budget && start < len(messages) {
+			break
+		}
+		budget -= cost
+		start--
+	}
+
+	history := make([]llm.Message, 0, len(messages)-start)
+	for _, msg := range messages[start:] {
+		history = append(history, toLLMMessage(msg))
+	}
+
+	return history, nil
+}
+
+// SystemPrompt дополняет инструкцию ассистента кратким содержанием старой переписки
+func (s *ConversationService) SystemPrompt(prompt string, conv *model.Conversation) string {
+	if conv.Summary == "" {
+		return prompt
+	}
+	return prompt + "\n\nКраткое содержание предыдущей переписки с гостем:\n" + conv.Summary
+}
+
+// Summarize сворачивает старые сообщения в summary, если переписка стала длинной
+func (s *ConversationService) Summarize(ctx context.Context, conv *model.Conversation) error {
+	count, err := s.repo.CountMessagesAfter(conv.ID, conv.SummarizedUntil)
+	if err != nil {
+		return err
+	}
+	if count <= summarizeThreshold {
+		return nil
+	}
+
+	messages, err := s.repo.GetRecentMessages(conv.ID, conv.SummarizedUntil, count)
+	if err != nil {
+		return err
+	}
+	old := messages[:len(messages)-keepAfterSummary]
+
+	var transcript strings.Builder
+	if conv.Summary != "" {
+		fmt.Fprintf(&transcript, "Ранее: %s\n\n", conv.Summary)
+	}
+	for _, msg := range old {
+		fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, msg.Content)
+	}
+
+	resp, err := s.llm.Complete(withUsagePurpose(ctx, model.UsageSummary), llm.Request{
+		Messages: llm.Conversation(summarizePrompt, llm.Message{Role: llm.RoleUser, Content: transcript.String()}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to summarize conversation: %v", err)
+	}
+
+	summary := strings.TrimSpace(resp.Content)
+	until := old[len(old)-1].ID
+	if err := s.repo.UpdateSummary(conv.ID, summary, until); err != nil {
+		return err
+	}
+
+	conv.Summary = summary
+	conv.SummarizedUntil = until
+	return nil
+}
+
+func (s *ConversationService) GetByUserID(userID uint) ([]model.Conversation, error) {
+	conversations, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversations: %v", err)
+	}
+	return conversations, nil
+}
+
+func (s *ConversationService) Get(userID uint, conversationID string) (*model.Conversation, error) {
+	return s.repo.GetByID(userID, conversationID)
+}
+
+func (s *ConversationService) GetConversation(userID uint, conversationID string) (*model.Conversation, error) {
+	conv, err := s.repo.GetByID(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.repo.GetMessages(conv.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %v", err)
+	}
+	conv.Messages = messages
+
+	return conv, nil
+}
+
+func toLLMMessage(msg model.ConversationMessage) llm.Message {
+	if msg.Role == model.RoleGuest {
+		return llm.Message{Role: llm.RoleUser, Content: msg.Content}
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: msg.Content}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"text/template"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/pkg/mail"
+)
+
+const (
+	// emailAccountExists - письмо о попытке зарегистрироваться с уже занятым email
+	emailAccountExists = "account_exists"
+	// emailInvitation - приглашение в организацию
+	emailInvitation = "organization_invitation"
+	// emailEmailChanged - уведомление на прежний адрес о смене email
+	emailEmailChanged = "email_changed"
+	// emailAccountDeletion - подтверждение запроса на удаление аккаунта
+	emailAccountDeletion = "account_deletion"
+)
+
+// emailTemplate - письмо на одном языке. Text и HTML получают одни и те же данные.
+type emailTemplate struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type emailData struct {
+	Link  string
+	Hours int
+	// Organization и Role - для приглашений в организацию
+	Organization string
+	Role         string
+	// Email - новый адрес в письмах о смене email
+	Email string
+	// Date - дата удаления аккаунта
+	Date string
+}
+
+// roleNames - названия ролей в письмах
+var roleNames = map[string]map[string]string{
+	"ru": {
+		model.MemberOwner:       "владелец",
+		model.MemberManager:     "менеджер",
+		model.MemberHousekeeper: "горничная",
+		model.MemberViewer:      "наблюдатель",
+	},
+	"kk": {
+		model.MemberOwner:       "иесі",
+		model.MemberManager:     "менеджер",
+		model.MemberHousekeeper: "үй қызметшісі",
+		model.MemberViewer:      "бақылаушы",
+	},
+}
+
+const emailLayout = `<!DOCTYPE html><html><body style="font-family:Arial,sans-serif;color:#111">
+{{template "content" .}}
+<p style="color:#888;font-size:12px">Uilet</p>
+</body></html>`
+
+var emailTemplates = map[string]map[string]emailTemplate{
+	model.TokenEmailVerification: {
+		"ru": {
+			Subject: "Подтвердите email в Uilet",
+			Text: "Здравствуйте!\n\nПодтвердите адрес почты, перейдя по ссылке:\n{{.Link}}\n\n" +
+				"Ссылка действует {{.Hours}} ч. Если вы не регистрировались в Uilet, просто удалите это письмо.",
+			HTML: `<p>Здравствуйте!</p><p>Подтвердите адрес почты, нажав на кнопку:</p>
+<p><a href="{{.Link}}">Подтвердить email</a></p>
+<p>Ссылка действует {{.Hours}} ч. Если вы не регистрировались в Uilet, просто удалите это письмо.</p>`,
+		},
+		"kk": {
+			Subject: "Uilet-те email-ді растаңыз",
+			Text: "Сәлеметсіз бе!\n\nПошта мекенжайын мына сілтеме арқылы растаңыз:\n{{.Link}}\n\n" +
+				"Сілтеме {{.Hours}} сағат жарамды. Егер сіз Uilet-те тіркелмеген болсаңыз, бұл хатты жойыңыз.",
+			HTML: `<p>Сәлеметсіз бе!</p><p>Пошта мекенжайын батырма арқылы растаңыз:</p>
+<p><a href="{{.Link}}">Email-ді растау</a></p>
+<p>Сілтеме {{.Hours}} сағат жарамды. Егер сіз Uilet-те тіркелмеген болсаңыз, бұл хатты жойыңыз.</p>`,
+		},
+	},
+	model.TokenEmailChange: {
+		"ru": {
+			Subject: "Подтвердите новый email в Uilet",
+			Text: "Здравствуйте!\n\nЧтобы входить в Uilet с этим адресом, подтвердите его по ссылке:\n{{.Link}}\n\n" +
+				"Ссылка действует {{.Hours}} ч. Пока адрес не подтверждён, вход работает по прежнему email. " +
+				"Если вы не меняли email, просто удалите это письмо.",
+			HTML: `<p>Здравствуйте!</p><p>Чтобы входить в Uilet с этим адресом, подтвердите его:</p>
+<p><a href="{{.Link}}">Подтвердить новый email</a></p>
+<p>Ссылка действует {{.Hours}} ч. Пока адрес не подтверждён, вход работает по прежнему email.
+Если вы не меняли email, просто удалите это письмо.</p>`,
+		},
+		"kk": {
+			Subject: "Uilet-те жаңа email-ді растаңыз",
+			Text: "Сәлеметсіз бе!\n\nUilet-ке осы мекенжаймен кіру үшін оны мына сілтеме арқылы растаңыз:\n{{.Link}}\n\n" +
+				"Сілтеме {{.Hours}} сағат жарамды. Мекенжай расталмайынша, кіру бұрынғы email арқылы жұмыс істейді. " +
+				"Егер сіз email-ді өзгертпеген болсаңыз, бұл хатты жойыңыз.",
+			HTML: `<p>Сәлеметсіз бе!</p><p>Uilet-ке осы мекенжаймен кіру үшін оны растаңыз:</p>
+<p><a href="{{.Link}}">Жаңа email-ді растау</a></p>
+<p>Сілтеме {{.Hours}} сағат жарамды. Мекенжай расталмайынша, кіру бұрынғы email арқылы жұмыс істейді.
+Егер сіз email-ді өзгертпеген болсаңыз, бұл хатты жойыңыз.</p>`,
+		},
+	},
+	emailEmailChanged: {
+		"ru": {
+			Subject: "Email в Uilet изменён",
+			Text: "Здравствуйте!\n\nEmail вашего аккаунта в Uilet изменён на {{.Email}}. Входить теперь нужно с новым адресом.\n\n" +
+				"Если это были не вы, ответьте на это письмо - поддержка поможет вернуть доступ.",
+			HTML: `<p>Здравствуйте!</p><p>Email вашего аккаунта в Uilet изменён на {{.Email}}. Входить теперь нужно с новым адресом.</p>
+<p>Если это были не вы, ответьте на это письмо - поддержка поможет вернуть доступ.</p>`,
+		},
+		"kk": {
+			Subject: "Uilet-тегі email өзгертілді",
+			Text: "Сәлеметсіз бе!\n\nUilet-тегі аккаунтыңыздың email-і {{.Email}} болып өзгертілді. Енді жаңа мекенжаймен кіру керек.\n\n" +
+				"Егер бұл сіз болмасаңыз, осы хатқа жауап беріңіз - қолдау қызметі қолжетімділікті қайтаруға көмектеседі.",
+			HTML: `<p>Сәлеметсіз бе!</p><p>Uilet-тегі аккаунтыңыздың email-і {{.Email}} болып өзгертілді. Енді жаңа мекенжаймен кіру керек.</p>
+<p>Егер бұл сіз болмасаңыз, осы хатқа жауап беріңіз - қолдау қызметі қолжетімділікті қайтаруға көмектеседі.</p>`,
+		},
+	},
+	emailAccountDeletion: {
+		"ru": {
+			Subject: "Аккаунт в Uilet будет удалён {{.Date}}",
+			Text: "Здравствуйте!\n\nМы получили запрос на удаление вашего аккаунта в Uilet. {{.Date}} будут удалены профиль, " +
+				"переписка с гостями и их номера; брони останутся без имён и телефонов для учёта.\n\n" +
+				"До этого дня аккаунт работает как обычно. Чтобы отменить удаление, войдите и откройте профиль:\n{{.Link}}",
+			HTML: `<p>Здравствуйте!</p><p>Мы получили запрос на удаление вашего аккаунта в Uilet. {{.Date}} будут удалены профиль,
+переписка с гостями и их номера; брони останутся без имён и телефонов для учёта.</p>
+<p>До этого дня аккаунт работает как обычно.</p>
+<p><a href="{{.Link}}">Отменить удаление</a></p>`,
+		},
+		"kk": {
+			Subject: "Uilet-тегі аккаунт {{.Date}} жойылады",
+			Text: "Сәлеметсіз бе!\n\nUilet-тегі аккаунтыңызды жою сұрауын алдық. {{.Date}} профиль, қонақтармен хат алмасу " +
+				"және олардың нөмірлері жойылады; брондар есеп үшін аты мен телефонсыз сақталады.\n\n" +
+				"Осы күнге дейін аккаунт әдеттегідей жұмыс істейді. Жоюды болдырмау үшін кіріп, профильді ашыңыз:\n{{.Link}}",
+			HTML: `<p>Сәлеметсіз бе!</p><p>Uilet-тегі аккаунтыңызды жою сұрауын алдық. {{.Date}} профиль, қонақтармен хат алмасу
+және олардың нөмірлері жойылады; брондар есеп үшін аты мен телефонсыз сақталады.</p>
+<p>Осы күнге дейін аккаунт әдеттегідей жұмыс істейді.</p>
+<p><a href="{{.Link}}">Жоюды болдырмау</a></p>`,
+		},
+	},
+	emailInvitation: {
+		"ru": {
+			Subject: "Приглашение в «{{.Organization}}» в Uilet",
+			Text: "Здравствуйте!\n\nВас пригласили в «{{.Organization}}» в Uilet, роль: {{.Role}}. " +
+				"Чтобы принять приглашение, войдите или зарегистрируйтесь по ссылке:\n{{.Link}}\n\n" +
+				"Ссылка действует {{.Hours}} ч. Если вы не ждали приглашения, просто удалите это письмо.",
+			HTML: `<p>Здравствуйте!</p><p>Вас пригласили в «{{.Organization}}» в Uilet, роль: {{.Role}}.</p>
+<p><a href="{{.Link}}">Принять приглашение</a></p>
+<p>Ссылка действует {{.Hours}} ч. Если вы не ждали приглашения, просто удалите это письмо.</p>`,
+		},
+		"kk": {
+			Subject: "Uilet-тегі «{{.Organization}}» шақыруы",
+			Text: "Сәлеметсіз бе!\n\nСізді Uilet-тегі «{{.Organization}}» ұйымына шақырды, рөлі: {{.Role}}. " +
+				"Шақыруды қабылдау үшін мына сілтеме арқылы кіріңіз немесе тіркеліңіз:\n{{.Link}}\n\n" +
+				"Сілтеме {{.Hours}} сағат жарамды. Егер сіз шақыру күтпеген болсаңыз, бұл хатты жойыңыз.",
+			HTML: `<p>Сәлеметсіз бе!</p><p>Сізді Uilet-тегі «{{.Organization}}» ұйымына шақырды, рөлі: {{.Role}}.</p>
+<p><a href="{{.Link}}">Шақыруды қабылдау</a></p>
+<p>Сілтеме {{.Hours}} сағат жарамды. Егер сіз шақыру күтпеген болсаңыз, бұл хатты жойыңыз.</p>`,
+		},
+	},
+	emailAccountExists: {
+		"ru": {
+			Subject: "Попытка регистрации в Uilet",
+			Text: "Здравствуйте!\n\nКто-то, возможно вы, пытался зарегистрироваться в Uilet с этим адресом. " +
+				"У вас уже есть аккаунт: войдите в него или восстановите пароль по ссылке:\n{{.Link}}\n\n" +
+				"Если это были не вы, просто удалите это письмо.",
+			HTML: `<p>Здравствуйте!</p><p>Кто-то, возможно вы, пытался зарегистрироваться в Uilet с этим адресом.
+У вас уже есть аккаунт: войдите в него или восстановите пароль.</p>
+<p><a href="{{.Link}}">Восстановить пароль</a></p>
+<p>Если это были не вы, просто удалите это письмо.</p>`,
+		},
+		"kk": {
+			Subject: "Uilet-те тіркелу әрекеті",
+			Text: "Сәлеметсіз бе!\n\nБіреу, мүмкін сіз, осы мекенжаймен Uilet-те тіркелуге тырысты. " +
+				"Сізде аккаунт бар: оған кіріңіз немесе құпиясөзді мына сілтеме арқылы қалпына келтіріңіз:\n{{.Link}}\n\n" +
+				"Егер бұл сіз болмасаңыз, бұл хатты жойыңыз.",
+			HTML: `<p>Сәлеметсіз бе!</p><p>Біреу, мүмкін сіз, осы мекенжаймен Uilet-те тіркелуге тырысты.
+Сізде аккаунт бар: оған кіріңіз немесе құпиясөзді қалпына келтіріңіз.</p>
+<p><a href="{{.Link}}">Құпиясөзді қалпына келтіру</a></p>
+<p>Егер бұл сіз болмасаңыз, бұл хатты жойыңыз.</p>`,
+		},
+	},
+	model.TokenPasswordReset: {
+		"ru": {
+			Subject: "Сброс пароля в Uilet",
+			Text: "Здравствуйте!\n\nМы получили запрос на сброс пароля. Чтобы задать новый пароль, перейдите по ссылке:\n{{.Link}}\n\n" +
+				"Ссылка действует {{.Hours}} ч и сработает один раз. Если вы не запрашивали сброс, ничего не делайте - пароль останется прежним.",
+			HTML: `<p>Здравствуйте!</p><p>Мы получили запрос на сброс пароля. Чтобы задать новый пароль, нажмите на кнопку:</p>
+<p><a href="{{.Link}}">Задать новый пароль</a></p>
+<p>Ссылка действует {{.Hours}} ч и сработает один раз. Если вы не запрашивали сброс, ничего не делайте - пароль останется прежним.</p>`,
+		},
+		"kk": {
+			Subject: "Uilet-те құпиясөзді қалпына келтіру",
+			Text: "Сәлеметсіз бе!\n\nҚұпиясөзді қалпына келтіру сұрауын алдық. Жаңа құпиясөз орнату үшін сілтемеге өтіңіз:\n{{.Link}}\n\n" +
+				"Сілтеме {{.Hours}} сағат жарамды және бір рет қана жұмыс істейді. Егер сіз сұрау жібермеген болсаңыз, ештеңе істемеңіз - құпиясөз өзгермейді.",
+			HTML: `<p>Сәлеметсіз бе!</p><p>Құпиясөзді қалпына келтіру сұрауын алдық. Жаңа құпиясөз орнату үшін батырманы басыңыз:</p>
+<p><a href="{{.Link}}">Жаңа құпиясөз орнату</a></p>
+<p>Сілтеме {{.Hours}} сағат жарамды және бір рет қана жұмыс істейді. Егер сіз сұрау жібермеген болсаңыз, ештеңе істемеңіз - құпиясөз өзгермейді.</p>`,
+		},
+	},
+}
+
+// renderEmail собирает письмо по шаблону. Неизвестный язык заменяется русским.
+func renderEmail(purpose, language, to string, data emailData) (mail.Message, error) {
+	templates := emailTemplates[purpose]
+	tmpl, ok := templates[language]
+	if !ok {
+		tmpl = templates[model.DefaultAILanguage]
+	}
+
+	// Тема - тоже шаблон: в неё может попасть название организации
+	subject, err := template.New("subject").Parse(tmpl.Subject)
+	if err != nil {
+		return mail.Message{}, err
+	}
+	var subjectBuf bytes.Buffer
+	if err := subject.Execute(&subjectBuf, data); err != nil {
+		return mail.Message{}, err
+	}
+
+	text, err := template.New("text").Parse(tmpl.Text)
+	if err != nil {
+		return mail.Message{}, err
+	}
+	var textBuf bytes.Buffer
+	if err := text.Execute(&textBuf, data); err != nil {
+		return mail.Message{}, err
+	}
+
+	html, err := htmltemplate.New("layout").Parse(emailLayout)
+	if err == nil {
+		_, err = html.New("content").Parse(tmpl.HTML)
+	}
+	if err != nil {
+		return mail.Message{}, err
+	}
+	var htmlBuf bytes.Buffer
+	if err := html.Execute(&htmlBuf, data); err != nil {
+		return mail.Message{}, err
+	}
+
+	return mail.Message{
+		To:      to,
+		Subject: subjectBuf.String(),
+		Text:    textBuf.String(),
+		HTML:    htmlBuf.String(),
+	}, nil
+}
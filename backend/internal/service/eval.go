@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/pkg/llm"
+)
+
+const judgePrompt = "Вы проверяете ответы ассистента, который помогает гостям арендовать квартиры посуточно. " +
+	"Оцените последний ответ ассистента от 1 до 5: верны ли цены и даты по данным объектов, " +
+	"отвечает ли он на вопрос гостя, вежлив ли и краток. Ответьте строкой \"ОЦЕНКА: N\" и одним предложением пояснения."
+
+// judgePassScore - с какой оценки судьи ответ считается хорошим
+const judgePassScore = 4
+
+var (
+	judgeScorePattern = regexp.MustCompile(`(?i)(оценка|score)\s*:?\s*([1-5])`)
+
+	// Отказ проверяется раньше согласия: "не свободна" содержит "свободна"
+	unavailableMarkers = []string{
+		"занят", "не свобод", "нет свобод", "недоступ", "уже заброниров",
+		"not available", "unavailable", "already booked", "бос емес", "бос жоқ",
+	}
+	availableMarkers = []string{"свобод", "доступн", "available", "free", "бос"}
+
+	kazakhLetters = "әғқңөұүһіӘҒҚҢӨҰҮҺІ"
+)
+
+// EvalRunner прогоняет записанные переписки через ассистента с заданными настройками
+// и оценивает ответы правилами и, если задан judge, моделью-судьёй.
+// Инструменты агента работают по квартирам из самой переписки, база не нужна.
+type EvalRunner struct {
+	llm   llm.LLM
+	judge llm.LLM
+}
+
+// NewEvalRunner создаёт раннер, judge может быть nil
+func NewEvalRunner(llmClient llm.LLM, judge llm.LLM) *EvalRunner {
+	return &EvalRunner{llm: llmClient, judge: judge}
+}
+
+// LoadEvalSuite читает набор переписок из JSON-файла
+func LoadEvalSuite(path string) (*model.EvalSuite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read eval suite: %v", err)
+	}
+
+	var suite model.EvalSuite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse eval suite: %v", err)
+	}
+
+	if suite.Version == "" {
+		return nil, errors.New("у набора не указана версия")
+	}
+	seen := make(map[string]bool)
+	for _, c := range suite.Cases {
+		if c.ID == "" || seen[c.ID] {
+			return nil, fmt.Errorf("пустой или повторяющийся id переписки %q", c.ID)
+		}
+		seen[c.ID] = true
+		if len(c.Turns) == 0 || c.Turns[len(c.Turns)-1].Role != model.RoleGuest {
+			return nil, fmt.Errorf("переписка %s должна заканчиваться сообщением гостя", c.ID)
+		}
+		if c.Date != "" {
+			if _, err := time.Parse(dateLayout, c.Date); err != nil {
+				return nil, fmt.Errorf("переписка %s: некорректная дата %q", c.ID, c.Date)
+			}
+		}
+	}
+
+	return &suite, nil
+}
+
+// Run прогоняет все переписки набора с настройками config
+func (r *EvalRunner) Run(ctx context.Context, suite *model.EvalSuite, config *model.AIConfig, label string) *model.EvalRun {
+	run := &model.EvalRun{
+		Label:        label,
+		SuiteVersion: suite.Version,
+		Model:        config.Model,
+		StartedAt:    time.Now(),
+	}
+
+	total := 0.0
+	for _, c := range suite.Cases {
+		result := r.runCase(ctx, c, config)
+		run.Results = append(run.Results, result)
+		total += result.Score
+	}
+	if len(run.Results) > 0 {
+		run.Score = total / float64(len(run.Results))
+	}
+
+	return run
+}
+
+func (r *EvalRunner) runCase(ctx context.Context, c model.EvalCase, config *model.AIConfig) model.EvalCaseResult {
+	result := model.EvalCaseResult{CaseID: c.ID}
+
+	now := time.Now()
+	if c.Date != "" {
+		now, _ = time.Parse(dateLayout, c.Date)
+	}
+
+	history := evalHistory(c.Turns)
+	guestText := recentGuestText(history)
+	lastGuest := c.Turns[len(c.Turns)-1].Content
+
+	// Ответ собирается так же, как в WhatsAppService.respond
+	screenHistory(history)
+	facts := newReplyFacts(now)
+	for _, apt := range c.Apartments {
+		facts.addAmount(apt.Price)
+	}
+	for _, msg := range history {
+		if msg.Role == llm.RoleAssistant {
+			facts.addText(msg.Content)
+		}
+	}
+
+	systemPrompt := assistantPrompt(config, listingPrompt(c.Apartments, guestText), now)
+	resp, err := runAgent(ctx, r.llm, llm.Request{
+		Model:       config.Model,
+		Messages:    llm.Conversation(systemPrompt, history...),
+		Temperature: llm.Temperature(config.Temperature),
+		MaxTokens:   config.MaxTokens,
+	}, func(call llm.ToolCall) string {
+		output := evalTool(c.Apartments, call)
+		facts.addJSON(call.Arguments)
+		facts.addJSON(output)
+		return output
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Reply = resp.Content
+	result.Checks = evalChecks(c.Expect, lastGuest, resp.Content, facts)
+
+	if r.judge != nil {
+		score, detail := r.judgeReply(ctx, c, resp.Content)
+		result.JudgeScore = score
+		result.Checks = append(result.Checks, model.EvalCheck{
+			Name:   "judge",
+			Passed: score >= judgePassScore,
+			Detail: detail,
+		})
+	}
+
+	passed := 0
+	for _, check := range result.Checks {
+		if check.Passed {
+			passed++
+		}
+	}
+	result.Score = 1
+	if len(result.Checks) > 0 {
+		result.Score = float64(passed) / float64(len(result.Checks))
+	}
+
+	return result
+}
+
+// judgeReply просит модель-судью оценить ответ, 0 - судья не поставил оценку
+func (r *EvalRunner) judgeReply(ctx context.Context, c model.EvalCase, reply string) (int, string) {
+	var sb strings.Builder
+	sb.WriteString("Объекты владельца:\n")
+	sb.WriteString(listingPrompt(c.Apartments, ""))
+	sb.WriteString("\nПереписка:\n")
+	for _, turn := range c.Turns {
+		fmt.Fprintf(&sb, "%s: %s\n", turn.Role, turn.Content)
+	}
+	fmt.Fprintf(&sb, "\nОтвет ассистента:\n%s", reply)
+
+	resp, err := r.judge.Complete(ctx, llm.Request{
+		Messages: llm.Conversation(judgePrompt, llm.Message{Role: llm.RoleUser, Content: sb.String()}),
+	})
+	if err != nil {
+		return 0, fmt.Sprintf("ошибка судьи: %v", err)
+	}
+
+	m := judgeScorePattern.FindStringSubmatch(resp.Content)
+	if m == nil {
+		return 0, "судья не поставил оценку"
+	}
+	score, _ := strconv.Atoi(m[2])
+	return score, strings.TrimSpace(resp.Content)
+}
+
+// evalHistory переводит записанную переписку в сообщения модели
+func evalHistory(turns []model.EvalTurn) []llm.Message {
+	history := make([]llm.Message, 0, len(turns))
+	for _, turn := range turns {
+		role := llm.RoleUser
+		if turn.Role != model.RoleGuest {
+			role = llm.RoleAssistant
+		}
+		history = append(history, llm.Message{Role: role, Content: turn.Content})
+	}
+	return history
+}
+
+// evalTool выполняет инструмент агента по квартирам из переписки.
+// Инструменты, которые что-то меняют или отправляют, работают в тестовом режиме.
+func evalTool(apartments []model.Apartment, call llm.ToolCall) string {
+	result, err := evalToolResult(apartments, call)
+
+	var output []byte
+	if err != nil {
+		output, _ = json.Marshal(map[string]string{"error": err.Error()})
+	} else if output, err = json.Marshal(result); err != nil {
+		output, _ = json.Marshal(map[string]string{"error": "failed to encode result"})
+	}
+	return string(output)
+}
+
+func evalToolResult(apartments []model.Apartment, call llm.ToolCall) (interface{}, error) {
+	raw := json.RawMessage(call.Arguments)
+	if call.Name == "search_apartments" {
+		return searchListings(apartments, raw)
+	}
+
+	var args stayArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	var apt *model.Apartment
+	for i := range apartments {
+		if apartments[i].ID == args.ApartmentID {
+			apt = &apartments[i]
+		}
+	}
+
+	switch call.Name {
+	case "check_availability", "get_quote", "create_booking_request":
+		if apt == nil {
+			return nil, errors.New("apartment not found")
+		}
+		start, end, err := parseStay(args.DateStart, args.DateEnd)
+		if err != nil {
+			return nil, err
+		}
+		switch call.Name {
+		case "check_availability":
+			return map[string]interface{}{"apartment_id": apt.ID, "available": isFree(*apt, start, end)}, nil
+		case "get_quote":
+			return QuoteStay(*apt, start, end), nil
+		}
+		return map[string]interface{}{"status": "test_mode", "quote": QuoteStay(*apt, start, end)}, nil
+	case "send_photos", "send_location", "escalate_to_owner":
+		return map[string]string{"status": "test_mode"}, nil
+	}
+
+	return nil, fmt.Errorf("unknown tool %q", call.Name)
+}
+
+// evalChecks оценивает ответ правилами: цена, доступность, язык, обязательные
+// и запрещённые фразы и те же проверки, что перед отправкой гостю
+func evalChecks(expect model.EvalExpectation, guestText, reply string, facts *replyFacts) []model.EvalCheck {
+	var checks []model.EvalCheck
+	lower := strings.ToLower(reply)
+
+	if expect.Price > 0 {
+		check := model.EvalCheck{Name: "price"}
+		prices := findPrices(reply)
+		for _, amount := range prices {
+			if amount == expect.Price {
+				check.Passed = true
+			}
+		}
+		if !check.Passed {
+			check.Detail = fmt.Sprintf("ожидалась цена %d ₸, в ответе %v", expect.Price, prices)
+		}
+		checks = append(checks, check)
+	}
+
+	if expect.Available != nil {
+		check := model.EvalCheck{Name: "availability"}
+		says, known := saysAvailable(lower)
+		check.Passed = known && says == *expect.Available
+		if !check.Passed {
+			if known {
+				check.Detail = fmt.Sprintf("ответ говорит свободна=%t, ожидалось %t", says, *expect.Available)
+			} else {
+				check.Detail = "ответ не говорит, свободна ли квартира"
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	language := expect.Language
+	if language == "" {
+		language = detectLanguage(guestText)
+	}
+	if language != "" {
+		got := detectLanguage(reply)
+		check := model.EvalCheck{Name: "language", Passed: got == language}
+		if !check.Passed {
+			check.Detail = fmt.Sprintf("язык ответа %q, ожидался %q", got, language)
+		}
+		checks = append(checks, check)
+	}
+
+	for _, phrase := range expect.MustMention {
+		check := model.EvalCheck{Name: "mentions: " + phrase, Passed: strings.Contains(lower, strings.ToLower(phrase))}
+		checks = append(checks, check)
+	}
+	for _, phrase := range expect.MustNotMention {
+		check := model.EvalCheck{Name: "avoids: " + phrase, Passed: !strings.Contains(lower, strings.ToLower(phrase))}
+		checks = append(checks, check)
+	}
+
+	_, violation := checkReply(reply, facts)
+	checks = append(checks, model.EvalCheck{Name: "guardrails", Passed: violation == "", Detail: violation})
+
+	return checks
+}
+
+// saysAvailable определяет, сказал ли ответ, что квартира свободна.
+// Второе значение false, если ответ об этом ничего не говорит.
+func saysAvailable(lower string) (bool, bool) {
+	if containsAny(lower, unavailableMarkers) {
+		return false, true
+	}
+	if containsAny(lower, availableMarkers) {
+		return true, true
+	}
+	return false, false
+}
+
+// detectLanguage грубо определяет язык текста: kk, ru, en или пустая строка
+func detectLanguage(text string) string {
+	var cyrillic, latin int
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(kazakhLetters, r):
+			return "kk"
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch {
+	case cyrillic == 0 && latin == 0:
+		return ""
+	case cyrillic >= latin:
+		return "ru"
+	}
+	return "en"
+}
+
+// CompareEvalRuns сравнивает прогон новой версии настроек с прежним по каждой переписке
+func CompareEvalRuns(baseline, candidate *model.EvalRun) *model.EvalDiff {
+	diff := &model.EvalDiff{Baseline: baseline, Candidate: candidate}
+
+	before := make(map[string]model.EvalCaseResult)
+	for _, result := range baseline.Results {
+		before[result.CaseID] = result
+	}
+
+	for _, after := range candidate.Results {
+		prev := before[after.CaseID]
+		item := model.EvalCaseDiff{
+			CaseID:         after.CaseID,
+			BaselineScore:  prev.Score,
+			CandidateScore: after.Score,
+			BaselineReply:  prev.Reply,
+			CandidateReply: after.Reply,
+		}
+
+		passedBefore := make(map[string]bool)
+		for _, check := range prev.Checks {
+			passedBefore[check.Name] = check.Passed
+		}
+		for _, check := range after.Checks {
+			was, ok := passedBefore[check.Name]
+			switch {
+			case ok && was && !check.Passed:
+				item.Regressed = append(item.Regressed, check.Name)
+			case ok && !was && check.Passed:
+				item.Fixed = append(item.Fixed, check.Name)
+			}
+		}
+
+		diff.Cases = append(diff.Cases, item)
+	}
+
+	return diff
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB - база для тестов сервисов: запрос сопоставляется с обработчиком по
+// фрагменту SQL, обработчик возвращает строки или число изменённых строк.
+// Запрос без обработчика завершается ошибкой, как при недоступной базе.
+type fakeDB struct {
+	mu       sync.Mutex
+	handlers []fakeHandler
+	queries  []fakeQuery
+}
+
+type fakeHandler struct {
+	match  string
+	handle func(args []driver.Value) fakeResult
+}
+
+type fakeResult struct {
+	rows         [][]driver.Value
+	rowsAffected int64
+}
+
+type fakeQuery struct {
+	sql  string
+	args []driver.Value
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = map[string]*fakeDB{}
+)
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+// newFakeDB открывает пустую базу, обработчики добавляются через on
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	fake := &fakeDB{}
+
+	fakeDBsMu.Lock()
+	fakeDBs[t.Name()] = fake
+	fakeDBsMu.Unlock()
+
+	db, err := sql.Open("fakedb", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBsMu.Lock()
+		delete(fakeDBs, t.Name())
+		fakeDBsMu.Unlock()
+	})
+	return db, fake
+}
+
+// on отвечает на запросы, в тексте которых есть match
+func (f *fakeDB) on(match string, handle func(args []driver.Value) fakeResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers = append(f.handlers, fakeHandler{match: match, handle: handle})
+}
+
+// executed возвращает выполненные запросы, в тексте которых есть match
+func (f *fakeDB) executed(match string) []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []fakeQuery
+	for _, q := range f.queries {
+		if strings.Contains(q.sql, match) {
+			found = append(found, q)
+		}
+	}
+	return found
+}
+
+func (f *fakeDB) run(query string, args []driver.Value) (fakeResult, error) {
+	f.mu.Lock()
+	f.queries = append(f.queries, fakeQuery{sql: query, args: args})
+	var handle func([]driver.Value) fakeResult
+	for _, h := range f.handlers {
+		if strings.Contains(query, h.match) {
+			handle = h.handle
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if handle == nil {
+		return fakeResult{}, fmt.Errorf("fakedb: unexpected query %q", strings.Join(strings.Fields(query), " "))
+	}
+	return handle(args), nil
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	fake, ok := fakeDBs[name]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: unknown database %q", name)
+	}
+	return &fakeConn{db: fake}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	result, err := s.db.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(result.rowsAffected), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	result, err := s.db.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: result.rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+// Columns - имена колонок сервисам не нужны, важно только их число
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+const (
+	// Сколько бот молчит после сообщения владельца с телефона
+	ownerPauseDuration = 2 * time.Hour
+	// После скольких ошибок ИИ подряд переписка передаётся владельцу
+	maxAIFailures = 3
+
+	handoffReply = "Передаю ваш вопрос владельцу, он ответит вам в ближайшее время."
+)
+
+// Фразы гостя, по которым переписка передаётся владельцу. Отдельные слова вроде
+// "владелец" или "возврат" встречаются в обычных вопросах ("владелец разрешает животных?"),
+// поэтому ищутся только явные просьбы и жалобы, целыми словами. Остальное решает
+// модель через инструмент escalate_to_owner.
+var (
+	humanRequestPatterns = phrasePatterns(
+		`(позовите|позови|соедините|свяжите|переключите|передайте)[^.!?]{0,30}?(хозя|владельц|владелец|менеджер|оператор|администратор|человек)\p{L}*`,
+		`(хочу|можно|нужно|надо|могу|дайте)[^.!?]{0,20}?(поговорить|связаться|пообщаться)[^.!?]{0,20}?с (хозя|владельц|менеджер|оператор|администратор|живым человеком|человеком)\p{L}*`,
+		`живой человек`, `живым человеком`,
+		`(позвоните|перезвоните) мне`,
+		`(адаммен|иесімен|менеджермен|оператормен) (сөйлес|байланыс)\p{L}*`,
+		`(talk|speak|connect me)[^.!?]{0,20}?(to|with) (a |the )?(human|person|operator|manager|owner|host)`,
+		`real person`, `call me`,
+	)
+	complaintPatterns = phrasePatterns(
+		`(хочу|буду) (пожаловаться|жаловаться)`,
+		`(подам|напишу|оставлю|подать|написать|оставить) жалоб\p{L}*`,
+		`верните[^.!?]{0,15}?деньги`,
+		`(требую|хочу) (вернуть деньги|возврат\p{L}*)`,
+		`обманули`, `мошенни\p{L}*`,
+		`(в квартире|тут|здесь|очень) (грязно|ужасно)`,
+		`не работает`, `сломан\p{L}*`,
+		`шағым\p{L}*`,
+		`(want|need|demand|give me)[^.!?]{0,10}?refund`, `complain\p{L}*`,
+	)
+	// Фразы, по которым видно, что модель не уверена в ответе
+	lowConfidencePhrases = []string{
+		"не знаю", "не могу ответить", "не могу сказать", "затрудняюсь", "нет информации",
+		"уточню у владельца", "i don't know", "i'm not sure",
+	}
+)
+
+// escalationReason проверяет сообщение гостя по правилам передачи владельцу
+// и возвращает причину или пустую строку
+func escalationReason(guestText string) string {
+	text := strings.ToLower(guestText)
+	if matchesAny(text, humanRequestPatterns) {
+		return "гость просит связаться с владельцем"
+	}
+	if matchesAny(text, complaintPatterns) {
+		return "жалоба гостя"
+	}
+	return ""
+}
+
+func isLowConfidence(reply string) bool {
+	return containsAny(strings.ToLower(reply), lowConfidencePhrases)
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// phrasePatterns собирает регулярные выражения, совпадающие только целыми словами.
+// \b в Go понимает лишь латиницу, поэтому границы слов заданы через \p{L}.
+func phrasePatterns(phrases ...string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(phrases))
+	for i, phrase := range phrases {
+		patterns[i] = regexp.MustCompile(`(?:^|[^\p{L}\p{N}])(?:` + phrase + `)(?:$|[^\p{L}\p{N}])`)
+	}
+	return patterns
+}
+
+func matchesAny(text string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Escalate передаёт переписку владельцу и уведомляет его
+func (s *ConversationService) Escalate(conv *model.Conversation, reason string) error {
+	if err := s.repo.Escalate(conv.ID, reason); err != nil {
+		return err
+	}
+
+	conv.Mode = model.ModeHuman
+	conv.EscalationReason = reason
+
+	s.notifications.Notify(
+		conv.UserID,
+		model.NotificationEscalation,
+		"Гость ждёт вашего ответа",
+		fmt.Sprintf("Переписка с %s передана вам: %s", conv.GuestPhone, reason),
+		map[string]string{"conversation_id": fmt.Sprint(conv.ID)},
+	)
+	return nil
+}
+
+// RecordFailure учитывает ошибку ИИ и передаёт переписку владельцу после maxAIFailures подряд
+func (s *ConversationService) RecordFailure(conv *model.Conversation) error {
+	count, err := s.repo.IncrementFailures(conv.ID)
+	if err != nil {
+		return err
+	}
+	conv.FailureCount = count
+
+	if count >= maxAIFailures {
+		return s.Escalate(conv, "ассистент не смог ответить несколько раз подряд")
+	}
+	return nil
+}
+
+func (s *ConversationService) ResetFailures(conv *model.Conversation) error {
+	if conv.FailureCount == 0 {
+		return nil
+	}
+	conv.FailureCount = 0
+	return s.repo.ResetFailures(conv.ID)
+}
+
+func (s *ConversationService) SetMode(userID uint, conversationID string, mode model.ConversationMode) (*model.Conversation, error) {
+	conv, err := s.repo.GetByID(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pausedUntil *time.Time
+	if mode == model.ModePaused {
+		until := time.Now().Add(ownerPauseDuration)
+		pausedUntil = &until
+	}
+
+	if err := s.repo.SetMode(conv.ID, mode, pausedUntil); err != nil {
+		return nil, err
+	}
+
+	conv.Mode = mode
+	conv.PausedUntil = pausedUntil
+	return conv, nil
+}
+
+// PauseForOwner ставит бота на паузу, когда владелец пишет гостю сам.
+// Перехваченную владельцем переписку не трогаем.
+func (s *ConversationService) PauseForOwner(conv *model.Conversation) error {
+	if conv.Mode == model.ModeHuman {
+		return nil
+	}
+
+	until := time.Now().Add(ownerPauseDuration)
+	if err := s.repo.SetMode(conv.ID, model.ModePaused, &until); err != nil {
+		return err
+	}
+
+	conv.Mode = model.ModePaused
+	conv.PausedUntil = &until
+	return nil
+}
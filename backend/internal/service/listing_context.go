@@ -0,0 +1,235 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+const (
+	listingCacheTTL     = 10 * time.Minute
+	listingHorizon      = 60 * 24 * time.Hour
+	maxListingsInPrompt = 5
+	maxDescriptionRunes = 200
+)
+
+var (
+	roomsPattern = regexp.MustCompile(`(\d)\s*-?\s*(?:х\s*)?(?:комн|к(?:\s|$|[.,])|room|бөлме)`)
+	roomsWords   = map[string]int{
+		"однушк": 1, "однокомнат": 1, "студи": 1,
+		"двушк": 2, "двухкомнат": 2,
+		"трешк": 3, "трёшк": 3, "трехкомнат": 3, "трёхкомнат": 3,
+	}
+)
+
+type listingCacheEntry struct {
+	apartments []model.Apartment
+	loadedAt   time.Time
+}
+
+// ListingContextBuilder собирает для ИИ-ассистента сведения об объектах владельца:
+// параметры, цены и занятые даты. Данные кэшируются и сбрасываются при изменении объявлений.
+type ListingContextBuilder struct {
+	repo  *postgres.ApartmentRepository
+	cache map[uint]listingCacheEntry
+	mu    sync.Mutex
+}
+
+func NewListingContextBuilder(repo *postgres.ApartmentRepository) *ListingContextBuilder {
+	return &ListingContextBuilder{
+		repo:  repo,
+		cache: make(map[uint]listingCacheEntry),
+	}
+}
+
+// Invalidate сбрасывает кэш владельца, подходит для ApartmentService.OnChange
+func (b *ListingContextBuilder) Invalidate(userID uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.cache, userID)
+}
+
+// Apartments возвращает активные объекты владельца с занятостью на ближайшие listingHorizon
+func (b *ListingContextBuilder) Apartments(userID uint) ([]model.Apartment, error) {
+	b.mu.Lock()
+	entry, ok := b.cache[userID]
+	b.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < listingCacheTTL {
+		return entry.apartments, nil
+	}
+
+	now := time.Now()
+	apartments, err := b.repo.GetActiveByUserID(userID, now, now.Add(listingHorizon))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load apartments: %v", err)
+	}
+
+	b.mu.Lock()
+	b.cache[userID] = listingCacheEntry{apartments: apartments, loadedAt: now}
+	b.mu.Unlock()
+
+	return apartments, nil
+}
+
+// Build формирует блок системной инструкции с объектами, наиболее подходящими под query
+func (b *ListingContextBuilder) Build(userID uint, query string) (string, error) {
+	apartments, err := b.Apartments(userID)
+	if err != nil {
+		return "", err
+	}
+
+	return listingPrompt(apartments, query), nil
+}
+
+// listingPrompt описывает объекты для системной инструкции
+func listingPrompt(apartments []model.Apartment, query string) string {
+	if len(apartments) == 0 {
+		return "У владельца сейчас нет активных объявлений. Не предлагайте гостю конкретные квартиры и цены."
+	}
+
+	selected := selectRelevantApartments(apartments, query, maxListingsInPrompt)
+
+	var sb strings.Builder
+	sb.WriteString("Объекты владельца. Называйте только эти цены и даты, ничего не придумывайте. ")
+	sb.WriteString("Если период не указан как занятый, квартира свободна.\n")
+	for _, apt := range selected {
+		sb.WriteString(describeApartment(apt))
+		sb.WriteString("\n")
+	}
+	if rest := len(apartments) - len(selected); rest > 0 {
+		fmt.Fprintf(&sb, "Есть ещё объектов: %d. Если ни один из перечисленных не подходит, уточните пожелания гостя.\n", rest)
+	}
+
+	return sb.String()
+}
+
+func describeApartment(apt model.Apartment) string {
+	parts := []string{fmt.Sprintf("- Квартира #%d", apt.ID)}
+	if apt.Complex != "" {
+		parts = append(parts, "ЖК "+apt.Complex)
+	}
+	parts = append(parts, fmt.Sprintf("%d-комн.", apt.Rooms))
+	if apt.Area > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f м²", apt.Area))
+	}
+	if apt.Floor > 0 {
+		parts = append(parts, fmt.Sprintf("%d этаж", apt.Floor))
+	}
+	if apt.Address != "" {
+		parts = append(parts, "адрес: "+apt.Address)
+	}
+	parts = append(parts, fmt.Sprintf("цена: %d ₸ за сутки", apt.Price))
+
+	if amenities := amenityList(apt.Amenities); len(amenities) > 0 {
+		parts = append(parts, "удобства: "+strings.Join(amenities, ", "))
+	}
+	if apt.Rules != "" {
+		parts = append(parts, "правила: "+truncateRunes(apt.Rules, maxDescriptionRunes))
+	}
+	if apt.Description != "" {
+		parts = append(parts, "описание: "+truncateRunes(apt.Description, maxDescriptionRunes))
+	}
+
+	var busy []string
+	for _, av := range apt.Availabilities {
+		if av.Status == model.StatusAvailable {
+			continue
+		}
+		busy = append(busy, av.DateStart.Format("02.01.2006")+"–"+av.DateEnd.Format("02.01.2006"))
+	}
+	if len(busy) > 0 {
+		parts = append(parts, "занято: "+strings.Join(busy, ", "))
+	} else {
+		parts = append(parts, "занятых дат нет")
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// selectRelevantApartments оставляет не больше limit объектов, в первую очередь
+// совпадающих с запросом гостя по числу комнат, ЖК, адресу и удобствам
+func selectRelevantApartments(apartments []model.Apartment, query string, limit int) []model.Apartment {
+	if len(apartments) <= limit {
+		return apartments
+	}
+
+	query = strings.ToLower(query)
+	rooms := requestedRooms(query)
+
+	type scored struct {
+		apt   model.Apartment
+		score int
+	}
+	candidates := make([]scored, len(apartments))
+	for i, apt := range apartments {
+		score := 0
+		if rooms > 0 && apt.Rooms == rooms {
+			score += 3
+		}
+		if apt.Complex != "" && strings.Contains(query, strings.ToLower(apt.Complex)) {
+			score += 3
+		}
+		for _, word := range strings.Fields(strings.ToLower(apt.Address)) {
+			word = strings.Trim(word, ".,;:")
+			if len([]rune(word)) > 3 && strings.Contains(query, word) {
+				score++
+			}
+		}
+		for amenity, ok := range apt.Amenities {
+			if ok && strings.Contains(query, strings.ToLower(amenity)) {
+				score++
+			}
+		}
+		candidates[i] = scored{apt: apt, score: score}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	result := make([]model.Apartment, 0, limit)
+	for _, c := range candidates[:limit] {
+		result = append(result, c.apt)
+	}
+	return result
+}
+
+func requestedRooms(query string) int {
+	if m := roomsPattern.FindStringSubmatch(query); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			return n
+		}
+	}
+	for word, n := range roomsWords {
+		if strings.Contains(query, word) {
+			return n
+		}
+	}
+	return 0
+}
+
+func amenityList(amenities map[string]bool) []string {
+	var list []string
+	for name, ok := range amenities {
+		if ok {
+			list = append(list, name)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/llm"
+)
+
+const (
+	// listingTextPerHour - сколько генераций текста в час доступно владельцу
+	listingTextPerHour = 20
+	listingTextTokens  = 800
+
+	descriptionPrompt = "Вы пишете объявления о посуточной аренде квартир. По фактам о квартире напишите " +
+		"привлекательное описание на %s языке: 3-5 предложений, без преувеличений. Используйте только " +
+		"перечисленные факты, не придумывайте удобств, расстояний и цен. Верните только текст описания."
+
+	translatePrompt = "Переведите описание и правила квартиры для посуточной аренды на %s язык. " +
+		"Сохраняйте смысл, числа и названия, не добавляйте ничего от себя. Верните JSON вида " +
+		"{\"description\": \"...\", \"rules\": \"...\"} без пояснений."
+)
+
+var (
+	// ErrListingTextLimit - владелец исчерпал часовой лимит генераций текста
+	ErrListingTextLimit = errors.New("слишком много запросов к ИИ, попробуйте позже")
+	// ErrNoListingText - на исходном языке ещё нет текста, переводить нечего
+	ErrNoListingText = errors.New("сначала напишите описание или правила на исходном языке")
+)
+
+// ListingTextService пишет описания квартир и переводит их с помощью ИИ.
+// Результат сохраняется черновиком, владелец правит и публикует его сам.
+type ListingTextService struct {
+	llm          llm.LLM
+	apartments   *postgres.ApartmentRepository
+	translations *postgres.ApartmentTranslationRepository
+	limiter      *hourlyLimiter
+}
+
+func NewListingTextService(llmClient llm.LLM, apartments *postgres.ApartmentRepository, translations *postgres.ApartmentTranslationRepository) *ListingTextService {
+	return &ListingTextService{
+		llm:          llmClient,
+		apartments:   apartments,
+		translations: translations,
+		limiter:      newHourlyLimiter(listingTextPerHour),
+	}
+}
+
+// DraftDescription пишет описание по фактам о квартире и подписям к фото
+// Лимит и расход ИИ считаются на владельца организации, даже если текст пишет менеджер.
+func (s *ListingTextService) DraftDescription(ctx context.Context, userID uint, apartmentID string, input model.DraftDescriptionInput) (*model.ApartmentTranslation, error) {
+	apt, owner, err := s.editable(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	language := input.Language
+	if language == "" {
+		language = model.DefaultAILanguage
+	}
+
+	if !s.limiter.allow(owner, time.Now()) {
+		return nil, ErrListingTextLimit
+	}
+
+	resp, err := s.complete(ctx, owner, fmt.Sprintf(descriptionPrompt, languageNames[language]), listingFacts(*apt, input.Captions))
+	if err != nil {
+		return nil, err
+	}
+
+	translation := &model.ApartmentTranslation{
+		ApartmentID: apt.ID,
+		Language:    language,
+		Description: strings.TrimSpace(resp.Content),
+		Rules:       apt.Rules,
+		Generated:   true,
+	}
+	if existing, err := s.translations.Get(apt.ID, language); err != nil {
+		return nil, err
+	} else if existing != nil {
+		translation.Rules = existing.Rules
+	}
+
+	if err := s.translations.Upsert(translation); err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+// Translate переводит черновик или опубликованный текст на исходном языке на остальные языки.
+// Переводить нечего, пока на исходном языке нет описания или правил.
+func (s *ListingTextService) Translate(ctx context.Context, userID uint, apartmentID string, input model.TranslateListingInput) ([]model.ApartmentTranslation, error) {
+	apt, owner, err := s.editable(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceLanguage := input.Source
+	if sourceLanguage == "" {
+		sourceLanguage = model.DefaultAILanguage
+	}
+	original, err := s.translations.Get(apt.ID, sourceLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || (strings.TrimSpace(original.Description) == "" && strings.TrimSpace(original.Rules) == "") {
+		return nil, ErrNoListingText
+	}
+
+	languages := input.Languages
+	if len(languages) == 0 {
+		languages = model.ListingLanguages
+	}
+
+	source, _ := json.Marshal(map[string]string{"description": original.Description, "rules": original.Rules})
+
+	var result []model.ApartmentTranslation
+	for _, language := range languages {
+		if language == sourceLanguage {
+			continue
+		}
+		if !s.limiter.allow(owner, time.Now()) {
+			if len(result) > 0 {
+				break
+			}
+			return nil, ErrListingTextLimit
+		}
+
+		resp, err := s.complete(ctx, owner, fmt.Sprintf(translatePrompt, languageNames[language]), string(source))
+		if err != nil {
+			return nil, err
+		}
+
+		var text struct {
+			Description string `json:"description"`
+			Rules       string `json:"rules"`
+		}
+		if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &text); err != nil {
+			return nil, fmt.Errorf("failed to parse translation: %v", err)
+		}
+
+		translation := model.ApartmentTranslation{
+			ApartmentID: apt.ID,
+			Language:    language,
+			Description: strings.TrimSpace(text.Description),
+			Rules:       strings.TrimSpace(text.Rules),
+			Generated:   true,
+		}
+		if err := s.translations.Upsert(&translation); err != nil {
+			return nil, err
+		}
+		result = append(result, translation)
+	}
+
+	return result, nil
+}
+
+func (s *ListingTextService) GetTranslations(userID uint, apartmentID string) ([]model.ApartmentTranslation, error) {
+	apt, err := s.apartments.GetByID(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	translations, err := s.translations.GetByApartmentID(apt.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get translations: %v", err)
+	}
+	return translations, nil
+}
+
+// UpdateTranslation сохраняет правку владельца, перевод снова становится черновиком
+func (s *ListingTextService) UpdateTranslation(userID uint, apartmentID, language string, input model.UpdateTranslationInput) (*model.ApartmentTranslation, error) {
+	if !isListingLanguage(language) {
+		return nil, errors.New("неподдерживаемый язык")
+	}
+
+	apt, _, err := s.editable(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	translation := &model.ApartmentTranslation{
+		ApartmentID: apt.ID,
+		Language:    language,
+		Description: strings.TrimSpace(input.Description),
+		Rules:       strings.TrimSpace(input.Rules),
+	}
+	if err := s.translations.Upsert(translation); err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+// Publish публикует перевод: он отдаётся вместе с квартирой в её описании
+func (s *ListingTextService) Publish(userID uint, apartmentID, language string) (*model.ApartmentTranslation, error) {
+	apt, _, err := s.editable(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.translations.SetStatus(apt.ID, language, model.TranslationPublished); err != nil {
+		return nil, err
+	}
+	return s.translations.Get(apt.ID, language)
+}
+
+// editable проверяет право редактировать квартиру и возвращает её вместе с владельцем организации
+func (s *ListingTextService) editable(userID uint, apartmentID string) (*model.Apartment, uint, error) {
+	access, err := authorizeApartment(s.apartments, userID, apartmentID, model.PermApartmentEdit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	apt, err := s.apartments.GetByID(userID, apartmentID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return apt, access.OwnerID, nil
+}
+
+func (s *ListingTextService) complete(ctx context.Context, userID uint, systemPrompt, content string) (*llm.Response, error) {
+	resp, err := s.llm.Complete(withUsage(ctx, userID, 0, model.UsageListing), llm.Request{
+		Messages:    llm.Conversation(systemPrompt, llm.Message{Role: llm.RoleUser, Content: content}),
+		Temperature: llm.Temperature(0.7),
+		MaxTokens:   listingTextTokens,
+	})
+	if errors.Is(err, ErrQuotaExceeded) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка ИИ: %v", err)
+	}
+	if strings.TrimSpace(resp.Content) == "" {
+		return nil, llm.ErrEmptyResponse
+	}
+	return resp, nil
+}
+
+// listingFacts перечисляет факты о квартире, из которых ИИ пишет описание
+func listingFacts(apt model.Apartment, captions []string) string {
+	var facts []string
+	if apt.Complex != "" {
+		facts = append(facts, "ЖК: "+apt.Complex)
+	}
+	facts = append(facts, fmt.Sprintf("Комнат: %d", apt.Rooms))
+	if apt.Area > 0 {
+		facts = append(facts, fmt.Sprintf("Площадь: %.0f м²", apt.Area))
+	}
+	if apt.Floor > 0 {
+		facts = append(facts, fmt.Sprintf("Этаж: %d", apt.Floor))
+	}
+	if amenities := amenityList(apt.Amenities); len(amenities) > 0 {
+		facts = append(facts, "Удобства: "+strings.Join(amenities, ", "))
+	}
+	if apt.Rules != "" {
+		facts = append(facts, "Правила: "+apt.Rules)
+	}
+	if apt.Description != "" {
+		facts = append(facts, "Описание владельца: "+apt.Description)
+	}
+	for _, caption := range captions {
+		if caption = strings.TrimSpace(caption); caption != "" {
+			facts = append(facts, "На фото: "+caption)
+		}
+	}
+	return strings.Join(facts, "\n")
+}
+
+// extractJSON вырезает JSON-объект из ответа модели, если она обернула его в текст
+func extractJSON(text string) string {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return text
+	}
+	return text[start : end+1]
+}
+
+func isListingLanguage(language string) bool {
+	for _, l := range model.ListingLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// hourlyLimiter - скользящее окно в час на владельца, состояние хранится в памяти
+type hourlyLimiter struct {
+	limit int
+	calls map[uint][]time.Time
+	mu    sync.Mutex
+}
+
+func newHourlyLimiter(limit int) *hourlyLimiter {
+	return &hourlyLimiter{limit: limit, calls: make(map[uint][]time.Time)}
+}
+
+func (l *hourlyLimiter) allow(userID uint, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window := l.calls[userID]
+	for len(window) > 0 && now.Sub(window[0]) >= time.Hour {
+		window = window[1:]
+	}
+	if len(window) >= l.limit {
+		l.calls[userID] = window
+		return false
+	}
+
+	l.calls[userID] = append(window, now)
+	return true
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/uilet/pkg/ratelimit"
+)
+
+const (
+	// loginFailureWindow - за какой период считаются попытки
+	loginFailureWindow = 15 * time.Minute
+	// loginAttemptsPerIP - попыток с одного адреса по всем аккаунтам. Считаются все попытки,
+	// а не только ошибки: их учитывают до проверки пароля, чтобы параллельные запросы
+	// не проходили ограничение вместе
+	loginAttemptsPerIP = 30
+	// loginDelayAfter - после стольких ошибок подряд каждая следующая попытка ждёт всё дольше
+	loginDelayAfter = 3
+	loginMaxDelay   = 30 * time.Second
+	// loginLockAfter - после стольких ошибок аккаунт блокируется на loginLockout
+	loginLockAfter = 10
+	loginLockout   = 15 * time.Minute
+)
+
+// LoginThrottledError - попытка входа отклонена до проверки пароля
+type LoginThrottledError struct {
+	Wait   time.Duration
+	Locked bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("слишком много неудачных попыток входа, вход временно заблокирован. Попробуйте через %d мин", int(e.Wait.Minutes()+0.99))
+	}
+	return fmt.Sprintf("слишком много неудачных попыток, подождите %d сек", int(e.Wait.Seconds()+0.99))
+}
+
+// LoginGuard защищает вход от перебора: считает попытки по адресу и ошибки
+// по аккаунту, после нескольких ошибок вводит растущую задержку, затем временную блокировку.
+// Аккаунт задаётся строкой (email, номер, id для второго фактора), так что ответ
+// не зависит от того, существует ли такой владелец.
+type LoginGuard struct {
+	store ratelimit.Store
+}
+
+func NewLoginGuard(store ratelimit.Store) *LoginGuard {
+	return &LoginGuard{store: store}
+}
+
+// Check вызывается до проверки пароля или кода. Попытка сразу учитывается как ошибка,
+// а Succeed её прощает: иначе параллельные попытки прошли бы проверку до того,
+// как хоть одна ошибка записана. Попытка во время задержки тоже считается ошибкой.
+func (g *LoginGuard) Check(ctx context.Context, account, ip string) error {
+	now := time.Now()
+
+	lock, err := g.store.Peek(ctx, "login-lock:"+account, now, loginLockout)
+	if err != nil {
+		return g.failOpen(err)
+	}
+	if lock.Count > 0 {
+		return &LoginThrottledError{Wait: lock.Newest.Add(loginLockout).Sub(now), Locked: true}
+	}
+
+	byIP, allowed, err := g.store.HitUnder(ctx, "login-ip:"+ip, now, loginFailureWindow, loginAttemptsPerIP)
+	if err != nil {
+		return g.failOpen(err)
+	}
+	if !allowed {
+		return &LoginThrottledError{Wait: byIP.Oldest.Add(loginFailureWindow).Sub(now), Locked: true}
+	}
+
+	failures, allowed, err := g.store.HitUnder(ctx, "login-fail:"+account, now, loginFailureWindow, loginLockAfter)
+	if err != nil {
+		return g.failOpen(err)
+	}
+	if !allowed {
+		g.lock(ctx, account, now)
+		return &LoginThrottledError{Wait: loginLockout, Locked: true}
+	}
+	if failures.Count >= loginDelayAfter {
+		delay := loginDelay(failures.Count)
+		if wait := failures.Newest.Add(delay).Sub(now); wait > 0 {
+			return &LoginThrottledError{Wait: wait}
+		}
+	}
+
+	return nil
+}
+
+// Fail блокирует аккаунт, если ошибок стало слишком много. Сама ошибка уже учтена в Check.
+func (g *LoginGuard) Fail(ctx context.Context, account string) {
+	now := time.Now()
+
+	failures, err := g.store.Peek(ctx, "login-fail:"+account, now, loginFailureWindow)
+	if err != nil {
+		log.Printf("Login guard error: %v", err)
+		return
+	}
+	if failures.Count >= loginLockAfter {
+		g.lock(ctx, account, now)
+	}
+}
+
+// lock блокирует аккаунт на loginLockout, после блокировки счёт ошибок начинается заново
+func (g *LoginGuard) lock(ctx context.Context, account string, now time.Time) {
+	if _, err := g.store.Hit(ctx, "login-lock:"+account, now, loginLockout); err != nil {
+		log.Printf("Login guard error: %v", err)
+	}
+	if err := g.store.Reset(ctx, "login-fail:"+account); err != nil {
+		log.Printf("Login guard error: %v", err)
+	}
+}
+
+// Succeed сбрасывает ошибки аккаунта после успешного входа
+func (g *LoginGuard) Succeed(ctx context.Context, account string) {
+	if err := g.store.Reset(ctx, "login-fail:"+account); err != nil {
+		log.Printf("Login guard error: %v", err)
+	}
+}
+
+// failOpen пропускает вход, если хранилище счётчиков недоступно
+func (g *LoginGuard) failOpen(err error) error {
+	log.Printf("Login guard error: %v", err)
+	return nil
+}
+
+// loginDelay растёт вдвое с каждой ошибкой после loginDelayAfter: 1, 2, 4... секунды
+func loginDelay(failures int) time.Duration {
+	delay := time.Second << uint(failures-loginDelayAfter)
+	if delay > loginMaxDelay || delay <= 0 {
+		return loginMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yourusername/uilet/pkg/ratelimit"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{loginDelayAfter, time.Second},
+		{loginDelayAfter + 1, 2 * time.Second},
+		{loginDelayAfter + 3, 8 * time.Second},
+		{loginDelayAfter + 10, loginMaxDelay},
+		{loginDelayAfter + 100, loginMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuardDelaysAfterFailures(t *testing.T) {
+	g := NewLoginGuard(ratelimit.NewMemory())
+	ctx := context.Background()
+
+	// Check сразу учитывает попытку как ошибку, поэтому без Succeed каждая проверка - ошибка
+	for i := 0; i < loginDelayAfter; i++ {
+		if err := g.Check(ctx, "owner@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+		g.Fail(ctx, "owner@example.com")
+	}
+
+	var throttled *LoginThrottledError
+	err := g.Check(ctx, "owner@example.com", "10.0.0.1")
+	if !errors.As(err, &throttled) || throttled.Locked || throttled.Wait <= 0 {
+		t.Fatalf("err = %v, want a delay", err)
+	}
+
+	if err := g.Check(ctx, "other@example.com", "10.0.0.1"); err != nil {
+		t.Errorf("another account delayed: %v", err)
+	}
+}
+
+func TestLoginGuardSucceedResetsFailures(t *testing.T) {
+	g := NewLoginGuard(ratelimit.NewMemory())
+	ctx := context.Background()
+
+	for i := 0; i < loginDelayAfter*2; i++ {
+		if err := g.Check(ctx, "owner@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+		g.Succeed(ctx, "owner@example.com")
+	}
+}
+
+func TestLoginGuardLocksAccount(t *testing.T) {
+	store := ratelimit.NewMemory()
+	g := NewLoginGuard(store)
+	ctx := context.Background()
+
+	// Ошибки записаны заранее, чтобы не ждать растущих задержек
+	old := time.Now().Add(-time.Minute)
+	for i := 0; i < loginLockAfter; i++ {
+		store.Hit(ctx, "login-fail:owner@example.com", old, loginFailureWindow)
+	}
+
+	var throttled *LoginThrottledError
+	if err := g.Check(ctx, "owner@example.com", "10.0.0.1"); !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("err = %v, want the account locked", err)
+	}
+	if err := g.Check(ctx, "owner@example.com", "10.0.0.2"); !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("err = %v, want the lock to hold from another address", err)
+	}
+}
+
+func TestLoginGuardLimitsAttemptsPerIP(t *testing.T) {
+	g := NewLoginGuard(ratelimit.NewMemory())
+	ctx := context.Background()
+
+	// Разные аккаунты с одного адреса: задержка по аккаунту не срабатывает
+	for i := 0; i < loginAttemptsPerIP; i++ {
+		if err := g.Check(ctx, fmt.Sprintf("owner%d@example.com", i), "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+
+	var throttled *LoginThrottledError
+	if err := g.Check(ctx, "new@example.com", "10.0.0.1"); !errors.As(err, &throttled) {
+		t.Fatalf("err = %v, want the address throttled", err)
+	}
+	if err := g.Check(ctx, "new@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("another address throttled: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/internal/whatsapp"
+	"github.com/yourusername/uilet/pkg/stt"
+)
+
+const transcribeTimeout = 60 * time.Second
+
+var coordinatesPattern = regexp.MustCompile(`(-?\d{1,2}\.\d+)\s*[,;]\s*(-?\d{1,3}\.\d+)`)
+
+// MediaService сохраняет вложения из WhatsApp и расшифровывает голосовые сообщения
+type MediaService struct {
+	repo          *postgres.MessageMediaRepository
+	conversations *ConversationService
+	aiRepo        *postgres.AIConfigRepository
+	// transcriber - nil, если распознавание речи не настроено
+	transcriber stt.Transcriber
+}
+
+func NewMediaService(repo *postgres.MessageMediaRepository, conversations *ConversationService, aiRepo *postgres.AIConfigRepository, transcriber stt.Transcriber) *MediaService {
+	return &MediaService{
+		repo:          repo,
+		conversations: conversations,
+		aiRepo:        aiRepo,
+		transcriber:   transcriber,
+	}
+}
+
+// Save сохраняет вложение гостя в переписку и возвращает текст для ИИ: подпись к фото,
+// расшифровку голосового или координаты точки. words - то, что гость написал или сказал
+// сам, без пометки о вложении: подпись или расшифровка, для геолокации пусто.
+func (s *MediaService) Save(ctx context.Context, conv *model.Conversation, in whatsapp.Media) (content, words string, err error) {
+	media := &model.MessageMedia{
+		ConversationID: conv.ID,
+		Kind:           model.MessageKind(in.Kind),
+		MimeType:       in.MimeType,
+		Data:           in.Data,
+		Caption:        in.Caption,
+	}
+
+	content = in.Describe()
+	switch in.Kind {
+	case whatsapp.KindImage:
+		words = in.Caption
+	case whatsapp.KindLocation:
+		media.Latitude = &in.Latitude
+		media.Longitude = &in.Longitude
+		media.Caption = in.Describe()
+	case whatsapp.KindAudio:
+		if transcript := s.transcribe(ctx, conv.UserID, in); transcript != "" {
+			media.Transcript = transcript
+			words = transcript
+			content = "[Голосовое сообщение] " + transcript
+		} else {
+			content = "[Голосовое сообщение, не удалось распознать]"
+		}
+	}
+
+	if err := s.repo.Create(media); err != nil {
+		return "", "", err
+	}
+
+	if err := s.conversations.AddMediaMessage(conv, model.RoleGuest, media.Kind, content, &media.ID); err != nil {
+		return "", "", err
+	}
+
+	return content, words, nil
+}
+
+func (s *MediaService) Get(userID uint, conversationID string, mediaID string) (*model.MessageMedia, error) {
+	return s.repo.GetByID(userID, conversationID, mediaID)
+}
+
+// transcribe возвращает расшифровку или пустую строку, если распознать не удалось
+func (s *MediaService) transcribe(ctx context.Context, userID uint, in whatsapp.Media) string {
+	if s.transcriber == nil || len(in.Data) == 0 {
+		return ""
+	}
+
+	audio := stt.Audio{Data: in.Data, MimeType: in.MimeType}
+	if config, err := s.aiRepo.GetByUserID(userID); err == nil && config != nil {
+		audio.Language = config.Language
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, transcribeTimeout)
+	defer cancel()
+
+	text, err := s.transcriber.Transcribe(ctx, audio)
+	if err != nil {
+		log.Printf("Error transcribing voice message for user %d: %v", userID, err)
+		return ""
+	}
+	return text
+}
+
+// parseCoordinates достаёт широту и долготу из поля location объявления,
+// например "43.238949, 76.889709" или ссылки на карту с координатами
+func parseCoordinates(location string) (float64, float64, bool) {
+	m := coordinatesPattern.FindStringSubmatch(location)
+	if m == nil {
+		return 0, 0, false
+	}
+
+	lat, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(m[2], 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+func mapsLink(query string) string {
+	return fmt.Sprintf("https://www.google.com/maps/search/?api=1&query=%s", url.QueryEscape(query))
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/internal/whatsapp"
+	"github.com/yourusername/uilet/pkg/stt"
+)
+
+// newTestMediaService - сервис с недоступной базой: настройки ИИ не читаются,
+// и язык распознавания остаётся пустым
+func newTestMediaService(t *testing.T, transcriber stt.Transcriber) *MediaService {
+	db, err := sql.Open("postgres", "host=/nonexistent sslmode=disable")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewMediaService(nil, nil, postgres.NewAIConfigRepository(db), transcriber)
+}
+
+func TestTranscribe(t *testing.T) {
+	voice := whatsapp.Media{Kind: whatsapp.KindAudio, MimeType: "audio/ogg", Data: []byte("ogg"), Voice: true}
+
+	fake := stt.NewFake("Стоп")
+	s := newTestMediaService(t, fake)
+
+	if got := s.transcribe(context.Background(), 1, voice); got != "Стоп" {
+		t.Errorf("first transcript = %q, want %q", got, "Стоп")
+	}
+	if got := s.transcribe(context.Background(), 1, voice); got != fake.Default {
+		t.Errorf("second transcript = %q, want default %q", got, fake.Default)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("transcriber called %d times, want 2", len(requests))
+	}
+	if requests[0].MimeType != "audio/ogg" || string(requests[0].Data) != "ogg" {
+		t.Errorf("audio = %+v", requests[0])
+	}
+}
+
+func TestTranscribeFailures(t *testing.T) {
+	voice := whatsapp.Media{Kind: whatsapp.KindAudio, MimeType: "audio/ogg", Data: []byte("ogg")}
+
+	failing := stt.NewFake()
+	failing.Err = errors.New("service unavailable")
+
+	empty := stt.NewFake()
+	empty.Default = ""
+
+	tests := []struct {
+		name        string
+		transcriber *stt.Fake
+		media       whatsapp.Media
+		calls       int
+	}{
+		{"transcriber error", failing, voice, 1},
+		{"nothing recognized", empty, voice, 1},
+		{"file not downloaded", stt.NewFake(), whatsapp.Media{Kind: whatsapp.KindAudio}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestMediaService(t, tt.transcriber)
+			if got := s.transcribe(context.Background(), 1, tt.media); got != "" {
+				t.Errorf("transcript = %q, want empty", got)
+			}
+			if n := len(tt.transcriber.Requests()); n != tt.calls {
+				t.Errorf("transcriber called %d times, want %d", n, tt.calls)
+			}
+		})
+	}
+}
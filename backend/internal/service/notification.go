@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+type NotificationService struct {
+	repo *postgres.NotificationRepository
+}
+
+func NewNotificationService(repo *postgres.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// Notify сохраняет уведомление владельцу. Ошибка только логируется:
+// сбой уведомления не должен ломать основной сценарий.
+func (s *NotificationService) Notify(userID uint, kind, title, body string, data map[string]string) {
+	n := &model.Notification{
+		UserID: userID,
+		Type:   kind,
+		Title:  title,
+		Body:   body,
+		Data:   data,
+	}
+	if n.Data == nil {
+		n.Data = map[string]string{}
+	}
+	if err := s.repo.Create(n); err != nil {
+		log.Printf("Error creating notification for user %d: %v", userID, err)
+	}
+}
+
+func (s *NotificationService) GetByUserID(userID uint, unreadOnly bool) ([]model.Notification, error) {
+	notifications, err := s.repo.GetByUserID(userID, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %v", err)
+	}
+	return notifications, nil
+}
+
+func (s *NotificationService) MarkRead(userID uint, notificationID string) error {
+	return s.repo.MarkRead(userID, notificationID)
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/otp"
+)
+
+const (
+	invitationTTL = 7 * 24 * time.Hour
+	// invitationsPerHour - сколько приглашений участник может разослать за час
+	invitationsPerHour = 20
+	smsSendTimeout     = 30 * time.Second
+	// defaultOrganizationName - название личной организации, созданной автоматически
+	defaultOrganizationName = "Мои квартиры"
+)
+
+var (
+	ErrForbidden         = errors.New("недостаточно прав для этого действия")
+	ErrInvalidInvitation = errors.New("приглашение недействительно или устарело")
+	ErrInvitationContact = errors.New("укажите email или телефон приглашённого")
+	ErrInvitationLimit   = errors.New("слишком много приглашений, попробуйте позже")
+	ErrCannotRemoveOwner = errors.New("владельца нельзя исключить из организации или сменить ему роль")
+)
+
+var invitationSMSTemplates = map[string]string{
+	"ru": "%s приглашает вас в Uilet: %s",
+	"kk": "%s сізді Uilet-ке шақырады: %s",
+}
+
+// OrganizationService управляет организациями, участниками и приглашениями.
+// Квартиры принадлежат организации, а что участник может с ними делать, определяет его роль.
+type OrganizationService struct {
+	repo          *postgres.OrganizationRepository
+	accounts      *AccountService
+	sms           otp.Sender
+	notifications *NotificationService
+	appURL        string
+	limiter       *hourlyLimiter
+}
+
+// NewOrganizationService: sms может быть nil, тогда приглашение по телефону
+// владелец пересылает сам по ссылке из ответа
+func NewOrganizationService(repo *postgres.OrganizationRepository, accounts *AccountService, sms otp.Sender, notifications *NotificationService, appURL string) *OrganizationService {
+	return &OrganizationService{
+		repo:          repo,
+		accounts:      accounts,
+		sms:           sms,
+		notifications: notifications,
+		appURL:        strings.TrimRight(appURL, "/"),
+		limiter:       newHourlyLimiter(invitationsPerHour),
+	}
+}
+
+func (s *OrganizationService) GetForUser(userID uint) ([]model.Organization, error) {
+	orgs, err := s.repo.GetForUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organizations: %v", err)
+	}
+	return orgs, nil
+}
+
+func (s *OrganizationService) Create(userID uint, input model.CreateOrganizationInput) (*model.Organization, error) {
+	org := &model.Organization{Name: strings.TrimSpace(input.Name), OwnerID: userID}
+	if org.Name == "" {
+		return nil, errors.New("укажите название организации")
+	}
+	if err := s.repo.Create(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// Personal возвращает личную организацию владельца и создаёт её при первой квартире
+func (s *OrganizationService) Personal(userID uint) (*model.Organization, error) {
+	org, err := s.repo.GetPersonal(userID)
+	if err != nil || org != nil {
+		return org, err
+	}
+
+	org = &model.Organization{Name: defaultOrganizationName, OwnerID: userID}
+	if err := s.repo.Create(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// ForNewApartment выбирает организацию для новой квартиры и проверяет право её добавить
+func (s *OrganizationService) ForNewApartment(userID, organizationID uint) (*model.Organization, error) {
+	if organizationID == 0 {
+		return s.Personal(userID)
+	}
+	return s.authorize(userID, organizationID, model.PermApartmentCreate)
+}
+
+func (s *OrganizationService) Update(userID, organizationID uint, input model.UpdateOrganizationInput) (*model.Organization, error) {
+	org, err := s.authorize(userID, organizationID, model.PermOrgSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	org.Name = strings.TrimSpace(input.Name)
+	if org.Name == "" {
+		return nil, errors.New("укажите название организации")
+	}
+	org.RequireTwoFactor = input.RequireTwoFactor
+	if err := s.repo.Update(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *OrganizationService) GetMembers(userID, organizationID uint) ([]model.OrganizationMember, error) {
+	if _, err := s.repo.GetMembership(userID, organizationID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(organizationID)
+}
+
+func (s *OrganizationService) UpdateMember(userID, organizationID, memberID uint, input model.UpdateMemberInput) error {
+	org, err := s.authorize(userID, organizationID, model.PermMembersManage)
+	if err != nil {
+		return err
+	}
+	if memberID == org.OwnerID {
+		return ErrCannotRemoveOwner
+	}
+	return s.repo.SetRole(organizationID, memberID, input.Role)
+}
+
+// RemoveMember исключает участника. Выйти из организации может любой участник, кроме владельца.
+func (s *OrganizationService) RemoveMember(userID, organizationID, memberID uint) error {
+	org, err := s.repo.GetMembership(userID, organizationID)
+	if err != nil {
+		return err
+	}
+	if memberID == org.OwnerID {
+		return ErrCannotRemoveOwner
+	}
+	if memberID != userID && !model.RoleCan(org.Role, model.PermMembersManage) {
+		return ErrForbidden
+	}
+	return s.repo.RemoveMember(organizationID, memberID)
+}
+
+// Invite создаёт приглашение и отправляет ссылку на email или в SMS.
+// Ссылка возвращается и в ответе: её можно переслать самому, например в WhatsApp.
+func (s *OrganizationService) Invite(userID, organizationID uint, input model.InviteMemberInput) (*model.OrganizationInvitation, error) {
+	org, err := s.authorize(userID, organizationID, model.PermMembersManage)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &model.OrganizationInvitation{
+		OrganizationID: org.ID,
+		Email:          strings.ToLower(strings.TrimSpace(input.Email)),
+		Role:           input.Role,
+		InvitedBy:      userID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+	if input.Phone != "" {
+		if invitation.Phone, err = normalizePhone(input.Phone); err != nil {
+			return nil, err
+		}
+	}
+	if invitation.Email == "" && invitation.Phone == "" {
+		return nil, ErrInvitationContact
+	}
+
+	if !s.limiter.allow(userID, time.Now()) {
+		return nil, ErrInvitationLimit
+	}
+
+	token, tokenHash, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateInvitation(tokenHash, invitation); err != nil {
+		return nil, err
+	}
+	invitation.Link = s.appURL + "/invitations/accept?token=" + url.QueryEscape(token)
+
+	if invitation.Email != "" {
+		if err := s.accounts.SendInvitation(userID, invitation.Email, input.Language, org.Name, invitation.Role, invitation.Link); err != nil {
+			log.Printf("Failed to send invitation to organization %d: %v", org.ID, err)
+		}
+	}
+	if invitation.Phone != "" && s.sms != nil {
+		s.sendSMS(invitation.Phone, input.Language, org.Name, invitation.Link)
+	}
+
+	return invitation, nil
+}
+
+func (s *OrganizationService) GetInvitations(userID, organizationID uint) ([]model.OrganizationInvitation, error) {
+	if _, err := s.authorize(userID, organizationID, model.PermMembersManage); err != nil {
+		return nil, err
+	}
+	return s.repo.GetInvitations(organizationID)
+}
+
+func (s *OrganizationService) RevokeInvitation(userID, organizationID, invitationID uint) error {
+	if _, err := s.authorize(userID, organizationID, model.PermMembersManage); err != nil {
+		return err
+	}
+	return s.repo.DeleteInvitation(organizationID, invitationID)
+}
+
+// Accept добавляет пользователя в организацию по ссылке из приглашения. Принять его может
+// только тот, чей подтверждённый email или телефон совпадает с приглашённым: для остальных
+// приглашение будто не существует.
+func (s *OrganizationService) Accept(userID uint, input model.AcceptInvitationInput) (*model.Organization, error) {
+	invitation, err := s.repo.AcceptInvitation(hashToken(strings.TrimSpace(input.Token)), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	org, err := s.repo.GetMembership(userID, invitation.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.OwnerID != userID {
+		who := invitation.Email
+		if who == "" {
+			who = invitation.Phone
+		}
+		s.notifications.Notify(
+			org.OwnerID,
+			model.NotificationTeam,
+			"Новый участник",
+			fmt.Sprintf("%s принял приглашение в «%s».", who, org.Name),
+			map[string]string{"organization_id": fmt.Sprint(org.ID), "user_id": fmt.Sprint(userID)},
+		)
+	}
+	return org, nil
+}
+
+// RequiresTwoFactor - политика для TwoFactorService.RequireWhen: 2FA обязательна,
+// если её требует хотя бы одна организация пользователя
+func (s *OrganizationService) RequiresTwoFactor(userID uint) (bool, error) {
+	return s.repo.RequiresTwoFactor(userID)
+}
+
+func (s *OrganizationService) authorize(userID, organizationID uint, permission model.Permission) (*model.Organization, error) {
+	org, err := s.repo.GetMembership(userID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if !model.RoleCan(org.Role, permission) {
+		return nil, ErrForbidden
+	}
+	return org, nil
+}
+
+func (s *OrganizationService) sendSMS(phone, language, orgName, link string) {
+	text, ok := invitationSMSTemplates[language]
+	if !ok {
+		text = invitationSMSTemplates[model.DefaultAILanguage]
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), smsSendTimeout)
+		defer cancel()
+		err := s.sms.Send(ctx, otp.Message{Phone: phone, Text: fmt.Sprintf(text, orgName, link), Language: language})
+		if err != nil {
+			log.Printf("Failed to send invitation SMS: %v", err)
+		}
+	}()
+}
+
+// authorizeApartment проверяет, что у пользователя есть право на действие с квартирой.
+// Квартира чужой организации для него не существует, поэтому ошибка - "not found".
+func authorizeApartment(apartments *postgres.ApartmentRepository, userID uint, apartmentID string, permission model.Permission) (*model.ApartmentAccess, error) {
+	access, err := apartments.GetAccess(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.Can(permission) {
+		return nil, ErrForbidden
+	}
+	return access, nil
+}
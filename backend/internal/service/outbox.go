@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/internal/whatsapp"
+)
+
+const (
+	outboxInterval  = time.Second
+	outboxBatchSize = 50
+	// outboxMaxAttempts - после стольких неудачных попыток сообщение помечается ошибкой
+	outboxMaxAttempts = 6
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = 30 * time.Minute
+	// outboxRecipientGap - минимальная пауза между сообщениями одному гостю,
+	// чтобы ответы из нескольких частей приходили по порядку
+	outboxRecipientGap = time.Second
+	outboxStaleTimeout = 5 * time.Minute
+	outboxStaleEvery   = time.Minute
+)
+
+// OutboundSender отправляет сообщение из очереди и возвращает его ID в WhatsApp
+type OutboundSender interface {
+	Deliver(msg model.OutboundMessage) (string, error)
+}
+
+// OutboxService - очередь исходящих сообщений в базе. Все сообщения гостям,
+// и ответы ассистента, и рассылки сценариев, уходят через неё: очередь
+// соблюдает лимиты частоты, повторяет неудачные отправки и отслеживает квитанции.
+type OutboxService struct {
+	repo          *postgres.OutboundRepository
+	notifications *NotificationService
+	limiter       *sendLimiter
+	wake          chan struct{}
+}
+
+func NewOutboxService(repo *postgres.OutboundRepository, notifications *NotificationService, perMinute int) *OutboxService {
+	return &OutboxService{
+		repo:          repo,
+		notifications: notifications,
+		limiter:       newSendLimiter(perMinute, outboxRecipientGap),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Enqueue ставит сообщение в очередь. Без ключа идемпотентности генерируется случайный.
+// Возвращает false, если сообщение с таким ключом уже было поставлено.
+func (s *OutboxService) Enqueue(msg *model.OutboundMessage) (bool, error) {
+	if msg.IdempotencyKey == "" {
+		msg.IdempotencyKey = newIdempotencyKey()
+	}
+	if msg.Kind == "" {
+		msg.Kind = model.KindText
+	}
+
+	created, err := s.repo.Enqueue(msg)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue message: %v", err)
+	}
+
+	if created {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return created, nil
+}
+
+func (s *OutboxService) GetByUserID(userID uint, status model.OutboundStatus) ([]model.OutboundMessage, error) {
+	messages, err := s.repo.GetByUserID(userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbound messages: %v", err)
+	}
+	return messages, nil
+}
+
+// Retry повторно отправляет сообщение, которое не удалось доставить
+func (s *OutboxService) Retry(userID uint, messageID string) error {
+	if err := s.repo.Requeue(userID, messageID); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// HandleReceipt обновляет статус сообщения по квитанции WhatsApp
+func (s *OutboxService) HandleReceipt(userID uint, whatsAppID string, status whatsapp.ReceiptStatus) {
+	if err := s.repo.UpdateReceipt(userID, whatsAppID, model.OutboundStatus(status)); err != nil {
+		log.Printf("Error updating receipt for message %s: %v", whatsAppID, err)
+	}
+}
+
+// Run отправляет сообщения из очереди, пока не отменён ctx
+func (s *OutboxService) Run(ctx context.Context, sender OutboundSender) {
+	ticker := time.NewTicker(outboxInterval)
+	defer ticker.Stop()
+
+	var lastStaleCheck time.Time
+	for {
+		if time.Since(lastStaleCheck) >= outboxStaleEvery {
+			s.failStale()
+			lastStaleCheck = time.Now()
+		}
+		s.tick(sender)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *OutboxService) failStale() {
+	if n, err := s.repo.FailStale(time.Now().Add(-outboxStaleTimeout)); err != nil {
+		log.Printf("Error failing stale outbound messages: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d interrupted outbound messages as failed", n)
+	}
+}
+
+func (s *OutboxService) tick(sender OutboundSender) {
+	due, err := s.repo.ClaimDue(outboxBatchSize)
+	if err != nil {
+		log.Printf("Error claiming outbound messages: %v", err)
+		return
+	}
+
+	// Если сообщение гостю отложено или не ушло и будет повторено, следующие
+	// ему сообщения тоже ждут, иначе они обгонят его
+	deferred := make(map[string]time.Time)
+	for _, msg := range due {
+		recipient := fmt.Sprintf("%d:%s", msg.UserID, msg.GuestPhone)
+		if until, ok := deferred[recipient]; ok {
+			s.postpone(msg, until)
+			continue
+		}
+
+		if until, ok := s.limiter.reserve(msg.UserID, msg.GuestPhone, time.Now()); !ok {
+			deferred[recipient] = until
+			s.postpone(msg, until)
+			continue
+		}
+
+		if retryAt, retried := s.deliver(sender, msg); retried {
+			deferred[recipient] = retryAt
+		}
+	}
+}
+
+// deliver отправляет сообщение. Если отправка не удалась и будет повторена,
+// возвращает время повтора.
+func (s *OutboxService) deliver(sender OutboundSender, msg model.OutboundMessage) (time.Time, bool) {
+	whatsAppID, err := sender.Deliver(msg)
+	if err == nil {
+		if err := s.repo.MarkSent(msg.ID, whatsAppID); err != nil {
+			log.Printf("Error marking outbound message %d sent: %v", msg.ID, err)
+		}
+		return time.Time{}, false
+	}
+
+	if msg.Attempts >= outboxMaxAttempts {
+		if err := s.repo.Fail(msg.ID, err.Error()); err != nil {
+			log.Printf("Error failing outbound message %d: %v", msg.ID, err)
+		}
+		s.notifications.Notify(msg.UserID, model.NotificationMessageFailed,
+			"Сообщение не отправлено",
+			fmt.Sprintf("Не удалось отправить сообщение на номер %s: %v", msg.GuestPhone, err),
+			map[string]string{"outbound_message_id": fmt.Sprint(msg.ID)})
+		return time.Time{}, false
+	}
+
+	retryAt := time.Now().Add(outboxBackoff(msg.Attempts))
+	if err := s.repo.Retry(msg.ID, retryAt, err.Error()); err != nil {
+		log.Printf("Error retrying outbound message %d: %v", msg.ID, err)
+	}
+	return retryAt, true
+}
+
+func (s *OutboxService) postpone(msg model.OutboundMessage, until time.Time) {
+	if err := s.repo.Defer(msg.ID, until); err != nil {
+		log.Printf("Error deferring outbound message %d: %v", msg.ID, err)
+	}
+}
+
+// outboxBackoff - задержка перед повтором: 30с, 1м, 2м... но не больше outboxMaxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseBackoff
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxBackoff {
+		delay = outboxMaxBackoff
+	}
+	return delay
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sendLimiter ограничивает частоту отправки: не больше perMinute сообщений
+// в минуту с номера владельца и не чаще раза в gap одному гостю.
+// Состояние хранится в памяти: после перезапуска лимиты начинаются заново.
+type sendLimiter struct {
+	perMinute int
+	gap       time.Duration
+	sent      map[uint][]time.Time
+	last      map[string]time.Time
+	mu        sync.Mutex
+}
+
+func newSendLimiter(perMinute int, gap time.Duration) *sendLimiter {
+	return &sendLimiter{
+		perMinute: perMinute,
+		gap:       gap,
+		sent:      make(map[uint][]time.Time),
+		last:      make(map[string]time.Time),
+	}
+}
+
+// reserve занимает место под отправку. Если лимит исчерпан,
+// возвращает момент, когда можно попробовать снова.
+func (l *sendLimiter) reserve(userID uint, phone string, now time.Time) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recipient := fmt.Sprintf("%d:%s", userID, phone)
+	if last, ok := l.last[recipient]; ok && now.Sub(last) < l.gap {
+		return last.Add(l.gap), false
+	}
+
+	window := l.sent[userID]
+	for len(window) > 0 && now.Sub(window[0]) >= time.Minute {
+		window = window[1:]
+	}
+	if l.perMinute > 0 && len(window) >= l.perMinute {
+		l.sent[userID] = window
+		return window[0].Add(time.Minute), false
+	}
+
+	l.sent[userID] = append(window, now)
+	l.last[recipient] = now
+	if len(l.last) > 1024 {
+		for key, at := range l.last {
+			if now.Sub(at) >= l.gap {
+				delete(l.last, key)
+			}
+		}
+	}
+	return time.Time{}, true
+}
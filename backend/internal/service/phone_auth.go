@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/otp"
+)
+
+const (
+	phoneCodeTTL      = 5 * time.Minute
+	phoneCodeCooldown = time.Minute
+	// phoneCodesPerHour - сколько кодов можно запросить на один номер за час
+	phoneCodesPerHour = 5
+	// phoneCodeAttempts - сколько раз можно ошибиться, после этого код сгорает
+	phoneCodeAttempts = 5
+	phoneCodeTimeout  = 20 * time.Second
+)
+
+var (
+	ErrInvalidPhoneCode   = errors.New("неверный или устаревший код")
+	ErrPhoneCodeAttempts  = errors.New("слишком много неверных попыток, запросите новый код")
+	ErrPhoneCodeLimit     = errors.New("слишком много кодов на этот номер, попробуйте через час")
+	ErrPhoneChannel       = errors.New("этот способ доставки кода сейчас недоступен")
+	ErrPhoneUndeliverable = errors.New("не удалось доставить код на этот номер, попробуйте другой способ")
+)
+
+// phoneCodeTextTemplates - текст SMS с кодом по языкам
+var phoneCodeTextTemplates = map[string]string{
+	"ru": "Код входа в Uilet: %s. Никому не сообщайте его.",
+	"kk": "Uilet-ке кіру коды: %s. Оны ешкімге айтпаңыз.",
+}
+
+// PhoneCodeCooldownError - новый код запрошен раньше, чем истекла пауза после предыдущего
+type PhoneCodeCooldownError struct {
+	Wait time.Duration
+}
+
+func (e *PhoneCodeCooldownError) Error() string {
+	return fmt.Sprintf("новый код можно запросить через %d сек", int(e.Wait.Seconds()+0.5))
+}
+
+// PhoneAuthService - регистрация и вход по номеру телефона с кодом из WhatsApp или SMS.
+// Первый успешный вход по номеру создаёт владельца без email и пароля.
+type PhoneAuthService struct {
+	users     *postgres.UserRepository
+	codes     *postgres.PhoneCodeRepository
+	senders   map[string]otp.Sender
+	twoFactor *TwoFactorService
+}
+
+// NewPhoneAuthService принимает отправщиков по каналам; канал без отправщика недоступен
+func NewPhoneAuthService(users *postgres.UserRepository, codes *postgres.PhoneCodeRepository, senders map[string]otp.Sender, twoFactor *TwoFactorService) *PhoneAuthService {
+	return &PhoneAuthService{
+		users:     users,
+		codes:     codes,
+		senders:   senders,
+		twoFactor: twoFactor,
+	}
+}
+
+// RequestCode отправляет новый код на номер
+func (s *PhoneAuthService) RequestCode(ctx context.Context, input model.RequestPhoneCodeInput) (*model.PhoneCodeSent, error) {
+	phone, err := normalizePhone(input.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := input.Channel
+	if channel == "" {
+		channel = otp.ChannelWhatsApp
+	}
+	sender, ok := s.senders[channel]
+	if !ok {
+		return nil, ErrPhoneChannel
+	}
+
+	now := time.Now()
+	latest, err := s.codes.GetLatest(phone)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		if wait := latest.CreatedAt.Add(phoneCodeCooldown).Sub(now); wait > 0 {
+			return nil, &PhoneCodeCooldownError{Wait: wait}
+		}
+	}
+
+	count, err := s.codes.CountSince(phone, now.Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if count >= phoneCodesPerHour {
+		return nil, ErrPhoneCodeLimit
+	}
+
+	code, err := newPhoneCode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.codes.Create(&model.PhoneCode{
+		Phone:     phone,
+		Channel:   channel,
+		CodeHash:  hashPhoneCode(phone, code),
+		ExpiresAt: now.Add(phoneCodeTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	language := input.Language
+	if _, ok := phoneCodeTextTemplates[language]; !ok {
+		language = model.DefaultAILanguage
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, phoneCodeTimeout)
+	defer cancel()
+	err = sender.Send(ctx, otp.Message{
+		Phone:    phone,
+		Code:     code,
+		Text:     fmt.Sprintf(phoneCodeTextTemplates[language], code),
+		Language: language,
+	})
+	if errors.Is(err, otp.ErrUndeliverable) {
+		return nil, ErrPhoneUndeliverable
+	}
+	if err != nil {
+		log.Printf("Failed to send phone code via %s: %v", channel, err)
+		return nil, fmt.Errorf("failed to send code: %v", err)
+	}
+
+	return &model.PhoneCodeSent{
+		Phone:     phone,
+		Channel:   channel,
+		ExpiresIn: int(phoneCodeTTL.Seconds()),
+		ResendIn:  int(phoneCodeCooldown.Seconds()),
+	}, nil
+}
+
+// Verify проверяет код и открывает сессию или, при включённой 2FA, промежуточный вход.
+// created = true, если владелец зарегистрирован этим входом.
+func (s *PhoneAuthService) Verify(input model.VerifyPhoneCodeInput, meta model.SessionMeta) (*model.SignInResult, bool, error) {
+	phone, err := normalizePhone(input.Phone)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := consumePhoneCode(s.codes, phone, input.Code); err != nil {
+		return nil, false, err
+	}
+
+	user, created, err := s.findOrCreate(phone)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result, err := s.twoFactor.Begin(user.ID, meta)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, created, nil
+}
+
+// consumePhoneCode проверяет и гасит действующий код номера. Код сгорает
+// после phoneCodeAttempts неверных попыток.
+func consumePhoneCode(codes *postgres.PhoneCodeRepository, phone, input string) error {
+	code, err := codes.GetActive(phone)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidPhoneCode
+		}
+		return err
+	}
+
+	attempts, err := codes.AddAttempt(code.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidPhoneCode
+		}
+		return err
+	}
+	if attempts > phoneCodeAttempts {
+		if err := codes.MarkUsed(code.ID); err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		return ErrPhoneCodeAttempts
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code.CodeHash), []byte(hashPhoneCode(phone, input))) != 1 {
+		return ErrInvalidPhoneCode
+	}
+
+	if err := codes.MarkUsed(code.ID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidPhoneCode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *PhoneAuthService) findOrCreate(phone string) (*model.User, bool, error) {
+	user, err := s.users.GetByPhone(phone)
+	if err == nil {
+		return user, false, nil
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		return nil, false, err
+	}
+
+	user = &model.User{
+		Phone:         phone,
+		PhoneVerified: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := s.users.Create(user); err != nil {
+		// Параллельный вход с тем же номером успел создать владельца
+		if existing, getErr := s.users.GetByPhone(phone); getErr == nil {
+			return existing, false, nil
+		}
+		return nil, false, fmt.Errorf("ошибка при создании пользователя")
+	}
+	return user, true, nil
+}
+
+// newPhoneCode возвращает случайный код из 6 цифр
+func newPhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %v", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashPhoneCode привязывает хеш кода к номеру
+func hashPhoneCode(phone, code string) string {
+	return hashToken(phone + ":" + code)
+}
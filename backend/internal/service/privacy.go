@@ -0,0 +1,274 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/hash"
+)
+
+const (
+	erasureInterval  = time.Hour
+	erasureBatchSize = 20
+)
+
+var (
+	ErrDeletionNotRequested = errors.New("удаление аккаунта не запрашивалось")
+	ErrInvalidGuestPhone    = errors.New("некорректный номер гостя")
+)
+
+// avatarExtensions - расширение файла аватара в архиве выгрузки
+var avatarExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// PrivacyService выгружает и удаляет персональные данные: владелец может скачать
+// всё о себе или о госте и удалить аккаунт, гостя владелец удаляет по его просьбе.
+type PrivacyService struct {
+	repo          *postgres.PrivacyRepository
+	users         *postgres.UserRepository
+	hasher        *hash.PasswordHasher
+	accounts      *AccountService
+	notifications *NotificationService
+	onErase       []func(userID uint)
+}
+
+func NewPrivacyService(repo *postgres.PrivacyRepository, users *postgres.UserRepository, hasher *hash.PasswordHasher, accounts *AccountService, notifications *NotificationService) *PrivacyService {
+	return &PrivacyService{
+		repo:          repo,
+		users:         users,
+		hasher:        hasher,
+		accounts:      accounts,
+		notifications: notifications,
+	}
+}
+
+// OnErase регистрирует обработчик, вызываемый после обезличивания аккаунта
+func (s *PrivacyService) OnErase(listener func(userID uint)) {
+	s.onErase = append(s.onErase, listener)
+}
+
+// ExportAccount собирает zip-архив со всеми данными владельца: по JSON-файлу
+// на раздел и аватар, если он загружен
+func (s *PrivacyService) ExportAccount(userID uint) ([]byte, error) {
+	export, err := s.repo.ExportAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	avatar, contentType, err := s.users.GetAvatar(userID)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+	if err == nil {
+		files["avatar."+avatarExtensions[contentType]] = avatar
+	}
+
+	return buildExportArchive(export, files)
+}
+
+// ExportGuest собирает zip-архив с данными гостя у владельца, чтобы передать его гостю
+func (s *PrivacyService) ExportGuest(userID uint, guestPhone string) ([]byte, error) {
+	phones, err := guestPhoneVariants(guestPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := s.repo.ExportGuest(userID, phones)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildExportArchive(export, nil)
+}
+
+// EraseGuest удаляет данные гостя по его просьбе. Брони остаются без имени и телефона.
+func (s *PrivacyService) EraseGuest(userID uint, guestPhone string) (int64, error) {
+	phones, err := guestPhoneVariants(guestPhone)
+	if err != nil {
+		return 0, err
+	}
+
+	erased, err := s.repo.EraseGuest(userID, phones)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Erased %d records of a guest for user %d", erased, userID)
+	return erased, nil
+}
+
+// RequestDeletion назначает удаление аккаунта через AccountDeletionGracePeriod.
+// До этого срока аккаунт работает как обычно и удаление можно отменить.
+func (s *PrivacyService) RequestDeletion(userID uint, input model.DeleteAccountInput) (*model.AccountDeletion, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash != "" && !s.hasher.CheckPassword(input.Password, user.PasswordHash) {
+		return nil, ErrWrongPassword
+	}
+	if user.DeletionScheduledAt != nil {
+		return &model.AccountDeletion{ScheduledAt: *user.DeletionScheduledAt}, nil
+	}
+
+	scheduledAt := time.Now().Add(model.AccountDeletionGracePeriod)
+	if err := s.repo.ScheduleDeletion(userID, scheduledAt); err != nil {
+		return nil, err
+	}
+
+	localDate := scheduledAt
+	if location, err := time.LoadLocation(user.Timezone); err == nil {
+		localDate = scheduledAt.In(location)
+	}
+	s.notifications.Notify(
+		userID,
+		model.NotificationSecurity,
+		"Аккаунт будет удалён",
+		fmt.Sprintf("Аккаунт и личные данные будут удалены %s. До этого удаление можно отменить в профиле.", localDate.Format("02.01.2006")),
+		nil,
+	)
+	if err := s.accounts.SendDeletionScheduled(user, scheduledAt); err != nil {
+		log.Printf("Failed to send deletion email to user %d: %v", userID, err)
+	}
+
+	return &model.AccountDeletion{ScheduledAt: scheduledAt}, nil
+}
+
+func (s *PrivacyService) CancelDeletion(userID uint) error {
+	if err := s.repo.CancelDeletion(userID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrDeletionNotRequested
+		}
+		return err
+	}
+
+	s.notifications.Notify(
+		userID,
+		model.NotificationSecurity,
+		"Удаление аккаунта отменено",
+		"Аккаунт не будет удалён. Если это были не вы, смените пароль.",
+		nil,
+	)
+	return nil
+}
+
+// Run раз в erasureInterval обезличивает аккаунты, срок удаления которых наступил
+func (s *PrivacyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(erasureInterval)
+	defer ticker.Stop()
+
+	for {
+		s.eraseDue()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PrivacyService) eraseDue() {
+	ids, err := s.repo.GetDueDeletions(erasureBatchSize)
+	if err != nil {
+		log.Printf("Error getting accounts due for deletion: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		if err := s.repo.EraseAccount(id); err != nil {
+			log.Printf("Error erasing account %d: %v", id, err)
+			continue
+		}
+		log.Printf("Erased account %d", id)
+
+		for _, listener := range s.onErase {
+			listener(id)
+		}
+	}
+}
+
+// buildExportArchive упаковывает разделы в <раздел>.json и добавляет файлы как есть
+func buildExportArchive(export model.DataExport, files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(export))
+	for name := range export {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	manifest, err := json.MarshalIndent(map[string]interface{}{
+		"generated_at": time.Now().UTC(),
+		"sections":     names,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeArchiveFile(archive, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, export[name], "", "  "); err != nil {
+			return nil, fmt.Errorf("error formatting %s: %v", name, err)
+		}
+		if err := writeArchiveFile(archive, name+".json", indented.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, data := range files {
+		if err := writeArchiveFile(archive, name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("error closing archive: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeArchiveFile(archive *zip.Writer, name string, data []byte) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("error adding %s to archive: %v", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("error writing %s to archive: %v", name, err)
+	}
+	return nil
+}
+
+// guestPhoneVariants возвращает номер гостя только цифрами во всех видах, в которых
+// его могли записать: казахстанский +77011234567 встречается и как 87011234567, и как 7011234567
+func guestPhoneVariants(raw string) ([]string, error) {
+	phone, err := normalizePhone(raw)
+	if err != nil {
+		return nil, ErrInvalidGuestPhone
+	}
+
+	digits := strings.TrimPrefix(phone, "+")
+	variants := []string{digits}
+	if len(digits) == 11 && digits[0] == '7' {
+		variants = append(variants, "8"+digits[1:], digits[1:])
+	}
+	return variants, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+)
+
+const dateLayout = "2006-01-02"
+
+// Quote - расчёт стоимости проживания
+type Quote struct {
+	ApartmentID   uint      `json:"apartment_id"`
+	DateStart     time.Time `json:"date_start"`
+	DateEnd       time.Time `json:"date_end"`
+	Nights        int       `json:"nights"`
+	PricePerNight int       `json:"price_per_night"`
+	Total         int       `json:"total"`
+}
+
+// parseStay разбирает даты заезда и выезда в формате ГГГГ-ММ-ДД
+func parseStay(dateStart, dateEnd string) (time.Time, time.Time, error) {
+	start, err := time.Parse(dateLayout, dateStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("некорректная дата заезда, нужен формат ГГГГ-ММ-ДД")
+	}
+	end, err := time.Parse(dateLayout, dateEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("некорректная дата выезда, нужен формат ГГГГ-ММ-ДД")
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("дата выезда должна быть позже даты заезда")
+	}
+	return start, end, nil
+}
+
+// QuoteStay считает стоимость проживания в квартире с заезда start до выезда end
+func QuoteStay(apt model.Apartment, start, end time.Time) Quote {
+	nights := int(end.Sub(start).Hours() / 24)
+	return Quote{
+		ApartmentID:   apt.ID,
+		DateStart:     start,
+		DateEnd:       end,
+		Nights:        nights,
+		PricePerNight: apt.Price,
+		Total:         nights * apt.Price,
+	}
+}
+
+// isFree проверяет по загруженному календарю, свободна ли квартира в период [start, end)
+func isFree(apt model.Apartment, start, end time.Time) bool {
+	for _, av := range apt.Availabilities {
+		if av.Status == model.StatusAvailable {
+			continue
+		}
+		if av.DateStart.Before(end) && av.DateEnd.After(start) {
+			return false
+		}
+	}
+	return true
+}
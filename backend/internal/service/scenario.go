@@ -0,0 +1,294 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+const (
+	defaultCheckInTime     = "14:00"
+	defaultCheckOutTime    = "12:00"
+	defaultCheckOutMorning = "09:00"
+	defaultGuestName       = "гость"
+)
+
+type ScenarioService struct {
+	repo        *postgres.ScenarioRepository
+	messages    *postgres.ScheduledMessageRepository
+	bookingRepo *postgres.BookingRequestRepository
+	aiRepo      *postgres.AIConfigRepository
+	templates   *TemplateService
+	apartments  *postgres.ApartmentRepository
+}
+
+func NewScenarioService(repo *postgres.ScenarioRepository, messages *postgres.ScheduledMessageRepository, bookingRepo *postgres.BookingRequestRepository, aiRepo *postgres.AIConfigRepository, templates *TemplateService, apartments *postgres.ApartmentRepository) *ScenarioService {
+	return &ScenarioService{
+		repo:        repo,
+		messages:    messages,
+		bookingRepo: bookingRepo,
+		aiRepo:      aiRepo,
+		templates:   templates,
+		apartments:  apartments,
+	}
+}
+
+func (s *ScenarioService) GetByUserID(userID uint) ([]model.Scenario, error) {
+	scenarios, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scenarios: %v", err)
+	}
+	return scenarios, nil
+}
+
+func (s *ScenarioService) Create(userID uint, input model.ScenarioInput) (*model.Scenario, error) {
+	scenario := &model.Scenario{UserID: userID}
+	if err := s.applyInput(scenario, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(scenario); err != nil {
+		return nil, fmt.Errorf("failed to create scenario: %v", err)
+	}
+	return scenario, nil
+}
+
+// Update меняет сценарий. Уже запланированные сообщения берут новый текст
+// при отправке, но время отправки не пересчитывается.
+func (s *ScenarioService) Update(userID uint, scenarioID string, input model.ScenarioInput) (*model.Scenario, error) {
+	scenario, err := s.repo.GetByID(userID, scenarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyInput(scenario, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(scenario); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+func (s *ScenarioService) Delete(userID uint, scenarioID string) error {
+	return s.repo.Delete(userID, scenarioID)
+}
+
+func (s *ScenarioService) GetGuestInfo(userID uint, apartmentID string) (*model.ApartmentGuestInfo, error) {
+	return s.repo.GetGuestInfo(userID, apartmentID)
+}
+
+func (s *ScenarioService) UpdateGuestInfo(userID uint, apartmentID string, input model.UpdateGuestInfoInput) (*model.ApartmentGuestInfo, error) {
+	if _, err := authorizeApartment(s.apartments, userID, apartmentID, model.PermApartmentEdit); err != nil {
+		return nil, err
+	}
+
+	info, err := s.repo.GetGuestInfo(userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	info.CheckInTime = strings.TrimSpace(input.CheckInTime)
+	info.CheckOutTime = strings.TrimSpace(input.CheckOutTime)
+	info.DoorCode = strings.TrimSpace(input.DoorCode)
+	info.Instructions = strings.TrimSpace(input.Instructions)
+
+	if info.CheckInTime == "" {
+		info.CheckInTime = defaultCheckInTime
+	}
+	if info.CheckOutTime == "" {
+		info.CheckOutTime = defaultCheckOutTime
+	}
+	if _, err := time.Parse("15:04", info.CheckInTime); err != nil {
+		return nil, errors.New("некорректное время заезда, нужен формат ЧЧ:ММ")
+	}
+	if _, err := time.Parse("15:04", info.CheckOutTime); err != nil {
+		return nil, errors.New("некорректное время выезда, нужен формат ЧЧ:ММ")
+	}
+
+	if err := s.repo.UpsertGuestInfo(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *ScenarioService) GetScheduledMessages(userID uint) ([]model.ScheduledMessage, error) {
+	messages, err := s.messages.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled messages: %v", err)
+	}
+	return messages, nil
+}
+
+// ScheduleForBooking планирует сообщения всех включённых сценариев владельца
+// по подтверждённой заявке. Повторный вызов не создаёт дублей.
+func (s *ScenarioService) ScheduleForBooking(req *model.BookingRequest) error {
+	if req.GuestPhone == "" {
+		return nil
+	}
+
+	scenarios, err := s.repo.GetByUserID(req.UserID)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.repo.GetGuestInfo(req.UserID, fmt.Sprint(req.ApartmentID))
+	if err != nil {
+		return err
+	}
+
+	loc := s.ownerLocation(req.UserID)
+	now := time.Now()
+	for _, scenario := range scenarios {
+		if !scenario.Enabled {
+			continue
+		}
+
+		sendAt, ok := scenarioSendTime(scenario, req, info, loc, now)
+		if !ok {
+			continue
+		}
+
+		msg := &model.ScheduledMessage{
+			UserID:           req.UserID,
+			ScenarioID:       scenario.ID,
+			BookingRequestID: req.ID,
+			GuestPhone:       req.GuestPhone,
+			SendAt:           sendAt,
+		}
+		if _, err := s.messages.Schedule(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OnBookingConfirmed подходит для BookingService.OnConfirm
+func (s *ScenarioService) OnBookingConfirmed(req *model.BookingRequest) {
+	if err := s.ScheduleForBooking(req); err != nil {
+		log.Printf("Error scheduling scenarios for booking request %d: %v", req.ID, err)
+	}
+}
+
+// render подставляет в шаблон сценария актуальные данные заявки и квартиры
+func (s *ScenarioService) render(msg model.ScheduledMessage) (*model.Scenario, *model.BookingRequest, string, error) {
+	scenario, err := s.repo.GetByID(msg.UserID, fmt.Sprint(msg.ScenarioID))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	req, err := s.bookingRepo.GetByID(msg.UserID, fmt.Sprint(msg.BookingRequestID))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if scenario.TemplateID != nil {
+		body, err := s.templates.RenderForBooking(msg.UserID, *scenario.TemplateID, req)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return scenario, req, body, nil
+	}
+
+	info, err := s.repo.GetGuestInfo(msg.UserID, fmt.Sprint(req.ApartmentID))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return scenario, req, renderTemplate(scenario.Template, req, info), nil
+}
+
+// ownerLocation возвращает часовой пояс владельца из настроек ИИ
+func (s *ScenarioService) ownerLocation(userID uint) *time.Location {
+	timezone := model.DefaultTimezone
+	if config, err := s.aiRepo.GetByUserID(userID); err == nil && config != nil && config.BusinessHours.Timezone != "" {
+		timezone = config.BusinessHours.Timezone
+	}
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func (s *ScenarioService) applyInput(scenario *model.Scenario, input model.ScenarioInput) error {
+	scenario.Name = strings.TrimSpace(input.Name)
+	scenario.Trigger = input.Trigger
+	scenario.OffsetHours = input.OffsetHours
+	scenario.SendTime = strings.TrimSpace(input.SendTime)
+	scenario.Template = strings.TrimSpace(input.Template)
+	scenario.TemplateID = input.TemplateID
+	scenario.Enabled = input.Enabled
+
+	switch scenario.Trigger {
+	case model.TriggerBookingConfirmed, model.TriggerBeforeCheckIn, model.TriggerAfterCheckOut:
+		scenario.SendTime = ""
+	case model.TriggerCheckOutMorning:
+		if scenario.SendTime == "" {
+			scenario.SendTime = defaultCheckOutMorning
+		}
+		if _, err := time.Parse("15:04", scenario.SendTime); err != nil {
+			return errors.New("некорректное время отправки, нужен формат ЧЧ:ММ")
+		}
+		scenario.OffsetHours = 0
+	default:
+		return errors.New("неизвестное событие сценария")
+	}
+
+	if scenario.Name == "" {
+		return errors.New("укажите название сценария")
+	}
+
+	// Текст из библиотеки шаблонов заменяет собственный текст сценария
+	if scenario.TemplateID != nil {
+		if _, err := s.templates.Get(scenario.UserID, fmt.Sprint(*scenario.TemplateID)); err != nil {
+			return err
+		}
+		scenario.Template = ""
+		return nil
+	}
+
+	if scenario.Template == "" {
+		return errors.New("укажите текст сценария или шаблон")
+	}
+	return validateTemplate(scenario.Template)
+}
+
+// scenarioSendTime считает момент отправки в часовом поясе владельца.
+// Сообщения, время которых уже прошло, не планируются.
+func scenarioSendTime(scenario model.Scenario, req *model.BookingRequest, info *model.ApartmentGuestInfo, loc *time.Location, now time.Time) (time.Time, bool) {
+	offset := time.Duration(scenario.OffsetHours) * time.Hour
+
+	var sendAt time.Time
+	switch scenario.Trigger {
+	case model.TriggerBookingConfirmed:
+		return now, true
+	case model.TriggerBeforeCheckIn:
+		sendAt = atClock(req.DateStart, info.CheckInTime, defaultCheckInTime, loc).Add(-offset)
+	case model.TriggerCheckOutMorning:
+		sendAt = atClock(req.DateEnd, scenario.SendTime, defaultCheckOutMorning, loc)
+	case model.TriggerAfterCheckOut:
+		sendAt = atClock(req.DateEnd, info.CheckOutTime, defaultCheckOutTime, loc).Add(offset)
+	default:
+		return time.Time{}, false
+	}
+
+	return sendAt, sendAt.After(now)
+}
+
+// atClock возвращает момент clock ("ЧЧ:ММ") в день date. Даты заявок
+// хранятся как полночь UTC, поэтому календарный день берётся в UTC.
+func atClock(date time.Time, clock, fallback string, loc *time.Location) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		t, _ = time.Parse("15:04", fallback)
+	}
+	y, m, d := date.UTC().Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+}
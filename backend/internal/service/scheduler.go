@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+const (
+	schedulerInterval   = time.Minute
+	schedulerBatchSize  = 20
+	maxSendAttempts     = 3
+	sendRetryDelay      = 5 * time.Minute
+	staleSendingTimeout = 10 * time.Minute
+	// maxSendDelay - насколько может опоздать сообщение, привязанное ко времени.
+	// Напоминание о заезде, пролежавшее в очереди сутки, гостю уже не нужно.
+	maxSendDelay = 6 * time.Hour
+)
+
+// MessageScheduler передаёт созревшие сообщения сценариев в очередь отправки.
+// Расписание переживает перезапуск, а сообщение, зависшее в обработке,
+// помечается ошибкой и не отправляется повторно. Доставку, повторы и лимиты
+// частоты берёт на себя OutboxService.
+type MessageScheduler struct {
+	scenarios     *ScenarioService
+	messages      *postgres.ScheduledMessageRepository
+	outbox        *OutboxService
+	consents      *ConsentService
+	conversations *ConversationService
+}
+
+func NewMessageScheduler(scenarios *ScenarioService, messages *postgres.ScheduledMessageRepository, outbox *OutboxService, consents *ConsentService, conversations *ConversationService) *MessageScheduler {
+	return &MessageScheduler{
+		scenarios:     scenarios,
+		messages:      messages,
+		outbox:        outbox,
+		consents:      consents,
+		conversations: conversations,
+	}
+}
+
+// Run обрабатывает очередь раз в schedulerInterval, пока не отменён ctx
+func (s *MessageScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		s.tick()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *MessageScheduler) tick() {
+	if n, err := s.messages.FailStale(time.Now().Add(-staleSendingTimeout)); err != nil {
+		log.Printf("Error failing stale scheduled messages: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d interrupted scheduled messages as failed", n)
+	}
+
+	due, err := s.messages.ClaimDue(schedulerBatchSize)
+	if err != nil {
+		log.Printf("Error claiming scheduled messages: %v", err)
+		return
+	}
+
+	for _, msg := range due {
+		s.deliver(msg)
+	}
+}
+
+func (s *MessageScheduler) deliver(msg model.ScheduledMessage) {
+	scenario, req, body, err := s.scenarios.render(msg)
+	if err != nil {
+		s.finish(msg, model.ScheduledFailed, err.Error())
+		return
+	}
+
+	switch {
+	case !scenario.Enabled:
+		s.finish(msg, model.ScheduledCancelled, "сценарий отключён")
+		return
+	case req.Status != model.BookingConfirmed:
+		s.finish(msg, model.ScheduledCancelled, "бронирование не подтверждено")
+		return
+	case scenario.Trigger != model.TriggerBookingConfirmed && time.Since(msg.SendAt) > maxSendDelay:
+		s.finish(msg, model.ScheduledCancelled, "время отправки прошло")
+		return
+	}
+
+	// Ключ по ID сообщения сценария: повторная обработка не поставит его в очередь дважды
+	outbound := &model.OutboundMessage{
+		UserID:         msg.UserID,
+		GuestPhone:     msg.GuestPhone,
+		Kind:           model.KindText,
+		Body:           body,
+		IdempotencyKey: fmt.Sprintf("scenario:%d", msg.ID),
+	}
+	allowed, err := s.consents.Prepare(outbound)
+	if err == nil && !allowed {
+		s.finish(msg, model.ScheduledCancelled, "гость отписался от автоматических сообщений")
+		return
+	}
+	if err == nil {
+		_, err = s.outbox.Enqueue(outbound)
+	}
+	if err != nil {
+		if msg.Attempts >= maxSendAttempts {
+			s.finish(msg, model.ScheduledFailed, err.Error())
+			return
+		}
+		retryAt := time.Now().Add(time.Duration(msg.Attempts) * sendRetryDelay)
+		if err := s.messages.Reschedule(msg.ID, retryAt, err.Error()); err != nil {
+			log.Printf("Error rescheduling message %d: %v", msg.ID, err)
+		}
+		return
+	}
+
+	if err := s.messages.MarkSent(msg.ID, body); err != nil {
+		log.Printf("Error marking message %d sent: %v", msg.ID, err)
+	}
+
+	if _, err := s.conversations.Record(msg.UserID, msg.GuestPhone, model.RoleAssistant, body); err != nil {
+		log.Printf("Error saving scheduled message %d to conversation: %v", msg.ID, err)
+	}
+}
+
+func (s *MessageScheduler) finish(msg model.ScheduledMessage, status model.ScheduledMessageStatus, reason string) {
+	if err := s.messages.Finish(msg.ID, status, reason); err != nil {
+		log.Printf("Error updating scheduled message %d: %v", msg.ID, err)
+	}
+}
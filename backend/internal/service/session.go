@@ -0,0 +1,271 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/jwt"
+)
+
+// sessionCheckInterval - как долго проверка сессии берётся из памяти.
+// Столько же после отзыва может действовать access-токен на другом экземпляре сервера.
+const sessionCheckInterval = 30 * time.Second
+
+var (
+	ErrInvalidRefreshToken = errors.New("недействительный refresh-токен, войдите заново")
+	ErrRefreshTokenReused  = errors.New("refresh-токен уже использовался, сессия завершена в целях безопасности")
+)
+
+// SessionService выдаёт пары токенов, обновляет их с ротацией refresh-токена
+// и ведёт список активных сессий владельца
+type SessionService struct {
+	repo          *postgres.SessionRepository
+	tokens        *jwt.TokenManager
+	notifications *NotificationService
+	refreshTTL    time.Duration
+
+	mu      sync.Mutex
+	checked map[string]sessionCheck
+}
+
+type sessionCheck struct {
+	userID uint
+	at     time.Time
+}
+
+func NewSessionService(repo *postgres.SessionRepository, tokens *jwt.TokenManager, notifications *NotificationService, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		repo:          repo,
+		tokens:        tokens,
+		notifications: notifications,
+		refreshTTL:    refreshTTL,
+		checked:       make(map[string]sessionCheck),
+	}
+}
+
+// Start открывает новую сессию после успешного входа
+func (s *SessionService) Start(userID uint, meta model.SessionMeta) (*model.TokenPair, error) {
+	refreshToken, tokenHash, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.refreshTTL)
+	session := &model.Session{
+		ID:        sessionID,
+		UserID:    userID,
+		UserAgent: truncateRunes(meta.UserAgent, 500),
+		IP:        meta.IP,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(session, tokenHash, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return s.pair(userID, sessionID, refreshToken)
+}
+
+// Refresh меняет refresh-токен на новую пару. Повторное предъявление
+// уже использованного токена отзывает сессию целиком.
+func (s *SessionService) Refresh(refreshToken string, meta model.SessionMeta) (*model.TokenPair, error) {
+	next, nextHash, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, reused, err := s.repo.Rotate(hashToken(refreshToken), nextHash, time.Now().Add(s.refreshTTL), model.SessionMeta{
+		UserAgent: truncateRunes(meta.UserAgent, 500),
+		IP:        meta.IP,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if reused {
+		s.forget(session.ID)
+		log.Printf("Refresh token reuse detected for user %d, session %s revoked", session.UserID, session.ID)
+		s.notifications.Notify(
+			session.UserID,
+			model.NotificationSecurity,
+			"Сессия завершена",
+			fmt.Sprintf("Кто-то повторно использовал токен входа с устройства %s (IP %s). Сессия завершена, войдите заново и смените пароль, если это были не вы.",
+				describeDevice(meta.UserAgent), meta.IP),
+			map[string]string{"session_id": session.ID},
+		)
+		return nil, ErrRefreshTokenReused
+	}
+
+	return s.pair(session.UserID, session.ID, next)
+}
+
+// Logout завершает сессию, которой принадлежит refresh-токен
+func (s *SessionService) Logout(refreshToken string) error {
+	session, err := s.repo.RevokeByToken(hashToken(refreshToken), model.RevokeLogout)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	s.forget(session.ID)
+	return nil
+}
+
+// LogoutAll завершает все сессии владельца, включая текущую
+func (s *SessionService) LogoutAll(userID uint) (int, error) {
+	ids, err := s.repo.RevokeAll(userID, model.RevokeLogoutAll)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		s.forget(id)
+	}
+	return len(ids), nil
+}
+
+// Revoke завершает одну сессию владельца, например на потерянном телефоне
+func (s *SessionService) Revoke(userID uint, sessionID string) error {
+	if err := s.repo.Revoke(userID, sessionID, model.RevokeByOwner); err != nil {
+		return err
+	}
+
+	s.forget(sessionID)
+	return nil
+}
+
+// GetActive возвращает активные сессии, отмечая ту, из которой пришёл запрос
+func (s *SessionService) GetActive(userID uint, currentID string) ([]model.Session, error) {
+	sessions, err := s.repo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %v", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Device = describeDevice(sessions[i].UserAgent)
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// ValidateSession проверяет, что сессия access-токена активна, и отмечает время активности
+func (s *SessionService) ValidateSession(userID uint, sessionID string) error {
+	if sessionID == "" {
+		return errors.New("token has no session")
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	check, ok := s.checked[sessionID]
+	s.mu.Unlock()
+	if ok && check.userID == userID && now.Sub(check.at) < sessionCheckInterval {
+		return nil
+	}
+
+	if err := s.repo.Touch(userID, sessionID); err != nil {
+		s.forget(sessionID)
+		return err
+	}
+
+	s.mu.Lock()
+	s.checked[sessionID] = sessionCheck{userID: userID, at: now}
+	// Записи старше интервала больше не нужны, чистим их заодно
+	for id, c := range s.checked {
+		if now.Sub(c.at) >= sessionCheckInterval {
+			delete(s.checked, id)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *SessionService) forget(sessionID string) {
+	s.mu.Lock()
+	delete(s.checked, sessionID)
+	s.mu.Unlock()
+}
+
+func (s *SessionService) pair(userID uint, sessionID, refreshToken string) (*model.TokenPair, error) {
+	accessToken, err := s.tokens.GenerateToken(userID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании токена")
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.tokens.AccessTTL().Seconds()),
+	}, nil
+}
+
+// newSecretToken возвращает случайный токен (refresh-токен, ссылка из письма) и его хеш для хранения в базе
+func newSecretToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// describeDevice превращает User-Agent в короткое название вроде "Chrome, Windows"
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Неизвестное устройство"
+	}
+
+	browser := "Браузер"
+	for _, b := range []struct{ marker, name string }{
+		{"edg/", "Edge"}, {"opr/", "Opera"}, {"yabrowser", "Яндекс Браузер"},
+		{"chrome/", "Chrome"}, {"firefox/", "Firefox"}, {"safari/", "Safari"},
+		{"okhttp", "Android-приложение"}, {"cfnetwork", "iOS-приложение"},
+	} {
+		if strings.Contains(ua, b.marker) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, platform := range []struct{ marker, name string }{
+		{"iphone", "iPhone"}, {"ipad", "iPad"}, {"android", "Android"},
+		{"windows", "Windows"}, {"mac os", "macOS"}, {"linux", "Linux"},
+	} {
+		if strings.Contains(ua, platform.marker) {
+			return browser + ", " + platform.name
+		}
+	}
+	return browser
+}
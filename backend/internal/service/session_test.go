@@ -0,0 +1,85 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/jwt"
+)
+
+func newTestSessionService(t *testing.T) (*SessionService, *fakeDB) {
+	db, fake := newFakeDB(t)
+
+	keys, err := jwt.NewHMACKeyset("test", "test-secret-that-is-long-enough-for-hs256")
+	if err != nil {
+		t.Fatalf("NewHMACKeyset: %v", err)
+	}
+	tokens := jwt.NewTokenManager(keys, "uilet", "uilet-api", 15*time.Minute)
+	notifications := NewNotificationService(postgres.NewNotificationRepository(db))
+
+	return NewSessionService(postgres.NewSessionRepository(db), tokens, notifications, 30*24*time.Hour), fake
+}
+
+func TestRefresh(t *testing.T) {
+	now := time.Now()
+	sessionRow := []driver.Value{"session-1", int64(7), "Mozilla/5.0", "10.0.0.1", now.Add(-time.Hour), now, now.Add(time.Hour)}
+
+	tests := []struct {
+		name      string
+		usedAt    interface{}
+		revokedAt interface{}
+		wantErr   error
+		revoked   bool
+	}{
+		{"fresh token is rotated", nil, nil, nil, false},
+		{"reused token revokes the session", now.Add(-time.Minute), nil, ErrRefreshTokenReused, true},
+		{"token of a revoked session", now.Add(-time.Minute), now.Add(-time.Minute), ErrInvalidRefreshToken, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestSessionService(t)
+			fake.on("FROM refresh_tokens rt", func(args []driver.Value) fakeResult {
+				return fakeResult{rows: [][]driver.Value{{"session-1", tt.usedAt, now.Add(time.Hour), tt.revokedAt, now.Add(time.Hour)}}}
+			})
+			fake.on("UPDATE sessions", func(args []driver.Value) fakeResult {
+				return fakeResult{rows: [][]driver.Value{sessionRow}}
+			})
+			fake.on("refresh_tokens", func(args []driver.Value) fakeResult {
+				return fakeResult{rowsAffected: 1}
+			})
+			fake.on("INSERT INTO notifications", func(args []driver.Value) fakeResult {
+				return fakeResult{rows: [][]driver.Value{{int64(1), now}}}
+			})
+			s.checked["session-1"] = sessionCheck{userID: 7, at: now}
+
+			pair, err := s.Refresh("refresh-token", model.SessionMeta{UserAgent: "Mozilla/5.0", IP: "10.0.0.1"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (pair.AccessToken == "" || pair.RefreshToken == "" || pair.RefreshToken == "refresh-token") {
+				t.Errorf("pair = %+v, want a new access and refresh token", pair)
+			}
+
+			revokes := fake.executed("SET revoked_at")
+			if got := len(revokes) == 1; got != tt.revoked {
+				t.Fatalf("session revoked %d times, want revoked = %v", len(revokes), tt.revoked)
+			}
+			if !tt.revoked {
+				return
+			}
+			if reason := revokes[0].args[0]; reason != model.RevokeTokenReuse {
+				t.Errorf("revoke reason = %v, want %s", reason, model.RevokeTokenReuse)
+			}
+			if _, ok := s.checked["session-1"]; ok {
+				t.Error("revoked session is still cached as active")
+			}
+			if n := len(fake.executed("INSERT INTO notifications")); n != 1 {
+				t.Errorf("owner notified %d times, want 1", n)
+			}
+		})
+	}
+}
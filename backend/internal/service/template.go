@@ -0,0 +1,299 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+)
+
+// maxTemplateRunes - ограничение WhatsApp Business на длину текста шаблона
+const maxTemplateRunes = 1024
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// templateVariables - единственные переменные, которые можно использовать в шаблонах.
+// Язык шаблонов сознательно ограничен подстановкой: ни условий, ни вызовов функций.
+var templateVariables = []model.TemplateVariable{
+	{Name: "guest_name", Description: "имя гостя"},
+	{Name: "address", Description: "адрес квартиры"},
+	{Name: "complex", Description: "жилой комплекс"},
+	{Name: "door_code", Description: "код от двери"},
+	{Name: "check_in_date", Description: "дата заезда"},
+	{Name: "check_in_time", Description: "время заезда"},
+	{Name: "check_out_date", Description: "дата выезда"},
+	{Name: "check_out_time", Description: "время выезда"},
+	{Name: "instructions", Description: "инструкции по заселению"},
+	{Name: "nights", Description: "количество ночей"},
+	{Name: "total_price", Description: "стоимость проживания"},
+}
+
+var templateCategories = map[model.TemplateCategory]bool{
+	model.TemplateUtility:        true,
+	model.TemplateMarketing:      true,
+	model.TemplateAuthentication: true,
+}
+
+type TemplateService struct {
+	repo         *postgres.MessageTemplateRepository
+	scenarioRepo *postgres.ScenarioRepository
+	bookingRepo  *postgres.BookingRequestRepository
+	aiRepo       *postgres.AIConfigRepository
+}
+
+func NewTemplateService(repo *postgres.MessageTemplateRepository, scenarioRepo *postgres.ScenarioRepository, bookingRepo *postgres.BookingRequestRepository, aiRepo *postgres.AIConfigRepository) *TemplateService {
+	return &TemplateService{
+		repo:         repo,
+		scenarioRepo: scenarioRepo,
+		bookingRepo:  bookingRepo,
+		aiRepo:       aiRepo,
+	}
+}
+
+func (s *TemplateService) Variables() []model.TemplateVariable {
+	return templateVariables
+}
+
+func (s *TemplateService) GetByUserID(userID uint) ([]model.MessageTemplate, error) {
+	templates, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %v", err)
+	}
+	return templates, nil
+}
+
+func (s *TemplateService) Get(userID uint, templateID string) (*model.MessageTemplate, error) {
+	return s.repo.GetByID(userID, templateID)
+}
+
+func (s *TemplateService) Create(userID uint, input model.MessageTemplateInput) (*model.MessageTemplate, error) {
+	t := &model.MessageTemplate{UserID: userID}
+	if err := applyTemplateInput(t, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(t); err != nil {
+		return nil, fmt.Errorf("failed to create template: %v", err)
+	}
+	return t, nil
+}
+
+func (s *TemplateService) Update(userID uint, templateID string, input model.MessageTemplateInput) (*model.MessageTemplate, error) {
+	t, err := s.repo.GetByID(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyTemplateInput(t, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *TemplateService) Delete(userID uint, templateID string) error {
+	t, err := s.repo.GetByID(userID, templateID)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.repo.IsUsed(t.ID)
+	if err != nil {
+		return err
+	}
+	if used {
+		return errors.New("шаблон используется в сценариях")
+	}
+
+	return s.repo.Delete(userID, templateID)
+}
+
+// Preview подставляет в шаблон данные заявки владельца
+func (s *TemplateService) Preview(userID uint, templateID string, input model.PreviewTemplateInput) (*model.TemplatePreview, error) {
+	t, err := s.repo.GetByID(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.bookingRepo.GetByID(userID, fmt.Sprint(input.BookingRequestID))
+	if err != nil {
+		return nil, err
+	}
+
+	language, text, err := s.render(t, input.Language, req)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TemplatePreview{Language: language, Text: text}, nil
+}
+
+// RenderForConversation заполняет шаблон для ответа владельца в переписке
+// по последней действующей заявке этого гостя
+func (s *TemplateService) RenderForConversation(conv *model.Conversation, templateID uint, language string) (string, error) {
+	t, err := s.repo.GetByID(conv.UserID, fmt.Sprint(templateID))
+	if err != nil {
+		return "", err
+	}
+
+	req, err := s.bookingRepo.GetLatestForGuest(conv.UserID, conv.ID, conv.GuestPhone)
+	if err != nil {
+		return "", err
+	}
+
+	_, text, err := s.render(t, language, req)
+	return text, err
+}
+
+// RenderForBooking заполняет шаблон по заявке на языке владельца
+func (s *TemplateService) RenderForBooking(userID uint, templateID uint, req *model.BookingRequest) (string, error) {
+	t, err := s.repo.GetByID(userID, fmt.Sprint(templateID))
+	if err != nil {
+		return "", err
+	}
+
+	_, text, err := s.render(t, "", req)
+	return text, err
+}
+
+// render выбирает вариант шаблона и подставляет переменные. req может быть nil,
+// тогда шаблон с переменными заявки заполнить нельзя.
+func (s *TemplateService) render(t *model.MessageTemplate, language string, req *model.BookingRequest) (string, string, error) {
+	language, body := s.pickBody(t, language)
+	if body == "" {
+		return "", "", errors.New("у шаблона нет текста")
+	}
+
+	if req == nil {
+		if placeholderPattern.MatchString(body) {
+			return "", "", errors.New("нет заявки на бронирование, чтобы заполнить шаблон")
+		}
+		return language, body, nil
+	}
+
+	info, err := s.scenarioRepo.GetGuestInfo(t.UserID, fmt.Sprint(req.ApartmentID))
+	if err != nil {
+		return "", "", err
+	}
+
+	return language, renderTemplate(body, req, info), nil
+}
+
+// pickBody выбирает язык: запрошенный, затем язык ассистента владельца, затем любой заполненный
+func (s *TemplateService) pickBody(t *model.MessageTemplate, language string) (string, string) {
+	candidates := []string{language}
+	if config, err := s.aiRepo.GetByUserID(t.UserID); err == nil && config != nil {
+		candidates = append(candidates, config.Language)
+	}
+	candidates = append(candidates, model.DefaultAILanguage)
+	candidates = append(candidates, model.TemplateLanguages...)
+
+	for _, lang := range candidates {
+		if body := t.Bodies[lang]; lang != "" && body != "" {
+			return lang, body
+		}
+	}
+	return "", ""
+}
+
+func applyTemplateInput(t *model.MessageTemplate, input model.MessageTemplateInput) error {
+	t.Name = strings.TrimSpace(input.Name)
+	t.Category = input.Category
+	t.Bodies = make(map[string]string)
+
+	if t.Name == "" {
+		return errors.New("укажите название шаблона")
+	}
+	if !templateCategories[t.Category] {
+		return errors.New("неизвестная категория шаблона")
+	}
+
+	for lang, body := range input.Bodies {
+		if !isTemplateLanguage(lang) {
+			return fmt.Errorf("неподдерживаемый язык шаблона: %s", lang)
+		}
+		body = strings.TrimSpace(body)
+		if body == "" {
+			continue
+		}
+		if err := validateTemplate(body); err != nil {
+			return fmt.Errorf("%s: %v", lang, err)
+		}
+		t.Bodies[lang] = body
+	}
+
+	if len(t.Bodies) == 0 {
+		return errors.New("заполните текст шаблона хотя бы на одном языке")
+	}
+	return nil
+}
+
+func isTemplateLanguage(lang string) bool {
+	for _, l := range model.TemplateLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTemplate проверяет, что в тексте только известные переменные
+// и нет незакрытых фигурных скобок
+func validateTemplate(template string) error {
+	if len([]rune(template)) > maxTemplateRunes {
+		return fmt.Errorf("текст длиннее %d символов", maxTemplateRunes)
+	}
+
+	for _, m := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if !isTemplateVariable(m[1]) {
+			return fmt.Errorf("неизвестная переменная {%s}", m[1])
+		}
+	}
+
+	if rest := placeholderPattern.ReplaceAllString(template, ""); strings.ContainsAny(rest, "{}") {
+		return errors.New("фигурные скобки можно использовать только для переменных, например {guest_name}")
+	}
+	return nil
+}
+
+func isTemplateVariable(name string) bool {
+	for _, v := range templateVariables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func renderTemplate(template string, req *model.BookingRequest, info *model.ApartmentGuestInfo) string {
+	guestName := req.GuestName
+	if guestName == "" {
+		guestName = defaultGuestName
+	}
+
+	values := map[string]string{
+		"guest_name":     guestName,
+		"address":        info.Address,
+		"complex":        info.Complex,
+		"door_code":      info.DoorCode,
+		"check_in_date":  req.DateStart.UTC().Format("02.01.2006"),
+		"check_in_time":  info.CheckInTime,
+		"check_out_date": req.DateEnd.UTC().Format("02.01.2006"),
+		"check_out_time": info.CheckOutTime,
+		"instructions":   info.Instructions,
+		"nights":         fmt.Sprint(req.Nights),
+		"total_price":    fmt.Sprintf("%d ₸", req.TotalPrice),
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		if value, ok := values[strings.Trim(placeholder, "{}")]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
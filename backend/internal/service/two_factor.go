@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/totp"
+)
+
+const (
+	twoFactorIssuer       = "Uilet"
+	twoFactorChallengeTTL = 5 * time.Minute
+	// twoFactorAttempts - сколько неверных кодов можно ввести за один промежуточный вход
+	twoFactorAttempts  = 5
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	// recoveryAlphabet без похожих символов: нет 0/o, 1/l/i
+	recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	ErrInvalidTwoFactorCode  = errors.New("неверный код подтверждения")
+	ErrInvalidChallenge      = errors.New("время на вход истекло, войдите заново")
+	ErrChallengeAttempts     = errors.New("слишком много неверных кодов, войдите заново")
+	ErrTwoFactorEnabled      = errors.New("двухфакторная аутентификация уже включена")
+	ErrTwoFactorNotEnabled   = errors.New("двухфакторная аутентификация не включена")
+	ErrTwoFactorNotEnrolling = errors.New("сначала начните подключение двухфакторной аутентификации")
+	ErrTwoFactorRequired     = errors.New("двухфакторная аутентификация обязательна для вашей команды")
+)
+
+// TwoFactorService - TOTP как второй фактор входа и резервные коды.
+// Вход с включённой или обязательной 2FA проходит через промежуточный токен.
+type TwoFactorService struct {
+	repo          *postgres.TwoFactorRepository
+	users         *postgres.UserRepository
+	sessions      *SessionService
+	notifications *NotificationService
+	guard         *LoginGuard
+	policies      []func(userID uint) (bool, error)
+}
+
+func NewTwoFactorService(repo *postgres.TwoFactorRepository, users *postgres.UserRepository, sessions *SessionService, notifications *NotificationService, guard *LoginGuard) *TwoFactorService {
+	return &TwoFactorService{
+		repo:          repo,
+		users:         users,
+		sessions:      sessions,
+		notifications: notifications,
+		guard:         guard,
+	}
+}
+
+// RequireWhen добавляет правило, по которому 2FA обязательна, например требование администратора команды
+func (s *TwoFactorService) RequireWhen(policy func(userID uint) (bool, error)) {
+	s.policies = append(s.policies, policy)
+}
+
+func (s *TwoFactorService) required(userID uint) (bool, error) {
+	for _, policy := range s.policies {
+		required, err := policy(userID)
+		if err != nil {
+			return false, err
+		}
+		if required {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Begin завершает вход после проверки пароля или кода из SMS: выдаёт токены
+// или промежуточный токен, если нужен второй фактор
+func (s *TwoFactorService) Begin(userID uint, meta model.SessionMeta) (*model.SignInResult, error) {
+	tf, err := s.repo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	purpose := ""
+	if tf != nil && tf.Enabled {
+		purpose = model.ChallengeVerify
+	} else if required, err := s.required(userID); err != nil {
+		return nil, err
+	} else if required {
+		purpose = model.ChallengeSetup
+	}
+
+	if purpose == "" {
+		tokens, err := s.sessions.Start(userID, meta)
+		if err != nil {
+			return nil, err
+		}
+		return &model.SignInResult{Tokens: tokens}, nil
+	}
+
+	token, tokenHash, err := newSecretToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.CreateChallenge(tokenHash, &model.TwoFactorChallenge{
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SignInResult{
+		ChallengeToken:     token,
+		ChallengePurpose:   purpose,
+		ChallengeExpiresIn: int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// Verify - второй шаг входа: код из приложения или резервный код
+func (s *TwoFactorService) Verify(input model.TwoFactorChallengeInput, meta model.SessionMeta) (*model.TokenPair, error) {
+	challenge, tokenHash, err := s.attempt(input.ChallengeToken, model.ChallengeVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ошибки считаются по владельцу, а не по промежуточному токену: иначе код можно
+	// перебирать, каждый раз заново входя по паролю
+	ctx := context.Background()
+	account := fmt.Sprintf("2fa:%d", challenge.UserID)
+	if err := s.guard.Check(ctx, account, meta.IP); err != nil {
+		return nil, err
+	}
+
+	tf, err := s.enabled(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCode(tf, input.Code, true); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.guard.Fail(ctx, account)
+		}
+		return nil, err
+	}
+	s.guard.Succeed(ctx, account)
+
+	if err := s.useChallenge(tokenHash); err != nil {
+		return nil, err
+	}
+	return s.sessions.Start(challenge.UserID, meta)
+}
+
+// SetupWithChallenge выдаёт секрет владельцу, которому 2FA обязательна, ещё до входа
+func (s *TwoFactorService) SetupWithChallenge(challengeToken string) (*model.TwoFactorEnrollment, error) {
+	challenge, err := s.repo.GetChallenge(hashToken(challengeToken))
+	if err != nil || challenge.Purpose != model.ChallengeSetup {
+		return nil, ErrInvalidChallenge
+	}
+	return s.Enroll(challenge.UserID)
+}
+
+// ConfirmWithChallenge включает обязательную 2FA первым кодом и завершает вход
+func (s *TwoFactorService) ConfirmWithChallenge(input model.TwoFactorChallengeInput, meta model.SessionMeta) (*model.TokenPair, *model.RecoveryCodes, error) {
+	challenge, tokenHash, err := s.attempt(input.ChallengeToken, model.ChallengeSetup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	codes, err := s.Confirm(challenge.UserID, input.Code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.useChallenge(tokenHash); err != nil {
+		return nil, nil, err
+	}
+	tokens, err := s.sessions.Start(challenge.UserID, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, codes, nil
+}
+
+// Enroll создаёт новый секрет. 2FA включится после ввода первого кода в Confirm.
+func (s *TwoFactorService) Enroll(userID uint) (*model.TwoFactorEnrollment, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveSecret(userID, secret); err != nil {
+		if strings.Contains(err.Error(), "already enabled") {
+			return nil, ErrTwoFactorEnabled
+		}
+		return nil, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+	return &model.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(twoFactorIssuer, account, secret),
+	}, nil
+}
+
+// Confirm проверяет первый код из приложения, включает 2FA и выдаёт резервные коды
+func (s *TwoFactorService) Confirm(userID uint, code string) (*model.RecoveryCodes, error) {
+	tf, err := s.repo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, ErrTwoFactorNotEnrolling
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), 1)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Confirm(userID, step, hashes); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrTwoFactorNotEnrolling
+		}
+		return nil, err
+	}
+
+	s.notifications.Notify(userID, model.NotificationSecurity, "Двухфакторная аутентификация включена",
+		"Теперь при входе понадобится код из приложения. Сохраните резервные коды в надёжном месте.", nil)
+	return codes, nil
+}
+
+// Disable отключает 2FA после проверки текущего кода
+func (s *TwoFactorService) Disable(userID uint, code string) error {
+	if required, err := s.required(userID); err != nil {
+		return err
+	} else if required {
+		return ErrTwoFactorRequired
+	}
+
+	tf, err := s.enabled(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCode(tf, code, true); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(userID); err != nil {
+		return err
+	}
+
+	s.notifications.Notify(userID, model.NotificationSecurity, "Двухфакторная аутентификация отключена",
+		"Для входа снова достаточно пароля. Если это были не вы, смените пароль и включите 2FA заново.", nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes заменяет резервные коды новыми, старые перестают действовать
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) (*model.RecoveryCodes, error) {
+	tf, err := s.enabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Новые коды выдаём только по коду из приложения: резервный код мог попасть в чужие руки
+	if err := s.checkCode(tf, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *TwoFactorService) Status(userID uint) (*model.TwoFactorStatus, error) {
+	status := &model.TwoFactorStatus{}
+
+	required, err := s.required(userID)
+	if err != nil {
+		return nil, err
+	}
+	status.Required = required
+
+	tf, err := s.repo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || !tf.Enabled {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.ConfirmedAt = tf.ConfirmedAt
+	if status.RecoveryCodesLeft, err = s.repo.CountRecoveryCodes(userID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (s *TwoFactorService) enabled(userID uint) (*model.TwoFactor, error) {
+	tf, err := s.repo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || !tf.Enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return tf, nil
+}
+
+// attempt находит промежуточный вход и учитывает попытку ввода кода
+func (s *TwoFactorService) attempt(challengeToken, purpose string) (*model.TwoFactorChallenge, string, error) {
+	tokenHash := hashToken(challengeToken)
+	challenge, err := s.repo.GetChallenge(tokenHash)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, "", ErrInvalidChallenge
+		}
+		return nil, "", err
+	}
+	if challenge.Purpose != purpose {
+		return nil, "", ErrInvalidChallenge
+	}
+
+	attempts, err := s.repo.AddChallengeAttempt(tokenHash)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, "", ErrInvalidChallenge
+		}
+		return nil, "", err
+	}
+	if attempts > twoFactorAttempts {
+		if err := s.repo.UseChallenge(tokenHash); err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, "", err
+		}
+		return nil, "", ErrChallengeAttempts
+	}
+
+	return challenge, tokenHash, nil
+}
+
+func (s *TwoFactorService) useChallenge(tokenHash string) error {
+	if err := s.repo.UseChallenge(tokenHash); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidChallenge
+		}
+		return err
+	}
+	return nil
+}
+
+// checkCode принимает код из приложения, а если allowRecovery - и резервный код.
+// Каждый код срабатывает один раз.
+func (s *TwoFactorService) checkCode(tf *model.TwoFactor, code string, allowRecovery bool) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if step, ok := totp.Validate(tf.Secret, code, time.Now(), 1); ok {
+		if err := s.repo.UseStep(tf.UserID, step); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return ErrInvalidTwoFactorCode
+			}
+			return err
+		}
+		return nil
+	}
+
+	if !allowRecovery {
+		return ErrInvalidTwoFactorCode
+	}
+
+	if err := s.repo.UseRecoveryCode(tf.UserID, hashToken(normalizeRecoveryCode(code))); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+
+	left, err := s.repo.CountRecoveryCodes(tf.UserID)
+	if err != nil {
+		return err
+	}
+	s.notifications.Notify(tf.UserID, model.NotificationSecurity, "Использован резервный код",
+		fmt.Sprintf("Для входа использован резервный код, осталось %d. Если это были не вы, смените пароль.", left), nil)
+	return nil
+}
+
+// newRecoveryCodes возвращает коды вида "abcde-fghjk" и их хеши для хранения
+func newRecoveryCodes() (*model.RecoveryCodes, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to generate recovery code: %v", err)
+			}
+			b[j] = recoveryAlphabet[n.Int64()]
+		}
+		code := string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	return &model.RecoveryCodes{Codes: codes}, hashes, nil
+}
+
+// normalizeRecoveryCode прощает регистр, пробелы и дефисы при вводе
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
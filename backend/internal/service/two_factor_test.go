@@ -0,0 +1,117 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/totp"
+)
+
+func newTestTwoFactorService(t *testing.T) (*TwoFactorService, *fakeDB) {
+	db, fake := newFakeDB(t)
+	notifications := NewNotificationService(postgres.NewNotificationRepository(db))
+	return NewTwoFactorService(postgres.NewTwoFactorRepository(db), postgres.NewUserRepository(db), nil, notifications, nil), fake
+}
+
+func TestCheckCodeRejectsReplayedStep(t *testing.T) {
+	s, fake := newTestTwoFactorService(t)
+
+	// last_used_step повторяет условие UPDATE в репозитории: шаг принимается,
+	// только если он позже последнего использованного
+	var lastUsedStep int64
+	fake.on("UPDATE user_totp SET last_used_step", func(args []driver.Value) fakeResult {
+		if step := args[0].(int64); step > lastUsedStep {
+			lastUsedStep = step
+			return fakeResult{rowsAffected: 1}
+		}
+		return fakeResult{}
+	})
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	tf := &model.TwoFactor{UserID: 7, Secret: secret, Enabled: true}
+	step := totp.Step(time.Now())
+	code := func(step int64) string {
+		c, err := totp.CodeAt(secret, step)
+		if err != nil {
+			t.Fatalf("CodeAt: %v", err)
+		}
+		return c
+	}
+	// Неверный код не должен случайно совпасть ни с одним кодом в пределах допуска
+	wrong := "000000"
+	for i := 1; wrong == code(step-1) || wrong == code(step) || wrong == code(step+1); i++ {
+		wrong = fmt.Sprintf("%06d", i)
+	}
+
+	steps := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"current code", code(step), nil},
+		{"same code again", code(step), ErrInvalidTwoFactorCode},
+		{"earlier code within skew", code(step - 1), ErrInvalidTwoFactorCode},
+		{"next code", code(step + 1), nil},
+		{"wrong code", wrong, ErrInvalidTwoFactorCode},
+	}
+	// Шаги выполняются по порядку: каждый зависит от использованных ранее кодов
+	for _, st := range steps {
+		if err := s.checkCode(tf, st.code, false); !errors.Is(err, st.wantErr) {
+			t.Errorf("%s: err = %v, want %v", st.name, err, st.wantErr)
+		}
+	}
+}
+
+func TestAttemptLimit(t *testing.T) {
+	s, fake := newTestTwoFactorService(t)
+
+	attempts := 0
+	fake.on("FROM two_factor_challenges", func(args []driver.Value) fakeResult {
+		return fakeResult{rows: [][]driver.Value{{int64(7), model.ChallengeVerify, int64(attempts), time.Now().Add(time.Minute)}}}
+	})
+	fake.on("SET attempts = attempts + 1", func(args []driver.Value) fakeResult {
+		attempts++
+		return fakeResult{rows: [][]driver.Value{{int64(attempts)}}}
+	})
+	fake.on("SET used_at", func(args []driver.Value) fakeResult {
+		return fakeResult{rowsAffected: 1}
+	})
+
+	for i := 1; i <= twoFactorAttempts; i++ {
+		if _, _, err := s.attempt("challenge", model.ChallengeVerify); err != nil {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	if n := len(fake.executed("SET used_at")); n != 0 {
+		t.Fatalf("challenge used after %d attempts", twoFactorAttempts)
+	}
+
+	if _, _, err := s.attempt("challenge", model.ChallengeVerify); !errors.Is(err, ErrChallengeAttempts) {
+		t.Fatalf("attempt %d: err = %v, want %v", twoFactorAttempts+1, err, ErrChallengeAttempts)
+	}
+	if n := len(fake.executed("SET used_at")); n != 1 {
+		t.Errorf("challenge used %d times after the limit, want 1", n)
+	}
+}
+
+func TestAttemptRejectsOtherPurpose(t *testing.T) {
+	s, fake := newTestTwoFactorService(t)
+	fake.on("FROM two_factor_challenges", func(args []driver.Value) fakeResult {
+		return fakeResult{rows: [][]driver.Value{{int64(7), model.ChallengeSetup, int64(0), time.Now().Add(time.Minute)}}}
+	})
+
+	if _, _, err := s.attempt("challenge", model.ChallengeVerify); !errors.Is(err, ErrInvalidChallenge) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidChallenge)
+	}
+	if n := len(fake.executed("SET attempts")); n != 0 {
+		t.Errorf("attempt counted for a challenge of another purpose")
+	}
+}
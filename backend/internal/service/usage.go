@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/pkg/llm"
+)
+
+// ErrQuotaExceeded - владелец израсходовал месячный лимит токенов своего тарифа
+var ErrQuotaExceeded = errors.New("исчерпан месячный лимит ИИ")
+
+// modelPrices - цена в долларах за миллион токенов запроса и ответа.
+// Сверяется по самому длинному совпадающему префиксу имени модели.
+var modelPrices = map[string][2]float64{
+	"gpt-3.5-turbo": {0.5, 1.5},
+	"gpt-4o-mini":   {0.15, 0.6},
+	"gpt-4o":        {2.5, 10},
+	"gpt-4.1-mini":  {0.4, 1.6},
+	"gpt-4.1":       {2, 8},
+	"gpt-4-turbo":   {10, 30},
+}
+
+type usageScopeKey struct{}
+
+// usageScope - чей это вызов модели и зачем
+type usageScope struct {
+	userID         uint
+	conversationID uint
+	purpose        model.UsagePurpose
+}
+
+// withUsage помечает контекст владельцем, чтобы вызовы модели учитывались в его расходе
+func withUsage(ctx context.Context, userID, conversationID uint, purpose model.UsagePurpose) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, usageScope{userID: userID, conversationID: conversationID, purpose: purpose})
+}
+
+// withUsagePurpose меняет назначение вызова, сохраняя владельца
+func withUsagePurpose(ctx context.Context, purpose model.UsagePurpose) context.Context {
+	scope, ok := ctx.Value(usageScopeKey{}).(usageScope)
+	if !ok {
+		return ctx
+	}
+	scope.purpose = purpose
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+type UsageService struct {
+	repo *postgres.UsageRepository
+}
+
+func NewUsageService(repo *postgres.UsageRepository) *UsageService {
+	return &UsageService{repo: repo}
+}
+
+// Meter оборачивает модель: вызовы с владельцем в контексте проверяются
+// по лимиту тарифа и записываются в учёт
+func (s *UsageService) Meter(inner llm.LLM) llm.LLM {
+	return &meteredLLM{inner: inner, usage: s}
+}
+
+// CheckQuota возвращает ErrQuotaExceeded, если лимит месяца исчерпан
+func (s *UsageService) CheckQuota(userID uint) error {
+	plan, err := s.repo.GetPlan(userID)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.repo.TokensSince(userID, monthStart(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	if used >= planQuota(plan) {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Report собирает расход за период с fromStr по toStr включительно (даты YYYY-MM-DD).
+// Без дат отчёт строится за текущий месяц.
+func (s *UsageService) Report(userID uint, fromStr, toStr string) (*model.UsageReport, error) {
+	from, to := monthStart(time.Now()), time.Now().UTC()
+	if fromStr != "" {
+		parsed, err := time.Parse(dateLayout, fromStr)
+		if err != nil {
+			return nil, errors.New("некорректная дата начала периода")
+		}
+		from = parsed
+	}
+	if toStr != "" {
+		parsed, err := time.Parse(dateLayout, toStr)
+		if err != nil {
+			return nil, errors.New("некорректная дата окончания периода")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		return nil, errors.New("конец периода должен быть позже начала")
+	}
+
+	plan, err := s.repo.GetPlan(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	monthUsed, err := s.repo.TokensSince(userID, monthStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.UsageReport{
+		From:         from,
+		To:           to,
+		Plan:         plan,
+		MonthlyQuota: planQuota(plan),
+		MonthUsed:    monthUsed,
+	}
+
+	groups := map[string]*[]model.UsageBreakdown{
+		"model":   &report.ByModel,
+		"purpose": &report.ByPurpose,
+		"day":     &report.Daily,
+	}
+	for groupBy, target := range groups {
+		rows, err := s.repo.Breakdown(userID, from, to, groupBy)
+		if err != nil {
+			return nil, err
+		}
+		*target = mergeUsage(rows)
+	}
+
+	for _, item := range report.ByModel {
+		report.Calls += item.Calls
+		report.Failed += item.Failed
+		report.PromptTokens += item.PromptTokens
+		report.CompletionTokens += item.CompletionTokens
+		report.EstimatedCostUSD += item.EstimatedCostUSD
+	}
+
+	return report, nil
+}
+
+func (s *UsageService) record(scope usageScope, req llm.Request, resp *llm.Response, latency time.Duration, callErr error) {
+	usage := &model.LLMUsage{
+		UserID:    scope.userID,
+		Purpose:   scope.purpose,
+		Model:     req.Model,
+		LatencyMs: int(latency.Milliseconds()),
+	}
+	if scope.conversationID != 0 {
+		usage.ConversationID = &scope.conversationID
+	}
+	if resp != nil {
+		if resp.Model != "" {
+			usage.Model = resp.Model
+		}
+		usage.PromptTokens = resp.Usage.PromptTokens
+		usage.CompletionTokens = resp.Usage.CompletionTokens
+	}
+	if callErr != nil {
+		usage.Error = callErr.Error()
+	}
+
+	if err := s.repo.Create(usage); err != nil {
+		log.Printf("Error recording LLM usage for user %d: %v", scope.userID, err)
+	}
+}
+
+type meteredLLM struct {
+	inner llm.LLM
+	usage *UsageService
+}
+
+func (m *meteredLLM) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	scope, ok := ctx.Value(usageScopeKey{}).(usageScope)
+	if !ok {
+		return m.inner.Complete(ctx, req)
+	}
+
+	if err := m.usage.CheckQuota(scope.userID); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	resp, err := m.inner.Complete(ctx, req)
+	m.usage.record(scope, req, resp, time.Since(started), err)
+	return resp, err
+}
+
+// mergeUsage сводит строки по модели в одну на ключ и считает стоимость
+func mergeUsage(rows []model.UsageBreakdown) []model.UsageBreakdown {
+	merged := make(map[string]*model.UsageBreakdown)
+	var keys []string
+	for _, row := range rows {
+		item, ok := merged[row.Key]
+		if !ok {
+			item = &model.UsageBreakdown{Key: row.Key}
+			merged[row.Key] = item
+			keys = append(keys, row.Key)
+		}
+
+		// Средняя задержка взвешивается числом вызовов
+		totalLatency := item.AvgLatencyMs*item.Calls + row.AvgLatencyMs*row.Calls
+		item.Calls += row.Calls
+		item.Failed += row.Failed
+		item.PromptTokens += row.PromptTokens
+		item.CompletionTokens += row.CompletionTokens
+		item.EstimatedCostUSD += estimateCost(row.Model, row.PromptTokens, row.CompletionTokens)
+		if item.Calls > 0 {
+			item.AvgLatencyMs = totalLatency / item.Calls
+		}
+	}
+
+	sort.Strings(keys)
+	result := make([]model.UsageBreakdown, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *merged[key])
+	}
+	return result
+}
+
+// estimateCost оценивает стоимость по прайсу модели, неизвестные модели считаются бесплатными
+func estimateCost(modelName string, promptTokens, completionTokens int) float64 {
+	var price [2]float64
+	matched := ""
+	for prefix, p := range modelPrices {
+		if strings.HasPrefix(modelName, prefix) && len(prefix) > len(matched) {
+			price, matched = p, prefix
+		}
+	}
+	return (float64(promptTokens)*price[0] + float64(completionTokens)*price[1]) / 1e6
+}
+
+func planQuota(plan model.Plan) int {
+	if quota, ok := model.PlanTokenQuotas[plan]; ok {
+		return quota
+	}
+	return model.PlanTokenQuotas[model.PlanFree]
+}
+
+// monthStart - начало календарного месяца по UTC, с него отсчитывается лимит
+func monthStart(now time.Time) time.Time {
+	y, m, _ := now.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
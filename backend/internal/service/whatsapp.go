@@ -1,93 +1,691 @@
 package service
 
 import (
-    "fmt"
-    "sync"
-    "log"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
 
-    "github.com/yourusername/uilet/internal/whatsapp"
-    "github.com/yourusername/uilet/pkg/ai"
-    "github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/internal/model"
+	"github.com/yourusername/uilet/internal/repository/postgres"
+	"github.com/yourusername/uilet/internal/whatsapp"
+	"github.com/yourusername/uilet/pkg/llm"
 )
 
 type WhatsAppService struct {
-    client    *whatsapp.Client
-    userRepo  *postgres.UserRepository
-    aiClient  *ai.Client
-    aiConfigs map[uint]AIConfig
-    mu        sync.RWMutex
+	clients       map[uint]*whatsapp.Client
+	userRepo      *postgres.UserRepository
+	aiRepo        *postgres.AIConfigRepository
+	conversations *ConversationService
+	listings      *ListingContextBuilder
+	agent         *AgentService
+	templates     *TemplateService
+	media         *MediaService
+	outbox        *OutboxService
+	consents      *ConsentService
+	mu            sync.RWMutex
 }
 
-type AIConfig struct {
-    Prompt      string  `json:"prompt"`
-    Temperature float32 `json:"temperature"`
-    MaxTokens   int     `json:"max_tokens"`
+func NewWhatsAppService(userRepo *postgres.UserRepository, aiRepo *postgres.AIConfigRepository, conversations *ConversationService, listings *ListingContextBuilder, agent *AgentService, templates *TemplateService, media *MediaService, outbox *OutboxService, consents *ConsentService) *WhatsAppService {
+	return &WhatsAppService{
+		clients:       make(map[uint]*whatsapp.Client),
+		userRepo:      userRepo,
+		aiRepo:        aiRepo,
+		conversations: conversations,
+		listings:      listings,
+		agent:         agent,
+		templates:     templates,
+		media:         media,
+		outbox:        outbox,
+		consents:      consents,
+	}
 }
 
-func NewWhatsAppService(userRepo *postgres.UserRepository, aiClient *ai.Client) *WhatsAppService {
-    service := &WhatsAppService{
-        client:    whatsapp.NewClient(),
-        userRepo:  userRepo,
-        aiClient:  aiClient,
-        aiConfigs: make(map[uint]AIConfig),
-    }
+// handleAIMessage сохраняет сообщение гостя и отвечает от имени владельца
+// с его настройками ИИ и историей переписки
+func (s *WhatsAppService) handleAIMessage(userID uint, guestPhone, message string) error {
+	if erased, err := s.accountErased(userID); err != nil || erased {
+		return err
+	}
 
-    service.client.SetMessageHandler(service.handleAIMessage)
-    return service
+	conv, err := s.conversations.Record(userID, guestPhone, model.RoleGuest, message)
+	if err != nil {
+		return err
+	}
+
+	// STOP и START подтверждаем сразу: это ответ на команду гостя, а не рассылка
+	confirmation, err := s.consents.HandleGuestMessage(userID, guestPhone, message)
+	if err != nil {
+		return err
+	}
+	if confirmation != "" {
+		return s.confirmConsent(conv, confirmation)
+	}
+
+	return s.answer(conv, message)
+}
+
+// handleMediaMessage сохраняет фото, голосовое или геолокацию гостя
+// и отвечает на их текстовое описание, например на расшифровку голосового
+func (s *WhatsAppService) handleMediaMessage(userID uint, guestPhone string, media whatsapp.Media) error {
+	if erased, err := s.accountErased(userID); err != nil || erased {
+		return err
+	}
+
+	conv, err := s.conversations.GetOrCreate(userID, guestPhone)
+	if err != nil {
+		return err
+	}
+
+	content, words, err := s.media.Save(context.Background(), conv, media)
+	if err != nil {
+		return err
+	}
+
+	// STOP в подписи к фото или в голосовом работает так же, как текстом
+	if words == "" {
+		words = content
+	}
+	confirmation, err := s.consents.HandleGuestMessage(userID, guestPhone, words)
+	if err != nil {
+		return err
+	}
+	if confirmation != "" {
+		return s.confirmConsent(conv, confirmation)
+	}
+
+	return s.answer(conv, content)
+}
+
+// confirmConsent отвечает гостю на STOP или START мимо проверки согласия
+func (s *WhatsAppService) confirmConsent(conv *model.Conversation, confirmation string) error {
+	if _, err := s.reply(conv, confirmation); err != nil {
+		return err
+	}
+	_, err := s.outbox.Enqueue(&model.OutboundMessage{UserID: conv.UserID, GuestPhone: conv.GuestPhone, Body: confirmation})
+	return err
+}
+
+// answer ставит ответ ассистента в очередь отправки.
+// Пустой ответ означает, что отвечать не нужно. Отписавшимся гостям бот не отвечает.
+func (s *WhatsAppService) answer(conv *model.Conversation, message string) error {
+	allowed, err := s.consents.Allowed(conv.UserID, conv.GuestPhone)
+	if err != nil || !allowed {
+		return err
+	}
+
+	response, err := s.respond(conv, message)
+	if err != nil || response == "" {
+		return err
+	}
+	return s.SendMessage(conv.UserID, conv.GuestPhone, response)
+}
+
+// respond формирует ответ ассистента на уже сохранённое сообщение гостя
+func (s *WhatsAppService) respond(conv *model.Conversation, message string) (string, error) {
+	userID, guestPhone := conv.UserID, conv.GuestPhone
+
+	// Владелец перехватил чат или бот на паузе
+	if !conv.BotActive(time.Now()) {
+		return "", nil
+	}
+
+	config, err := s.GetAIConfig(userID)
+	if err != nil {
+		return "", err
+	}
+
+	if !config.Enabled || !withinBusinessHours(config.BusinessHours, time.Now()) {
+		return "", nil
+	}
+
+	if reason := escalationReason(message); reason != "" {
+		return s.handOff(conv, reason)
+	}
+
+	history, err := s.conversations.History(conv)
+	if err != nil {
+		return "", err
+	}
+
+	// Попытки переписать инструкцию вырезаем из истории, а о свежей сообщаем владельцу
+	if _, flagged := screenGuestInput(message); flagged {
+		log.Printf("Prompt injection attempt in conversation %d", conv.ID)
+		s.conversations.ReportGuardrail(conv, "Подозрительное сообщение гостя",
+			"гость пытался изменить инструкцию ассистента, фрагмент вырезан")
+	}
+	screenHistory(history)
+	facts := s.replyFacts(userID, history)
+
+	systemPrompt, err := s.systemPrompt(config, recentGuestText(history))
+	if err != nil {
+		return "", err
+	}
+
+	ctx := withUsage(context.Background(), userID, conv.ID, model.UsageReply)
+	systemPrompt = s.conversations.SystemPrompt(systemPrompt, conv)
+	var escalation string
+	session := agentSession{
+		userID:       userID,
+		conversation: conv,
+		send: func(text string) error {
+			return s.SendMessage(userID, guestPhone, text)
+		},
+		sendImage: func(data []byte, mimeType, caption string) error {
+			if err := s.SendImage(userID, guestPhone, data, mimeType, caption); err != nil {
+				return err
+			}
+			return s.conversations.AddMediaMessage(conv, model.RoleAssistant, model.KindImage, strings.TrimSpace("[Фото] "+caption), nil)
+		},
+		sendLocation: func(latitude, longitude float64, name, address string) error {
+			if err := s.SendLocation(userID, guestPhone, latitude, longitude, name, address); err != nil {
+				return err
+			}
+			content := strings.TrimSpace(fmt.Sprintf("[Геолокация %.6f, %.6f] %s", latitude, longitude, address))
+			return s.conversations.AddMediaMessage(conv, model.RoleAssistant, model.KindLocation, content, nil)
+		},
+		escalate: func(reason string) {
+			escalation = reason
+		},
+		facts: facts,
+	}
+	response, err := s.complete(ctx, config, systemPrompt, history, session)
+	if errors.Is(err, ErrQuotaExceeded) {
+		return s.handOff(conv, ErrQuotaExceeded.Error())
+	}
+	if err != nil {
+		if failErr := s.conversations.RecordFailure(conv); failErr != nil {
+			log.Printf("Error recording AI failure for conversation %d: %v", conv.ID, failErr)
+		}
+		if conv.Mode == model.ModeHuman {
+			return s.reply(conv, handoffReply)
+		}
+		return "", err
+	}
+
+	if err := s.conversations.ResetFailures(conv); err != nil {
+		log.Printf("Error resetting failures for conversation %d: %v", conv.ID, err)
+	}
+
+	response, violation := checkReply(response, facts)
+	if violation != "" {
+		log.Printf("Blocked AI reply in conversation %d: %s", conv.ID, violation)
+		s.conversations.ReportGuardrail(conv, "Ответ ассистента заблокирован",
+			fmt.Sprintf("%s. Вместо ответа «%s» гость получил стандартное сообщение", violation, response))
+		return s.reply(conv, guardrailReply)
+	}
+
+	if escalation == "" && isLowConfidence(response) {
+		escalation = "ассистент не уверен в ответе"
+	}
+	if escalation != "" {
+		if err := s.conversations.Escalate(conv, escalation); err != nil {
+			log.Printf("Error escalating conversation %d: %v", conv.ID, err)
+		}
+	}
+
+	if _, err := s.reply(conv, response); err != nil {
+		return "", err
+	}
+
+	// Ошибка сжатия истории не должна мешать ответу гостю
+	if err := s.conversations.Summarize(ctx, conv); err != nil {
+		log.Printf("Error summarizing conversation %d: %v", conv.ID, err)
+	}
+
+	return response, nil
+}
+
+// handOff передаёт переписку владельцу и сообщает об этом гостю
+func (s *WhatsAppService) handOff(conv *model.Conversation, reason string) (string, error) {
+	if err := s.conversations.Escalate(conv, reason); err != nil {
+		return "", err
+	}
+	return s.reply(conv, handoffReply)
+}
+
+// reply сохраняет ответ ассистента в переписке и возвращает его для отправки
+func (s *WhatsAppService) reply(conv *model.Conversation, text string) (string, error) {
+	if err := s.conversations.AddMessage(conv, model.RoleAssistant, text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// handleOwnerMessage сохраняет сообщение, которое владелец написал гостю с телефона,
+// и ставит бота в этом чате на паузу
+func (s *WhatsAppService) handleOwnerMessage(userID uint, guestPhone, text string) {
+	conv, err := s.conversations.Record(userID, guestPhone, model.RoleOwner, text)
+	if err != nil {
+		log.Printf("Error saving owner message: %v", err)
+		return
+	}
+
+	if err := s.conversations.PauseForOwner(conv); err != nil {
+		log.Printf("Error pausing conversation %d: %v", conv.ID, err)
+	}
 }
 
-func (s *WhatsAppService) handleAIMessage(message string) (string, error) {
-    defaultConfig := AIConfig{
-        Prompt:      "Вы - помощник по аренде недвижимости. Отвечайте кратко и по делу на русском языке.",
-        Temperature: 0.7,
-        MaxTokens:   150,
-    }
+// ReplyAsOwner отправляет гостю ответ владельца из личного кабинета.
+// Шаблон заполняется по последней заявке гостя.
+// Бот в этой переписке перестаёт отвечать, пока владелец его не вернёт.
+// Повтор запроса с тем же ключом идемпотентности ничего не отправляет и возвращает nil.
+func (s *WhatsAppService) ReplyAsOwner(userID uint, conversationID string, input model.OwnerReplyInput) (*model.ConversationMessage, error) {
+	conv, err := s.conversations.Get(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
 
-    response, err := s.aiClient.CreateChatCompletion(defaultConfig.Prompt, message)
-    if err != nil {
-        log.Printf("AI error details: %v", err)
-        return "", fmt.Errorf("Ошибка ИИ: %v", err)
-    }
+	text := strings.TrimSpace(input.Text)
+	if input.TemplateID != nil {
+		text, err = s.templates.RenderForConversation(conv, *input.TemplateID, input.Language)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if text == "" {
+		return nil, errors.New("пустое сообщение")
+	}
 
-    return response, nil
+	outbound := &model.OutboundMessage{
+		UserID:     userID,
+		GuestPhone: conv.GuestPhone,
+		Body:       text,
+	}
+	if key := strings.TrimSpace(input.IdempotencyKey); key != "" {
+		outbound.IdempotencyKey = "owner:" + key
+	}
+	created, err := s.outbox.Enqueue(outbound)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, nil
+	}
+
+	message, err := s.conversations.AddOwnerMessage(conv, text)
+	if err != nil {
+		return nil, err
+	}
+
+	if conv.Mode != model.ModeHuman {
+		if _, err := s.conversations.SetMode(userID, conversationID, model.ModeHuman); err != nil {
+			return nil, err
+		}
+	}
+
+	return message, nil
+}
+
+func (s *WhatsAppService) complete(ctx context.Context, config *model.AIConfig, systemPrompt string, history []llm.Message, session agentSession) (string, error) {
+	response, err := s.agent.Run(ctx, llm.Request{
+		Model:       config.Model,
+		Messages:    llm.Conversation(systemPrompt, history...),
+		Temperature: llm.Temperature(config.Temperature),
+		MaxTokens:   config.MaxTokens,
+	}, session)
+	if errors.Is(err, ErrQuotaExceeded) {
+		return "", err
+	}
+	if err != nil {
+		log.Printf("AI error details: %v", err)
+		return "", fmt.Errorf("Ошибка ИИ: %v", err)
+	}
+
+	return response.Content, nil
 }
 
-func (s *WhatsAppService) ConfigureAI(userID uint, config AIConfig) error {
-    s.mu.Lock()
-    defer s.mu.Unlock()
+func (s *WhatsAppService) GetAIConfig(userID uint) (*model.AIConfig, error) {
+	config, err := s.aiRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ai config: %v", err)
+	}
+	if config == nil {
+		return model.DefaultAIConfig(userID), nil
+	}
+	return config, nil
+}
+
+// ConfigureAI обновляет только переданные поля настроек, остальные остаются как были
+func (s *WhatsAppService) ConfigureAI(userID uint, input model.UpdateAIConfigInput) (*model.AIConfig, error) {
+	if input.BusinessHours != nil {
+		if err := validateBusinessHours(*input.BusinessHours); err != nil {
+			return nil, err
+		}
+	}
+	if input.QuietHours != nil {
+		if err := validateQuietHours(*input.QuietHours); err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := s.GetAIConfig(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Prompt != nil {
+		config.Prompt = strings.TrimSpace(*input.Prompt)
+	}
+	if input.Tone != nil {
+		config.Tone = strings.TrimSpace(*input.Tone)
+	}
+	if input.Language != nil {
+		config.Language = *input.Language
+	}
+	if input.Temperature != nil {
+		config.Temperature = *input.Temperature
+	}
+	if input.MaxTokens != nil {
+		config.MaxTokens = *input.MaxTokens
+	}
+	if input.Model != nil {
+		config.Model = strings.TrimSpace(*input.Model)
+	}
+	if input.BusinessHours != nil {
+		config.BusinessHours = *input.BusinessHours
+	}
+	if input.QuietHours != nil {
+		config.QuietHours = *input.QuietHours
+	}
+	if input.Enabled != nil {
+		config.Enabled = *input.Enabled
+	}
+
+	if config.Prompt == "" {
+		config.Prompt = model.DefaultAIPrompt
+	}
+	if config.Language == "" {
+		config.Language = model.DefaultAILanguage
+	}
+	if config.MaxTokens == 0 {
+		config.MaxTokens = model.DefaultAIMaxTokens
+	}
+	if config.BusinessHours.Timezone == "" {
+		config.BusinessHours.Timezone = model.DefaultTimezone
+	}
+
+	now := time.Now()
+	if config.CreatedAt.IsZero() {
+		config.CreatedAt = now
+	}
+	config.UpdatedAt = now
 
-    s.aiConfigs[userID] = config
-    return nil
+	if err := s.aiRepo.Upsert(config); err != nil {
+		return nil, fmt.Errorf("failed to save ai config: %v", err)
+	}
+
+	return config, nil
 }
 
-func (s *WhatsAppService) TestAI(message string) (string, error) {
-    defaultConfig := AIConfig{
-        Prompt:      "Вы - помощник по аренде недвижимости. Отвечайте кратко и по делу на русском языке.",
-        Temperature: 0.7,
-        MaxTokens:   150,
-    }
+// TestAI отвечает на сообщение с настройками владельца, не учитывая часы работы.
+// Инструменты агента работают в тестовом режиме и не создают заявок.
+func (s *WhatsAppService) TestAI(ctx context.Context, userID uint, message string) (string, error) {
+	config, err := s.GetAIConfig(userID)
+	if err != nil {
+		return "", err
+	}
 
-    log.Printf("Sending message to AI: %s", message)
-    response, err := s.aiClient.CreateChatCompletion(defaultConfig.Prompt, message)
-    if err != nil {
-        log.Printf("AI error details: %v", err)
-        return "", fmt.Errorf("Ошибка ИИ: %v", err)
-    }
+	systemPrompt, err := s.systemPrompt(config, message)
+	if err != nil {
+		return "", err
+	}
 
-    log.Printf("Received AI response: %s", response)
-    return response, nil
+	session := agentSession{userID: userID, dryRun: true}
+	ctx = withUsage(ctx, userID, 0, model.UsageTest)
+	return s.complete(ctx, config, systemPrompt, []llm.Message{{Role: llm.RoleUser, Content: message}}, session)
 }
 
 func (s *WhatsAppService) InitiateLogin(userID uint) (string, error) {
-    err := s.client.Connect()
-    if err != nil {
-        return "", fmt.Errorf("failed to connect: %v", err)
-    }
-
-    qr, err := s.client.Login()
-    if err != nil {
-        return "", fmt.Errorf("failed to get QR code: %v", err)
-    }
-
-    return qr, nil
-} 
\ No newline at end of file
+	client := whatsapp.NewClient()
+	client.SetMessageHandler(func(sender, message string) error {
+		return s.handleAIMessage(userID, sender, message)
+	})
+	client.SetMediaHandler(func(sender string, media whatsapp.Media) error {
+		return s.handleMediaMessage(userID, sender, media)
+	})
+	client.SetReceiptHandler(func(messageID string, status whatsapp.ReceiptStatus) {
+		s.outbox.HandleReceipt(userID, messageID, status)
+	})
+	client.SetOwnerMessageHandler(func(recipient, message string) {
+		s.handleOwnerMessage(userID, recipient, message)
+	})
+
+	err := client.Connect()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect: %v", err)
+	}
+
+	qr, err := client.Login()
+	if err != nil {
+		return "", fmt.Errorf("failed to get QR code: %v", err)
+	}
+
+	s.mu.Lock()
+	s.clients[userID] = client
+	s.mu.Unlock()
+
+	return qr, nil
+}
+
+// Disconnect отключает WhatsApp владельца, например когда его аккаунт удалён
+func (s *WhatsAppService) Disconnect(userID uint) {
+	s.mu.Lock()
+	client, ok := s.clients[userID]
+	delete(s.clients, userID)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := client.Disconnect(); err != nil {
+		log.Printf("Failed to disconnect WhatsApp of user %d: %v", userID, err)
+	}
+}
+
+// accountErased сообщает, что аккаунт владельца уже удалён: сообщения гостей,
+// пришедшие до отключения клиента, не сохраняются и остаются без ответа
+func (s *WhatsAppService) accountErased(userID uint) (bool, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.Deleted, nil
+}
+
+// SendMessage ставит автоматическое сообщение гостю в очередь отправки
+// через WhatsApp владельца с учётом согласия и тихих часов
+func (s *WhatsAppService) SendMessage(userID uint, phone, text string) error {
+	return s.enqueueAutomated(&model.OutboundMessage{
+		UserID:     userID,
+		GuestPhone: phone,
+		Kind:       model.KindText,
+		Body:       text,
+	})
+}
+
+func (s *WhatsAppService) SendImage(userID uint, phone string, data []byte, mimeType, caption string) error {
+	return s.enqueueAutomated(&model.OutboundMessage{
+		UserID:     userID,
+		GuestPhone: phone,
+		Kind:       model.KindImage,
+		Body:       caption,
+		Data:       data,
+		MimeType:   mimeType,
+	})
+}
+
+func (s *WhatsAppService) SendLocation(userID uint, phone string, latitude, longitude float64, name, address string) error {
+	return s.enqueueAutomated(&model.OutboundMessage{
+		UserID:       userID,
+		GuestPhone:   phone,
+		Kind:         model.KindLocation,
+		Body:         address,
+		Latitude:     &latitude,
+		Longitude:    &longitude,
+		LocationName: name,
+	})
+}
+
+// enqueueAutomated ставит сообщение в очередь, если гость не отписался.
+// В тихие часы гостя отправка откладывается.
+func (s *WhatsAppService) enqueueAutomated(msg *model.OutboundMessage) error {
+	ok, err := s.consents.Prepare(msg)
+	if err != nil || !ok {
+		return err
+	}
+	_, err = s.outbox.Enqueue(msg)
+	return err
+}
+
+// Deliver отправляет сообщение из очереди, реализует OutboundSender
+func (s *WhatsAppService) Deliver(msg model.OutboundMessage) (string, error) {
+	client, err := s.client(msg.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	switch msg.Kind {
+	case model.KindImage:
+		return client.SendImage(msg.GuestPhone, msg.Data, msg.MimeType, msg.Body)
+	case model.KindLocation:
+		if msg.Latitude == nil || msg.Longitude == nil {
+			return "", errors.New("нет координат точки")
+		}
+		return client.SendLocation(msg.GuestPhone, *msg.Latitude, *msg.Longitude, msg.LocationName, msg.Body)
+	default:
+		return client.SendMessage(msg.GuestPhone, msg.Body)
+	}
+}
+
+func (s *WhatsAppService) client(userID uint) (*whatsapp.Client, error) {
+	s.mu.RLock()
+	client, ok := s.clients[userID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, errors.New("WhatsApp не подключён")
+	}
+	return client, nil
+}
+
+// systemPrompt дополняет инструкцию владельца данными о его объектах,
+// подходящими под запрос гостя
+func (s *WhatsAppService) systemPrompt(config *model.AIConfig, query string) (string, error) {
+	listings, err := s.listings.Build(config.UserID, query)
+	if err != nil {
+		return "", err
+	}
+	return assistantPrompt(config, listings, time.Now()), nil
+}
+
+// assistantPrompt собирает полную системную инструкцию агента на дату now
+func assistantPrompt(config *model.AIConfig, listings string, now time.Time) string {
+	tools := fmt.Sprintf("Сегодня %s. Проверяйте свободные даты и стоимость через инструменты, "+
+		"а бронирование оформляйте только через create_booking_request.", now.Format(dateLayout))
+	return buildSystemPrompt(config) + "\n\n" + listings + "\n" + tools + "\n" + guardrailInstruction
+}
+
+// replyFacts собирает цены квартир и факты из прошлых ответов ассистента,
+// с которыми сверяется новый ответ
+func (s *WhatsAppService) replyFacts(userID uint, history []llm.Message) *replyFacts {
+	facts := newReplyFacts(time.Now())
+
+	apartments, err := s.listings.Apartments(userID)
+	if err != nil {
+		log.Printf("Error loading apartments for reply check: %v", err)
+	}
+	for _, apt := range apartments {
+		facts.addAmount(apt.Price)
+	}
+
+	for _, msg := range history {
+		if msg.Role == llm.RoleAssistant {
+			facts.addText(msg.Content)
+		}
+	}
+	return facts
+}
+
+// recentGuestText склеивает последние сообщения гостя для подбора объектов
+func recentGuestText(history []llm.Message) string {
+	var parts []string
+	for i := len(history) - 1; i >= 0 && len(parts) < 3; i-- {
+		if history[i].Role == llm.RoleUser {
+			parts = append(parts, history[i].Content)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+var languageNames = map[string]string{
+	"ru": "русском",
+	"kk": "казахском",
+	"en": "английском",
+}
+
+func buildSystemPrompt(config *model.AIConfig) string {
+	var b strings.Builder
+	b.WriteString(config.Prompt)
+
+	if config.Tone != "" {
+		fmt.Fprintf(&b, "\nТон общения: %s.", config.Tone)
+	}
+	if name, ok := languageNames[config.Language]; ok {
+		fmt.Fprintf(&b, "\nОтвечайте на %s языке.", name)
+	}
+
+	return b.String()
+}
+
+func validateBusinessHours(hours model.BusinessHours) error {
+	if (hours.Start == "") != (hours.End == "") {
+		return errors.New("нужно указать и начало, и конец рабочего времени")
+	}
+	if hours.Start != "" {
+		if _, err := time.Parse("15:04", hours.Start); err != nil {
+			return errors.New("некорректное время начала работы")
+		}
+		if _, err := time.Parse("15:04", hours.End); err != nil {
+			return errors.New("некорректное время окончания работы")
+		}
+	}
+	if hours.Timezone != "" {
+		if _, err := time.LoadLocation(hours.Timezone); err != nil {
+			return errors.New("неизвестный часовой пояс")
+		}
+	}
+	return nil
+}
+
+// withinBusinessHours проверяет, попадает ли момент now в рабочее время.
+// Интервал может переходить через полночь, например 20:00-08:00.
+func withinBusinessHours(hours model.BusinessHours, now time.Time) bool {
+	if hours.Start == "" || hours.End == "" {
+		return true
+	}
+
+	start, err := time.Parse("15:04", hours.Start)
+	if err != nil {
+		return true
+	}
+	end, err := time.Parse("15:04", hours.End)
+	if err != nil {
+		return true
+	}
+
+	if loc, err := time.LoadLocation(hours.Timezone); err == nil {
+		now = now.In(loc)
+	}
+
+	minutes := now.Hour()*60 + now.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from <= to {
+		return minutes >= from && minutes < to
+	}
+	return minutes >= from || minutes < to
+}
@@ -1,8 +1,12 @@
 package whatsapp
 
 import (
+	"bytes"
+	"crypto/rand"
 	_ "encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,13 +19,17 @@ type Client struct {
 	handler   *MessageHandler
 	qrChannel chan string
 	connected bool
-	mu        sync.RWMutex
+	// sentIDs - ID сообщений, отправленных через API, чтобы отличать их
+	// от сообщений, которые владелец пишет с телефона
+	sentIDs map[string]struct{}
+	mu      sync.RWMutex
 }
 
 func NewClient() *Client {
 	return &Client{
 		qrChannel: make(chan string),
 		handler:   newMessageHandler(),
+		sentIDs:   make(map[string]struct{}),
 	}
 }
 
@@ -59,20 +67,95 @@ func (c *Client) Login() (string, error) {
 	return <-qr, nil
 }
 
-func (c *Client) SendMessage(phone string, message string) error {
+// SendMessage отправляет текст и возвращает ID сообщения в WhatsApp
+func (c *Client) SendMessage(phone string, message string) (string, error) {
+	return c.send(phone, func(info whatsapp.MessageInfo) interface{} {
+		return whatsapp.TextMessage{Info: info, Text: message}
+	})
+}
+
+// SendImage отправляет изображение с подписью
+func (c *Client) SendImage(phone string, data []byte, mimeType, caption string) (string, error) {
+	return c.send(phone, func(info whatsapp.MessageInfo) interface{} {
+		return whatsapp.ImageMessage{
+			Info:    info,
+			Type:    mimeType,
+			Caption: caption,
+			Content: bytes.NewReader(data),
+		}
+	})
+}
+
+// SendLocation отправляет точку на карте
+func (c *Client) SendLocation(phone string, latitude, longitude float64, name, address string) (string, error) {
+	return c.send(phone, func(info whatsapp.MessageInfo) interface{} {
+		return whatsapp.LocationMessage{
+			Info:             info,
+			DegreesLatitude:  latitude,
+			DegreesLongitude: longitude,
+			Name:             name,
+			Address:          address,
+		}
+	})
+}
+
+func (c *Client) send(phone string, build func(info whatsapp.MessageInfo) interface{}) (string, error) {
 	if !c.IsConnected() {
-		return fmt.Errorf("not connected to WhatsApp")
+		return "", fmt.Errorf("not connected to WhatsApp")
 	}
 
-	msg := whatsapp.TextMessage{
-		Info: whatsapp.MessageInfo{
-			RemoteJid: phone + "@s.whatsapp.net",
-		},
-		Text: message,
+	// ID задаём сами и запоминаем до отправки: эхо сообщения может прийти раньше, чем вернётся Send
+	id := newMessageID()
+	c.mu.Lock()
+	c.sentIDs[id] = struct{}{}
+	c.mu.Unlock()
+
+	info := whatsapp.MessageInfo{
+		Id:        id,
+		RemoteJid: phone + "@s.whatsapp.net",
+	}
+
+	if _, err := c.conn.Send(build(info)); err != nil {
+		c.mu.Lock()
+		delete(c.sentIDs, id)
+		c.mu.Unlock()
+		return "", err
+	}
+
+	return id, nil
+}
+
+func newMessageID() string {
+	b := make([]byte, 10)
+	rand.Read(b)
+	return "3EB0" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+// sentByAPI проверяет, было ли сообщение отправлено через SendMessage
+func (c *Client) sentByAPI(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.sentIDs[id]; ok {
+		delete(c.sentIDs, id)
+		return true
 	}
+	return false
+}
+
+// Disconnect закрывает соединение с WhatsApp, после него сообщения не принимаются
+func (c *Client) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	_, err := c.conn.Send(msg)
-	return err
+	c.connected = false
+	if c.conn == nil {
+		return nil
+	}
+	if _, err := c.conn.Disconnect(); err != nil {
+		return fmt.Errorf("error disconnecting: %v", err)
+	}
+	return nil
 }
 
 func (c *Client) IsConnected() bool {
@@ -81,6 +164,23 @@ func (c *Client) IsConnected() bool {
 	return c.connected
 }
 
-func (c *Client) SetMessageHandler(handler func(string) (string, error)) {
+func (c *Client) SetMessageHandler(handler func(sender, text string) error) {
 	c.handler.SetAIHandler(handler)
 }
+
+// SetMediaHandler задаёт обработчик входящих фото, голосовых сообщений и геолокации
+func (c *Client) SetMediaHandler(handler func(sender string, media Media) error) {
+	c.handler.SetMediaHandler(handler)
+}
+
+// SetOwnerMessageHandler задаёт обработчик сообщений, которые владелец
+// отправил гостю со своего телефона
+func (c *Client) SetOwnerMessageHandler(handler func(recipient, text string)) {
+	c.handler.SetOwnerHandler(handler)
+}
+
+// SetReceiptHandler задаёт обработчик квитанций о доставке и прочтении
+// отправленных сообщений
+func (c *Client) SetReceiptHandler(handler func(messageID string, status ReceiptStatus)) {
+	c.handler.SetReceiptHandler(handler)
+}
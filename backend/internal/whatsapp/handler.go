@@ -8,52 +8,59 @@ import (
 )
 
 type MessageHandler struct {
-	client    *Client
-	aiHandler func(string) (string, error)
+	client         *Client
+	aiHandler      func(sender, text string) error
+	ownerHandler   func(recipient, text string)
+	mediaHandler   func(sender string, media Media) error
+	receiptHandler func(messageID string, status ReceiptStatus)
 }
 
 func newMessageHandler() *MessageHandler {
-	return &MessageHandler{
-		aiHandler: func(msg string) (string, error) {
-			return "Default response", nil // Дефолтный обработчик
-		},
-	}
+	return &MessageHandler{}
 }
 
 func (h *MessageHandler) SetClient(c *Client) {
 	h.client = c
 }
 
-func (h *MessageHandler) SetAIHandler(handler func(string) (string, error)) {
+func (h *MessageHandler) SetAIHandler(handler func(sender, text string) error) {
 	h.aiHandler = handler
 }
 
+func (h *MessageHandler) SetOwnerHandler(handler func(recipient, text string)) {
+	h.ownerHandler = handler
+}
+
+func (h *MessageHandler) SetMediaHandler(handler func(sender string, media Media) error) {
+	h.mediaHandler = handler
+}
+
+func (h *MessageHandler) SetReceiptHandler(handler func(messageID string, status ReceiptStatus)) {
+	h.receiptHandler = handler
+}
+
 func (h *MessageHandler) HandleError(err error) {
 	fmt.Printf("Error occurred: %v\n", err)
 }
 
 func (h *MessageHandler) HandleTextMessage(message whatsapp.TextMessage) {
-	// Игнорируем собственные сообщения
+	// Получаем текст сообщения и номер собеседника
+	text := message.Text
+	sender := strings.Split(message.Info.RemoteJid, "@")[0]
+
+	// Собственные сообщения: ответы бота пропускаем,
+	// а написанные владельцем с телефона передаём отдельному обработчику
 	if message.Info.FromMe {
+		if h.ownerHandler != nil && !h.client.sentByAPI(message.Info.Id) {
+			h.ownerHandler(sender, text)
+		}
 		return
 	}
 
-	// Получаем текст сообщения
-	text := message.Text
-
-	// Обрабатываем сообщение через AI
+	// Обрабатываем сообщение через AI. Ответ уходит через очередь отправки сервиса.
 	if h.aiHandler != nil {
-		response, err := h.aiHandler(text)
-		if err != nil {
+		if err := h.aiHandler(sender, text); err != nil {
 			fmt.Printf("Error processing message with AI: %v\n", err)
-			return
-		}
-
-		// Отправляем ответ
-		sender := strings.Split(message.Info.RemoteJid, "@")[0]
-		err = h.client.SendMessage(sender, response)
-		if err != nil {
-			fmt.Printf("Error sending response: %v\n", err)
 		}
 	}
 }
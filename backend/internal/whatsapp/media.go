@@ -0,0 +1,102 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Rhymen/go-whatsapp"
+)
+
+const (
+	KindImage    = "image"
+	KindAudio    = "audio"
+	KindLocation = "location"
+)
+
+// Media - вложение во входящем сообщении
+type Media struct {
+	Kind     string
+	MimeType string
+	// Data - содержимое файла, пустое для геолокации или если файл не удалось скачать
+	Data      []byte
+	Caption   string
+	Latitude  float64
+	Longitude float64
+	// Name и Address - подписи к геолокации
+	Name    string
+	Address string
+	// Voice - голосовое сообщение, записанное в WhatsApp, а не пересланный аудиофайл
+	Voice bool
+}
+
+// Describe возвращает текстовое описание вложения для переписки
+func (m Media) Describe() string {
+	switch m.Kind {
+	case KindImage:
+		return strings.TrimSpace("[Фото] " + m.Caption)
+	case KindAudio:
+		return "[Голосовое сообщение]"
+	case KindLocation:
+		place := strings.TrimSpace(strings.Join([]string{m.Name, m.Address}, " "))
+		return strings.TrimSpace(fmt.Sprintf("[Геолокация %.6f, %.6f] %s", m.Latitude, m.Longitude, place))
+	}
+	return "[Вложение]"
+}
+
+func (h *MessageHandler) HandleImageMessage(message whatsapp.ImageMessage) {
+	data, err := message.Download()
+	if err != nil {
+		fmt.Printf("Error downloading image: %v\n", err)
+	}
+
+	h.handleMedia(message.Info, Media{
+		Kind:     KindImage,
+		MimeType: message.Type,
+		Data:     data,
+		Caption:  message.Caption,
+	})
+}
+
+func (h *MessageHandler) HandleAudioMessage(message whatsapp.AudioMessage) {
+	data, err := message.Download()
+	if err != nil {
+		fmt.Printf("Error downloading audio: %v\n", err)
+	}
+
+	h.handleMedia(message.Info, Media{
+		Kind:     KindAudio,
+		MimeType: message.Type,
+		Data:     data,
+		Voice:    message.Ptt,
+	})
+}
+
+func (h *MessageHandler) HandleLocationMessage(message whatsapp.LocationMessage) {
+	h.handleMedia(message.Info, Media{
+		Kind:      KindLocation,
+		Latitude:  message.DegreesLatitude,
+		Longitude: message.DegreesLongitude,
+		Name:      message.Name,
+		Address:   message.Address,
+	})
+}
+
+func (h *MessageHandler) handleMedia(info whatsapp.MessageInfo, media Media) {
+	sender := strings.Split(info.RemoteJid, "@")[0]
+
+	// Вложения, отправленные владельцем с телефона, сохраняем как его сообщение
+	if info.FromMe {
+		if h.ownerHandler != nil && !h.client.sentByAPI(info.Id) {
+			h.ownerHandler(sender, media.Describe())
+		}
+		return
+	}
+
+	if h.mediaHandler == nil {
+		return
+	}
+
+	if err := h.mediaHandler(sender, media); err != nil {
+		fmt.Printf("Error processing media message: %v\n", err)
+	}
+}
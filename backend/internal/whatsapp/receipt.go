@@ -0,0 +1,82 @@
+package whatsapp
+
+import (
+	"encoding/json"
+)
+
+// ReceiptStatus - статус отправленного сообщения по квитанции WhatsApp
+type ReceiptStatus string
+
+const (
+	ReceiptDelivered ReceiptStatus = "delivered"
+	ReceiptRead      ReceiptStatus = "read"
+)
+
+// Значения поля ack в квитанциях: 3 - доставлено, 4 - прочитано, 5 - голосовое прослушано
+const (
+	ackDelivered = 3
+	ackRead      = 4
+)
+
+// HandleJsonMessage разбирает квитанции вида ["Msg",{"cmd":"ack","id":"...","ack":3}].
+// Для нескольких сообщений сразу приходит "cmd":"acks" с массивом id.
+func (h *MessageHandler) HandleJsonMessage(message string) {
+	if h.receiptHandler == nil {
+		return
+	}
+
+	var envelope []json.RawMessage
+	if err := json.Unmarshal([]byte(message), &envelope); err != nil || len(envelope) < 2 {
+		return
+	}
+
+	var kind string
+	if err := json.Unmarshal(envelope[0], &kind); err != nil || (kind != "Msg" && kind != "MsgInfo") {
+		return
+	}
+
+	var ack struct {
+		Cmd string          `json:"cmd"`
+		ID  json.RawMessage `json:"id"`
+		Ack int             `json:"ack"`
+	}
+	if err := json.Unmarshal(envelope[1], &ack); err != nil || (ack.Cmd != "ack" && ack.Cmd != "acks") {
+		return
+	}
+
+	status, ok := receiptStatus(ack.Ack)
+	if !ok {
+		return
+	}
+
+	for _, id := range receiptIDs(ack.ID) {
+		h.receiptHandler(id, status)
+	}
+}
+
+func receiptStatus(ack int) (ReceiptStatus, bool) {
+	switch {
+	case ack >= ackRead:
+		return ReceiptRead, true
+	case ack == ackDelivered:
+		return ReceiptDelivered, true
+	}
+	return "", false
+}
+
+// receiptIDs принимает id строкой или массивом строк
+func receiptIDs(raw json.RawMessage) []string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		if id == "" {
+			return nil
+		}
+		return []string{id}
+	}
+
+	var ids []string
+	if err := json.Unmarshal(raw, &ids); err == nil {
+		return ids
+	}
+	return nil
+}
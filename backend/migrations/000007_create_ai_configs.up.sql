@@ -0,0 +1,21 @@
+-- Настройки ИИ-ассистента для каждого владельца
+CREATE TABLE IF NOT EXISTS ai_configs (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    prompt TEXT NOT NULL DEFAULT '',
+    tone VARCHAR(50) NOT NULL DEFAULT '',
+    language VARCHAR(10) NOT NULL DEFAULT 'ru',
+    temperature REAL NOT NULL DEFAULT 0.7,
+    max_tokens INTEGER NOT NULL DEFAULT 150,
+    model VARCHAR(100) NOT NULL DEFAULT 'gpt-3.5-turbo',
+    business_hours JSONB NOT NULL DEFAULT '{}'::JSONB,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE ai_configs IS 'Настройки ИИ-ассистента WhatsApp для владельца';
+COMMENT ON COLUMN ai_configs.prompt IS 'Системная инструкция ассистента';
+COMMENT ON COLUMN ai_configs.tone IS 'Тон общения с гостями';
+COMMENT ON COLUMN ai_configs.language IS 'Язык ответов (ru, kk, en)';
+COMMENT ON COLUMN ai_configs.business_hours IS 'Часы работы ассистента в формате JSON';
+COMMENT ON COLUMN ai_configs.enabled IS 'Включены ли автоматические ответы';
@@ -0,0 +1,2 @@
+UPDATE ai_configs SET model = 'gpt-3.5-turbo' WHERE model = '';
+ALTER TABLE ai_configs ALTER COLUMN model SET DEFAULT 'gpt-3.5-turbo';
@@ -0,0 +1,6 @@
+-- Пустая модель означает модель по умолчанию из LLM_MODEL,
+-- чтобы настройки работали и с локальным OpenAI-совместимым сервером
+ALTER TABLE ai_configs ALTER COLUMN model SET DEFAULT '';
+
+-- Строки со старым значением по умолчанию тоже переходят на модель из LLM_MODEL
+UPDATE ai_configs SET model = '' WHERE model = 'gpt-3.5-turbo';
@@ -0,0 +1,26 @@
+-- Переписка ИИ-ассистента с гостями в WhatsApp
+CREATE TABLE IF NOT EXISTS conversations (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    guest_phone VARCHAR(50) NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    summarized_until INTEGER NOT NULL DEFAULT 0,
+    last_message_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, guest_phone)
+);
+
+CREATE TABLE IF NOT EXISTS conversation_messages (
+    id SERIAL PRIMARY KEY,
+    conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL, -- 'guest', 'assistant', 'owner'
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_conversations_user_id ON conversations(user_id, last_message_at DESC);
+CREATE INDEX idx_conversation_messages_conversation_id ON conversation_messages(conversation_id, id);
+
+COMMENT ON COLUMN conversations.summary IS 'Краткое содержание старой части переписки';
+COMMENT ON COLUMN conversations.summarized_until IS 'ID последнего сообщения, вошедшего в summary';
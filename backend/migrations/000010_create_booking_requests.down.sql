@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ai_tool_calls;
+DROP TABLE IF EXISTS booking_requests;
@@ -0,0 +1,35 @@
+-- Заявки на бронирование, ожидающие подтверждения владельца
+CREATE TABLE IF NOT EXISTS booking_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    apartment_id INTEGER NOT NULL REFERENCES apartments(id) ON DELETE CASCADE,
+    conversation_id INTEGER REFERENCES conversations(id) ON DELETE SET NULL,
+    guest_name VARCHAR(255),
+    guest_phone VARCHAR(50),
+    date_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    date_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    nights INTEGER NOT NULL,
+    total_price INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'confirmed', 'rejected', 'cancelled'
+    source VARCHAR(50) NOT NULL DEFAULT 'whatsapp_ai',
+    availability_id INTEGER REFERENCES apartment_availability(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_booking_requests_user_id ON booking_requests(user_id, status);
+
+-- Журнал вызовов инструментов ИИ-агентом
+CREATE TABLE IF NOT EXISTS ai_tool_calls (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id INTEGER REFERENCES conversations(id) ON DELETE SET NULL,
+    tool VARCHAR(100) NOT NULL,
+    arguments TEXT NOT NULL,
+    result TEXT,
+    error TEXT,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ai_tool_calls_user_id ON ai_tool_calls(user_id, created_at DESC);
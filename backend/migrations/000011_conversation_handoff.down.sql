@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS notifications;
+
+ALTER TABLE conversations
+DROP COLUMN IF EXISTS mode,
+DROP COLUMN IF EXISTS paused_until,
+DROP COLUMN IF EXISTS failure_count,
+DROP COLUMN IF EXISTS escalation_reason,
+DROP COLUMN IF EXISTS escalated_at;
@@ -0,0 +1,21 @@
+-- Режимы переписки: бот отвечает сам, владелец перехватил чат или бот на паузе
+ALTER TABLE conversations
+ADD COLUMN IF NOT EXISTS mode VARCHAR(20) NOT NULL DEFAULT 'bot', -- 'bot', 'human', 'paused'
+ADD COLUMN IF NOT EXISTS paused_until TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS failure_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS escalation_reason TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP WITH TIME ZONE;
+
+-- Уведомления владельцу в личном кабинете
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    data JSONB NOT NULL DEFAULT '{}'::JSONB,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scheduled_messages;
+DROP TABLE IF EXISTS apartment_guest_info;
+DROP TABLE IF EXISTS message_scenarios;
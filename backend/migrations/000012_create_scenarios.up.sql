@@ -0,0 +1,47 @@
+-- Сценарии автоматических сообщений гостю по событиям бронирования
+CREATE TABLE IF NOT EXISTS message_scenarios (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    trigger VARCHAR(50) NOT NULL, -- 'booking_confirmed', 'before_check_in', 'check_out_morning', 'after_check_out'
+    offset_hours INTEGER NOT NULL DEFAULT 0,
+    send_time VARCHAR(5) NOT NULL DEFAULT '',
+    template TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_scenarios_user_id ON message_scenarios(user_id);
+
+-- Сведения для заселения, которые не попадают в объявление и в контекст ИИ
+CREATE TABLE IF NOT EXISTS apartment_guest_info (
+    apartment_id INTEGER PRIMARY KEY REFERENCES apartments(id) ON DELETE CASCADE,
+    check_in_time VARCHAR(5) NOT NULL DEFAULT '14:00',
+    check_out_time VARCHAR(5) NOT NULL DEFAULT '12:00',
+    door_code VARCHAR(100) NOT NULL DEFAULT '',
+    instructions TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Очередь запланированных сообщений. Уникальный ключ не даёт
+-- запланировать одно и то же сообщение дважды.
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scenario_id INTEGER NOT NULL REFERENCES message_scenarios(id) ON DELETE CASCADE,
+    booking_request_id INTEGER NOT NULL REFERENCES booking_requests(id) ON DELETE CASCADE,
+    guest_phone VARCHAR(50) NOT NULL,
+    send_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'sending', 'sent', 'failed', 'cancelled'
+    attempts INTEGER NOT NULL DEFAULT 0,
+    body TEXT NOT NULL DEFAULT '',
+    last_error TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scenario_id, booking_request_id)
+);
+
+CREATE INDEX idx_scheduled_messages_due ON scheduled_messages(send_at) WHERE status = 'pending';
+CREATE INDEX idx_scheduled_messages_user_id ON scheduled_messages(user_id, send_at DESC);
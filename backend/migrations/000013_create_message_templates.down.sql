@@ -0,0 +1,5 @@
+ALTER TABLE message_scenarios
+DROP COLUMN IF EXISTS template_id,
+ALTER COLUMN template DROP DEFAULT;
+
+DROP TABLE IF EXISTS message_templates;
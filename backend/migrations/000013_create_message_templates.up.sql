@@ -0,0 +1,17 @@
+-- Библиотека шаблонов сообщений. Категории совпадают с категориями
+-- шаблонов WhatsApp Business, тексты хранятся по языкам: {"ru": "...", "kk": "...", "en": "..."}
+CREATE TABLE IF NOT EXISTS message_templates (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(20) NOT NULL, -- 'utility', 'marketing', 'authentication'
+    bodies JSONB NOT NULL DEFAULT '{}'::JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+-- Сценарий может ссылаться на шаблон вместо собственного текста
+ALTER TABLE message_scenarios
+ADD COLUMN IF NOT EXISTS template_id INTEGER REFERENCES message_templates(id) ON DELETE RESTRICT,
+ALTER COLUMN template SET DEFAULT '';
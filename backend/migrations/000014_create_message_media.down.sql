@@ -0,0 +1,5 @@
+ALTER TABLE conversation_messages
+DROP COLUMN IF EXISTS kind,
+DROP COLUMN IF EXISTS media_id;
+
+DROP TABLE IF EXISTS message_media;
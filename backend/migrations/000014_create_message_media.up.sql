@@ -0,0 +1,19 @@
+-- Вложения в переписке: фото, голосовые сообщения и геолокация
+CREATE TABLE IF NOT EXISTS message_media (
+    id SERIAL PRIMARY KEY,
+    conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL, -- 'image', 'audio', 'location'
+    mime_type VARCHAR(100) NOT NULL DEFAULT '',
+    data BYTEA,
+    caption TEXT NOT NULL DEFAULT '',
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    transcript TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_media_conversation_id ON message_media(conversation_id);
+
+ALTER TABLE conversation_messages
+ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'text', -- 'text', 'image', 'audio', 'location'
+ADD COLUMN IF NOT EXISTS media_id INTEGER REFERENCES message_media(id) ON DELETE SET NULL;
@@ -0,0 +1,30 @@
+-- Очередь исходящих сообщений WhatsApp. Все отправки гостям проходят через неё:
+-- ответы бота, ответы владельца и сообщения сценариев.
+CREATE TABLE IF NOT EXISTS outbound_messages (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    guest_phone VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'text', -- 'text', 'image', 'location'
+    body TEXT NOT NULL DEFAULT '',
+    data BYTEA,
+    mime_type VARCHAR(100) NOT NULL DEFAULT '',
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    location_name VARCHAR(255) NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued', -- 'queued', 'sending', 'sent', 'delivered', 'read', 'failed'
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    whatsapp_id VARCHAR(100),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, idempotency_key)
+);
+
+CREATE INDEX idx_outbound_messages_due ON outbound_messages(next_attempt_at) WHERE status = 'queued';
+CREATE INDEX idx_outbound_messages_user_id ON outbound_messages(user_id, created_at DESC);
+CREATE INDEX idx_outbound_messages_whatsapp_id ON outbound_messages(whatsapp_id);
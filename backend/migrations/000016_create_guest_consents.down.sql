@@ -0,0 +1,4 @@
+ALTER TABLE ai_configs DROP COLUMN IF EXISTS quiet_hours;
+
+DROP TABLE IF EXISTS consent_log;
+DROP TABLE IF EXISTS guest_consents;
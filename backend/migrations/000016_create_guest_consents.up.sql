@@ -0,0 +1,27 @@
+-- Согласие гостей на автоматические сообщения. Нет записи - гость ещё не писал
+-- и не отписывался, автоматические сообщения разрешены.
+CREATE TABLE IF NOT EXISTS guest_consents (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    guest_phone VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL, -- 'opted_in', 'opted_out'
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, guest_phone)
+);
+
+-- Журнал изменений согласия: кто, когда и каким сообщением подписался или отписался
+CREATE TABLE IF NOT EXISTS consent_log (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    guest_phone VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    source VARCHAR(30) NOT NULL, -- 'first_message', 'guest_keyword', 'owner'
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_consent_log_guest ON consent_log(user_id, guest_phone, created_at DESC);
+
+-- Тихие часы: автоматические сообщения в это время откладываются
+ALTER TABLE ai_configs ADD COLUMN quiet_hours JSONB NOT NULL DEFAULT '{}'::JSONB;
+
+COMMENT ON COLUMN ai_configs.quiet_hours IS 'Тихие часы по времени гостя в формате JSON';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS llm_usage;
+
+ALTER TABLE users DROP COLUMN IF EXISTS plan;
@@ -0,0 +1,20 @@
+-- Тариф владельца определяет месячный лимит токенов ИИ
+ALTER TABLE users ADD COLUMN plan VARCHAR(20) NOT NULL DEFAULT 'free';
+
+-- Учёт каждого вызова языковой модели
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id INTEGER REFERENCES conversations(id) ON DELETE SET NULL,
+    purpose VARCHAR(30) NOT NULL, -- 'reply', 'summary', 'test', 'listing'
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_llm_usage_user_created ON llm_usage(user_id, created_at);
+
+COMMENT ON COLUMN users.plan IS 'Тариф: free, standard или pro';
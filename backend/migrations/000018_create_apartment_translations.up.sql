@@ -0,0 +1,16 @@
+-- Описание и правила квартиры на разных языках. Черновики, в том числе
+-- сгенерированные ИИ, владелец проверяет и публикует вручную.
+CREATE TABLE IF NOT EXISTS apartment_translations (
+    apartment_id INTEGER NOT NULL REFERENCES apartments(id) ON DELETE CASCADE,
+    language VARCHAR(2) NOT NULL, -- 'ru', 'kk', 'en'
+    description TEXT NOT NULL DEFAULT '',
+    rules TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'draft', -- 'draft', 'published'
+    generated BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (apartment_id, language)
+);
+
+COMMENT ON TABLE apartment_translations IS 'Локализованные описания и правила квартир';
+COMMENT ON COLUMN apartment_translations.generated IS 'Текст написан ИИ и ещё не правился владельцем';
@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+-- Аккаунты, созданные до появления подтверждения, считаются подтверждёнными,
+-- иначе их владельцы не смогут войти по паролю
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL AND email IS NOT NULL;
+
+-- Одноразовые токены из писем: сброс пароля и подтверждение email.
+-- Хранится только SHA-256 токена, email фиксирует адрес, на который ушло письмо.
+CREATE TABLE IF NOT EXISTS auth_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(30) NOT NULL, -- 'password_reset', 'email_verification'
+    email VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_auth_tokens_user_purpose ON auth_tokens(user_id, purpose) WHERE used_at IS NULL;
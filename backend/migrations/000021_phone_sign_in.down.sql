@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS phone_codes;
+DROP INDEX IF EXISTS idx_users_verified_phone;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified_at;
+-- Владельцы без email не переживут возврат NOT NULL
+DELETE FROM users WHERE email IS NULL;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
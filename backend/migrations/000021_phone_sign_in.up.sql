@@ -0,0 +1,21 @@
+-- Вход по номеру телефона: у таких владельцев может не быть email и пароля
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(20);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Подтверждённый номер принадлежит только одному владельцу
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_verified_phone ON users(phone) WHERE phone_verified_at IS NOT NULL;
+
+-- Одноразовые коды входа. Хранится только хеш кода, attempts считает неверные попытки.
+CREATE TABLE IF NOT EXISTS phone_codes (
+    id SERIAL PRIMARY KEY,
+    phone VARCHAR(20) NOT NULL,
+    channel VARCHAR(20) NOT NULL, -- 'whatsapp', 'sms'
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_phone_codes_phone ON phone_codes(phone, created_at DESC);
@@ -0,0 +1,8 @@
+-- Общие счётчики ограничений частоты запросов для нескольких экземпляров сервера.
+-- UNLOGGED: при сбое базы счётчики можно потерять, зато запись дешевле.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_hits (
+    key VARCHAR(255) NOT NULL,
+    hit_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_hits_key ON rate_limit_hits(key, hit_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_apartments_organization_id;
+ALTER TABLE apartments DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
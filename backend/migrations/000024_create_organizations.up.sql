@@ -0,0 +1,57 @@
+-- Организация владеет квартирами. owner_id - аккаунт, через WhatsApp и ИИ которого
+-- обслуживаются гости; он же хранится в apartments.user_id.
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- require_two_factor - участники не войдут без 2FA
+    require_two_factor BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_organizations_owner_id ON organizations(owner_id);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL, -- 'owner', 'manager', 'housekeeper', 'viewer'
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+
+-- Приглашение по email или телефону. Хранится только хеш токена из ссылки.
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email VARCHAR(255),
+    phone VARCHAR(20),
+    role VARCHAR(20) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_organization_invitations_org ON organization_invitations(organization_id) WHERE accepted_at IS NULL;
+
+ALTER TABLE apartments ADD COLUMN IF NOT EXISTS organization_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+
+-- Каждый владелец с квартирами получает личную организацию, квартиры переходят в неё
+INSERT INTO organizations (name, owner_id)
+SELECT COALESCE(NULLIF(u.email, ''), u.phone, 'Мои квартиры'), u.id
+FROM users u
+WHERE EXISTS (SELECT 1 FROM apartments a WHERE a.user_id = u.id);
+
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT id, owner_id, 'owner' FROM organizations;
+
+UPDATE apartments a SET organization_id = o.id
+FROM organizations o
+WHERE o.owner_id = a.user_id AND a.organization_id IS NULL;
+
+CREATE INDEX idx_apartments_organization_id ON apartments(organization_id);
@@ -0,0 +1,20 @@
+-- Профиль владельца. В старых схемах name и phone были NOT NULL без значения
+-- по умолчанию, а в новых их не было вовсе - приводим обе к одному виду.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS name VARCHAR(255);
+UPDATE users SET name = '' WHERE name IS NULL;
+ALTER TABLE users ALTER COLUMN name SET DEFAULT '';
+ALTER TABLE users ALTER COLUMN name SET NOT NULL;
+ALTER TABLE users ALTER COLUMN phone DROP NOT NULL;
+UPDATE users SET phone = NULL WHERE phone = '';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_type VARCHAR(50);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language VARCHAR(5) NOT NULL DEFAULT 'ru';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Almaty';
+-- notification_preferences: вид уведомления -> включено ли оно, отсутствующий вид включён
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notification_preferences JSONB NOT NULL DEFAULT '{}';
+-- Реквизиты для счетов: название компании и БИН/ИИН
+ALTER TABLE users ADD COLUMN IF NOT EXISTS company_name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bin VARCHAR(12) NOT NULL DEFAULT '';
+-- pending_email - новый адрес, который ждёт подтверждения по ссылке из письма
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
@@ -0,0 +1,7 @@
+-- Удаление аккаунта по запросу владельца. До deletion_scheduled_at запрос можно отменить,
+-- после него личные данные стираются, а брони остаются обезличенными для учёта.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMP WITH TIME ZONE;
+-- deleted_at - аккаунт обезличен, войти в него нельзя
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
@@ -0,0 +1,211 @@
+package jwt
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinSecretLength - минимальная длина секрета HS256: не короче выхода SHA-256
+const MinSecretLength = 32
+
+var (
+	ErrWeakSecret = errors.New("jwt: секрет HS256 слишком короткий или стандартный")
+	ErrUnknownKey = errors.New("jwt: неизвестный kid")
+)
+
+// defaultSecrets - значения из примеров и документации, которые нельзя использовать в продакшене
+var defaultSecrets = []string{"your-secret-key", "secret", "changeme", "change-me", "jwt-secret"}
+
+// Key - ключ подписи или проверки. У ключа проверки может не быть закрытой части.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signKey - секрет HS256 или закрытый ключ, verifyKey - секрет или открытый ключ
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// CanSign - есть ли у ключа секрет или закрытая часть
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// Keyset - набор ключей: одним подписываются новые токены, проверяются токены
+// любым ключом набора. Ротация: добавить новый ключ, сделать его ключом подписи,
+// а старый удалить, когда истекут выданные им токены.
+type Keyset struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// KeyConfig описывает ключ в файле набора. Для HS256 задаётся secret, для EdDSA и RS256 -
+// PEM-файлы: private_key_file для ключа подписи, public_key_file для ключа только проверки.
+type KeyConfig struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"`
+	Secret         string `json:"secret,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	PublicKeyFile  string `json:"public_key_file,omitempty"`
+}
+
+type KeysetConfig struct {
+	SigningKeyID string      `json:"signing_kid"`
+	Keys         []KeyConfig `json:"keys"`
+}
+
+// NewHMACKeyset - набор из одного секрета HS256, как раньше задавался JWT_KEY
+func NewHMACKeyset(id, secret string) (*Keyset, error) {
+	return NewKeyset(KeysetConfig{
+		SigningKeyID: id,
+		Keys:         []KeyConfig{{ID: id, Algorithm: jwt.SigningMethodHS256.Alg(), Secret: secret}},
+	})
+}
+
+// LoadKeysetFile читает набор ключей из JSON-файла
+func LoadKeysetFile(path string) (*Keyset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: не удалось прочитать набор ключей: %v", err)
+	}
+
+	var config KeysetConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("jwt: некорректный набор ключей: %v", err)
+	}
+	return NewKeyset(config)
+}
+
+func NewKeyset(config KeysetConfig) (*Keyset, error) {
+	ks := &Keyset{keys: make(map[string]*Key)}
+	for _, kc := range config.Keys {
+		if kc.ID == "" {
+			return nil, errors.New("jwt: у ключа не указан kid")
+		}
+		if _, exists := ks.keys[kc.ID]; exists {
+			return nil, fmt.Errorf("jwt: kid %q повторяется", kc.ID)
+		}
+
+		key, err := parseKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: ключ %q: %v", kc.ID, err)
+		}
+		ks.keys[kc.ID] = key
+	}
+
+	signing, ok := ks.keys[config.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("jwt: ключ подписи %q не найден в наборе", config.SigningKeyID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("jwt: у ключа подписи %q нет закрытой части", signing.ID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+// Signing - ключ, которым подписываются новые токены
+func (ks *Keyset) Signing() *Key {
+	return ks.signing
+}
+
+// Lookup возвращает ключ проверки по kid из заголовка токена
+func (ks *Keyset) Lookup(id string) (*Key, error) {
+	key, ok := ks.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// CheckStrength возвращает ErrWeakSecret, если в наборе есть короткий или стандартный секрет
+func (ks *Keyset) CheckStrength() error {
+	for _, key := range ks.keys {
+		secret, ok := key.verifyKey.([]byte)
+		if !ok {
+			continue
+		}
+		if len(secret) < MinSecretLength || isDefaultSecret(string(secret)) {
+			return fmt.Errorf("%w (kid %q, нужно не меньше %d байт)", ErrWeakSecret, key.ID, MinSecretLength)
+		}
+	}
+	return nil
+}
+
+func parseKey(kc KeyConfig) (*Key, error) {
+	key := &Key{ID: kc.ID}
+
+	switch kc.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if kc.Secret == "" {
+			return nil, errors.New("не задан secret")
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.signKey = []byte(kc.Secret)
+		key.verifyKey = []byte(kc.Secret)
+		return key, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		key.Method = jwt.SigningMethodEdDSA
+	case jwt.SigningMethodRS256.Alg():
+		key.Method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("неподдерживаемый алгоритм %q, допустимы HS256, EdDSA и RS256", kc.Algorithm)
+	}
+
+	switch {
+	case kc.PrivateKeyFile != "":
+		pem, err := os.ReadFile(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		var signer crypto.Signer
+		if key.Method == jwt.SigningMethodEdDSA {
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			signer = private.(crypto.Signer)
+		} else {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			signer = private
+		}
+		key.signKey = signer
+		key.verifyKey = signer.Public()
+	case kc.PublicKeyFile != "":
+		pem, err := os.ReadFile(kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.Method == jwt.SigningMethodEdDSA {
+			key.verifyKey, err = jwt.ParseEdPublicKeyFromPEM(pem)
+		} else {
+			key.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("не задан private_key_file или public_key_file")
+	}
+
+	return key, nil
+}
+
+func isDefaultSecret(secret string) bool {
+	secret = strings.ToLower(strings.TrimSpace(secret))
+	for _, d := range defaultSecrets {
+		if secret == d {
+			return true
+		}
+	}
+	return false
+}
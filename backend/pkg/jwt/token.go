@@ -1,47 +1,92 @@
 package jwt
 
 import (
+    "errors"
     "time"
     "github.com/golang-jwt/jwt/v5"
 )
 
 type TokenManager struct {
-    signingKey string
+    keys      *Keyset
+    issuer    string
+    audience  string
+    accessTTL time.Duration
 }
 
-func NewTokenManager(signingKey string) *TokenManager {
-    return &TokenManager{signingKey: signingKey}
+func NewTokenManager(keys *Keyset, issuer, audience string, accessTTL time.Duration) *TokenManager {
+    return &TokenManager{keys: keys, issuer: issuer, audience: audience, accessTTL: accessTTL}
 }
 
 type Claims struct {
     jwt.RegisteredClaims
     UserID uint `json:"user_id"`
+    // SessionID - сессия, из которой выдан токен; при её отзыве токен перестаёт действовать
+    SessionID string `json:"sid"`
 }
 
-func (m *TokenManager) GenerateToken(userID uint) (string, error) {
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+// AccessTTL - время жизни access-токена
+func (m *TokenManager) AccessTTL() time.Duration {
+    return m.accessTTL
+}
+
+func (m *TokenManager) GenerateToken(userID uint, sessionID string) (string, error) {
+    key := m.keys.Signing()
+    now := time.Now()
+
+    token := jwt.NewWithClaims(key.Method, Claims{
         RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-            IssuedAt:  jwt.NewNumericDate(time.Now()),
+            Issuer:    m.issuer,
+            Audience:  jwt.ClaimStrings{m.audience},
+            ExpiresAt: jwt.NewNumericDate(now.Add(m.accessTTL)),
+            IssuedAt:  jwt.NewNumericDate(now),
         },
-        UserID: userID,
+        UserID:    userID,
+        SessionID: sessionID,
     })
+    token.Header["kid"] = key.ID
 
-    return token.SignedString([]byte(m.signingKey))
+    return token.SignedString(key.signKey)
 }
 
-func (m *TokenManager) ValidateToken(tokenString string) (uint, error) {
+// ParseToken выбирает ключ проверки по kid и принимает только алгоритм этого ключа
+func (m *TokenManager) ParseToken(tokenString string) (*Claims, error) {
     token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-        return []byte(m.signingKey), nil
-    })
+        kid, _ := token.Header["kid"].(string)
+        key, err := m.keys.Lookup(kid)
+        if err != nil {
+            return nil, err
+        }
+        if token.Method.Alg() != key.Method.Alg() {
+            return nil, errors.New("jwt: алгоритм токена не совпадает с алгоритмом ключа")
+        }
+        return key.verifyKey, nil
+    },
+        jwt.WithValidMethods([]string{
+            jwt.SigningMethodHS256.Alg(),
+            jwt.SigningMethodEdDSA.Alg(),
+            jwt.SigningMethodRS256.Alg(),
+        }),
+        jwt.WithIssuer(m.issuer),
+        jwt.WithAudience(m.audience),
+        jwt.WithExpirationRequired(),
+    )
 
     if err != nil {
-        return 0, err
+        return nil, err
     }
 
     if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-        return claims.UserID, nil
+        return claims, nil
+    }
+
+    return nil, jwt.ErrSignatureInvalid
+}
+
+func (m *TokenManager) ValidateToken(tokenString string) (uint, error) {
+    claims, err := m.ParseToken(tokenString)
+    if err != nil {
+        return 0, err
     }
 
-    return 0, jwt.ErrSignatureInvalid
-} 
\ No newline at end of file
+    return claims.UserID, nil
+}
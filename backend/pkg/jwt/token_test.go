@@ -0,0 +1,117 @@
+package jwt
+
+import (
+    "crypto/ed25519"
+    "crypto/rand"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// newTestKeyset - набор из ключа подписи HS256 "hs" и ключа проверки EdDSA "ed"
+func newTestKeyset(t *testing.T) (*Keyset, ed25519.PrivateKey) {
+    public, private, err := ed25519.GenerateKey(rand.Reader)
+    if err != nil {
+        t.Fatalf("GenerateKey: %v", err)
+    }
+
+    ks, err := NewHMACKeyset("hs", "test-secret-that-is-long-enough-for-hs256")
+    if err != nil {
+        t.Fatalf("NewHMACKeyset: %v", err)
+    }
+    ks.keys["ed"] = &Key{ID: "ed", Method: jwt.SigningMethodEdDSA, verifyKey: public}
+    return ks, private
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+    now := time.Now()
+    token := jwt.NewWithClaims(method, Claims{
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    "uilet",
+            Audience:  jwt.ClaimStrings{"uilet-api"},
+            ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+            IssuedAt:  jwt.NewNumericDate(now),
+        },
+        UserID: 7,
+    })
+    if kid != "" {
+        token.Header["kid"] = kid
+    }
+
+    signed, err := token.SignedString(key)
+    if err != nil {
+        t.Fatalf("SignedString: %v", err)
+    }
+    return signed
+}
+
+func TestParseToken(t *testing.T) {
+    ks, edKey := newTestKeyset(t)
+    m := NewTokenManager(ks, "uilet", "uilet-api", time.Minute)
+    secret := []byte("test-secret-that-is-long-enough-for-hs256")
+
+    other, err := NewHMACKeyset("old", "another-secret-that-is-long-enough-too")
+    if err != nil {
+        t.Fatalf("NewHMACKeyset: %v", err)
+    }
+    rotated, err := NewTokenManager(other, "uilet", "uilet-api", time.Minute).GenerateToken(7, "session-1")
+    if err != nil {
+        t.Fatalf("GenerateToken: %v", err)
+    }
+
+    tests := []struct {
+        name  string
+        token string
+        ok    bool
+    }{
+        {"signing key", sign(t, jwt.SigningMethodHS256, "hs", secret), true},
+        {"verification-only key", sign(t, jwt.SigningMethodEdDSA, "ed", edKey), true},
+        {"unknown kid", rotated, false},
+        {"missing kid", sign(t, jwt.SigningMethodHS256, "", secret), false},
+        {"EdDSA token under HS256 kid", sign(t, jwt.SigningMethodEdDSA, "hs", edKey), false},
+        // Открытый ключ известен всем, им нельзя подписать токен как секретом HS256
+        {"HS256 token signed with the EdDSA public key", sign(t, jwt.SigningMethodHS256, "ed", []byte(edKey.Public().(ed25519.PublicKey))), false},
+        {"algorithm outside the allowed list", sign(t, jwt.SigningMethodHS512, "hs", secret), false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            claims, err := m.ParseToken(tt.token)
+            if tt.ok {
+                if err != nil || claims.UserID != 7 {
+                    t.Fatalf("ParseToken = %+v, %v; want user 7", claims, err)
+                }
+                return
+            }
+            if err == nil {
+                t.Fatalf("ParseToken accepted the token, claims = %+v", claims)
+            }
+        })
+    }
+}
+
+func TestParseTokenUnknownKid(t *testing.T) {
+    ks, _ := newTestKeyset(t)
+    m := NewTokenManager(ks, "uilet", "uilet-api", time.Minute)
+
+    _, err := m.ParseToken(sign(t, jwt.SigningMethodHS256, "retired", []byte("test-secret-that-is-long-enough-for-hs256")))
+    if !errors.Is(err, ErrUnknownKey) {
+        t.Fatalf("err = %v, want %v", err, ErrUnknownKey)
+    }
+}
+
+func TestParseTokenChecksIssuerAndAudience(t *testing.T) {
+    ks, _ := newTestKeyset(t)
+    token, err := NewTokenManager(ks, "uilet", "uilet-api", time.Minute).GenerateToken(7, "session-1")
+    if err != nil {
+        t.Fatalf("GenerateToken: %v", err)
+    }
+
+    if _, err := NewTokenManager(ks, "uilet", "uilet-admin", time.Minute).ParseToken(token); err == nil {
+        t.Error("token for another audience accepted")
+    }
+    if _, err := NewTokenManager(ks, "someone-else", "uilet-api", time.Minute).ParseToken(token); err == nil {
+        t.Error("token from another issuer accepted")
+    }
+}
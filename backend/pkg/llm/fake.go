@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Fake - детерминированная модель для тестов и офлайн-запуска.
+// Отдаёт заранее заданные ответы по очереди, а когда они закончились -
+// повторяет последнее сообщение пользователя.
+type Fake struct {
+	mu        sync.Mutex
+	responses []Response
+	requests  []Request
+	Err       error
+}
+
+func NewFake(responses ...string) *Fake {
+	f := &Fake{}
+	for _, content := range responses {
+		f.responses = append(f.responses, Response{Content: content})
+	}
+	return f
+}
+
+// Script добавляет в очередь готовые ответы, например с вызовами инструментов
+func (f *Fake) Script(responses ...Response) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, responses...)
+}
+
+func (f *Fake) Complete(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, req)
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	var resp Response
+	if len(f.responses) > 0 {
+		resp = f.responses[0]
+		f.responses = f.responses[1:]
+	} else {
+		resp.Content = lastUserMessage(req.Messages)
+	}
+
+	resp.Model = req.Model
+	if resp.Model == "" {
+		resp.Model = "fake"
+	}
+	resp.Usage = Usage{
+		PromptTokens:     countWords(req.Messages),
+		CompletionTokens: len(strings.Fields(resp.Content)),
+	}
+
+	return &resp, nil
+}
+
+// Requests возвращает все запросы, полученные моделью
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Request(nil), f.requests...)
+}
+
+func lastUserMessage(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+func countWords(messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += len(strings.Fields(m.Content))
+	}
+	return total
+}
@@ -0,0 +1,102 @@
+// Package llm описывает общий интерфейс языковых моделей и адаптеры к ним.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"unicode/utf8"
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+var ErrEmptyResponse = errors.New("llm: empty response")
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// ToolCalls - вызовы инструментов, запрошенные моделью в ответе ассистента
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID связывает сообщение с ролью RoleTool с вызовом, на который оно отвечает
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// Tool описывает функцию, которую модель может вызвать.
+// Parameters - JSON Schema аргументов.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type Request struct {
+	// Model - пустое значение означает модель адаптера по умолчанию
+	Model    string
+	Messages []Message
+	Tools    []Tool
+	// Temperature - nil означает значение провайдера по умолчанию; 0 - самые предсказуемые ответы
+	Temperature *float32
+	MaxTokens   int
+}
+
+// Temperature возвращает указатель для Request.Temperature
+func Temperature(t float32) *float32 {
+	return &t
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type Response struct {
+	Content   string
+	ToolCalls []ToolCall
+	Model     string
+	Usage     Usage
+}
+
+// Message возвращает ответ модели в виде сообщения для продолжения диалога
+func (r *Response) Message() Message {
+	return Message{Role: RoleAssistant, Content: r.Content, ToolCalls: r.ToolCalls}
+}
+
+// LLM - любая модель, умеющая продолжать диалог
+type LLM interface {
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// Conversation собирает сообщения с системной инструкцией в начале
+func Conversation(systemPrompt string, messages ...Message) []Message {
+	result := make([]Message, 0, len(messages)+1)
+	if systemPrompt != "" {
+		result = append(result, Message{Role: RoleSystem, Content: systemPrompt})
+	}
+	return append(result, messages...)
+}
+
+// EstimateTokens грубо оценивает число токенов в тексте без токенизатора.
+// Кириллица токенизируется хуже латиницы, поэтому берём ~3 символа на токен.
+func EstimateTokens(text string) int {
+	return utf8.RuneCountInString(text)/3 + 1
+}
+
+// EstimateMessagesTokens оценивает размер диалога с учётом служебных токенов сообщений
+func EstimateMessagesTokens(messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += EstimateTokens(m.Content) + 4
+	}
+	return total
+}
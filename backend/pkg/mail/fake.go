@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Log пишет письма в лог вместо отправки. Подходит для локального запуска без SMTP:
+// ссылки из писем видны в выводе сервера.
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// Fake - отправка для тестов: запоминает письма и может вернуть заданную ошибку
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// Messages возвращает все отправленные письма
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.messages...)
+}
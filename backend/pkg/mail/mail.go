@@ -0,0 +1,17 @@
+// Package mail описывает отправку писем владельцам: подтверждение email, сброс пароля.
+package mail
+
+import "context"
+
+// Message - письмо одному получателю. HTML необязателен, Text отправляется всегда.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender отправляет письмо
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeKeys struct {
+	scopes []string
+}
+
+func (k fakeKeys) IsAPIKey(token string) bool {
+	return len(token) > 6 && token[:6] == "uilet_"
+}
+
+func (k fakeKeys) AuthenticateAPIKey(token, ip string) (uint, []string, error) {
+	if token != "uilet_abc_secret" {
+		return 0, nil, errors.New("invalid api key")
+	}
+	return 7, k.scopes, nil
+}
+
+func newAPIKeyRouter(scopes []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(APIKeyMiddleware(fakeKeys{scopes: scopes}, RouteScopes{
+		"GET /api/apartments":  "apartments:read",
+		"POST /api/apartments": "apartments:write",
+	}))
+
+	handler := func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+		c.JSON(http.StatusOK, gin.H{"user_id": userID})
+	}
+	r.GET("/api/apartments", handler)
+	r.POST("/api/apartments", handler)
+	r.GET("/api/users/profile", handler)
+	return r
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"granted scope", http.MethodGet, "/api/apartments", "X-API-Key", "uilet_abc_secret", http.StatusOK},
+		{"bearer api key", http.MethodGet, "/api/apartments", "Authorization", "Bearer uilet_abc_secret", http.StatusOK},
+		{"missing scope", http.MethodPost, "/api/apartments", "X-API-Key", "uilet_abc_secret", http.StatusForbidden},
+		{"route not mapped", http.MethodGet, "/api/users/profile", "X-API-Key", "uilet_abc_secret", http.StatusForbidden},
+		{"invalid key", http.MethodGet, "/api/apartments", "X-API-Key", "uilet_abc_wrong", http.StatusUnauthorized},
+		// Без ключа запрос идёт дальше, его проверит AuthMiddleware
+		{"jwt passes through", http.MethodGet, "/api/users/profile", "Authorization", "Bearer eyJhbGciOi", http.StatusOK},
+		{"no credentials", http.MethodGet, "/api/users/profile", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newAPIKeyRouter([]string{"apartments:read"})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d; body %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{"apartments:read", "bookings:read"}, "bookings:read", true},
+		{[]string{"apartments:read"}, "apartments:write", false},
+		{[]string{"apartments:write"}, "apartments:read", false},
+		{[]string{"apartments:read"}, "apartments", false},
+		{nil, "apartments:read", false},
+	}
+	for _, tt := range tests {
+		if got := hasScope(tt.granted, tt.required); got != tt.want {
+			t.Errorf("hasScope(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
@@ -8,8 +8,19 @@ import (
     "github.com/yourusername/uilet/pkg/jwt"
 )
 
-func AuthMiddleware(tokenManager *jwt.TokenManager) gin.HandlerFunc {
+// SessionValidator проверяет, что сессия токена не отозвана
+type SessionValidator interface {
+    ValidateSession(userID uint, sessionID string) error
+}
+
+func AuthMiddleware(tokenManager *jwt.TokenManager, sessions SessionValidator) gin.HandlerFunc {
     return func(c *gin.Context) {
+        // Запрос уже прошёл проверку API-ключа в APIKeyMiddleware
+        if c.GetBool("apiKey") {
+            c.Next()
+            return
+        }
+
         header := c.GetHeader("Authorization")
         if header == "" {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "empty auth header"})
@@ -24,14 +35,21 @@ func AuthMiddleware(tokenManager *jwt.TokenManager) gin.HandlerFunc {
             return
         }
 
-        userID, err := tokenManager.ValidateToken(headerParts[1])
+        claims, err := tokenManager.ParseToken(headerParts[1])
         if err != nil {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
             c.Abort()
             return
         }
 
-        c.Set("userID", userID)
+        if err := sessions.ValidateSession(claims.UserID, claims.SessionID); err != nil {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "session expired"})
+            c.Abort()
+            return
+        }
+
+        c.Set("userID", claims.UserID)
+        c.Set("sessionID", claims.SessionID)
         c.Next()
     }
-} 
\ No newline at end of file
+}
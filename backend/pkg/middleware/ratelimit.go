@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/uilet/pkg/ratelimit"
+)
+
+// KeyFunc выбирает, чьи запросы считаются вместе
+type KeyFunc func(c *gin.Context) string
+
+// ByIP считает запросы с одного адреса
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser считает запросы владельца, а до входа - запросы с адреса
+func ByUser(c *gin.Context) string {
+	if userID, ok := c.Get("userID"); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return ByIP(c)
+}
+
+// RateLimit отклоняет запросы сверх ограничения с кодом 429 и заголовком Retry-After.
+// Если хранилище недоступно, запрос пропускается: ограничение не должно ронять API.
+func RateLimit(limiter *ratelimit.Limiter, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := limiter.Allow(c.Request.Context(), key(c))
+		if err != nil {
+			log.Printf("Rate limiter error: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limiter.Limit().Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds()+0.5)))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Слишком много запросов, попробуйте позже"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
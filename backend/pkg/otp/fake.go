@@ -0,0 +1,55 @@
+package otp
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Log пишет коды в лог вместо отправки, для локального запуска
+type Log struct {
+	Channel string
+}
+
+func NewLog(channel string) *Log {
+	return &Log{Channel: channel}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("OTP via %s to %s: %s", l.Channel, msg.Phone, msg.Code)
+	return nil
+}
+
+// Fake - отправка для тестов: запоминает сообщения и может вернуть заданную ошибку
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// Messages возвращает все отправленные коды
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.messages...)
+}
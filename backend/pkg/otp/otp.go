@@ -0,0 +1,31 @@
+// Package otp доставляет одноразовые коды входа по номеру телефона: через WhatsApp или SMS.
+package otp
+
+import (
+	"context"
+	"errors"
+)
+
+// Каналы доставки кода
+const (
+	ChannelWhatsApp = "whatsapp"
+	ChannelSMS      = "sms"
+)
+
+// ErrUndeliverable - провайдер отказался доставлять сообщение на этот номер
+var ErrUndeliverable = errors.New("otp: message cannot be delivered to this number")
+
+// Message - код для одного номера. Text - готовый текст для SMS,
+// WhatsApp отправляет Code через заранее одобренный шаблон.
+type Message struct {
+	// Phone - номер в формате E.164, например +77011234567
+	Phone    string
+	Code     string
+	Text     string
+	Language string
+}
+
+// Sender доставляет код по одному каналу
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
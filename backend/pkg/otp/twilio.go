@@ -0,0 +1,74 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultTwilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+type TwilioConfig struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	// From - номер или альфа-имя отправителя
+	From    string
+	Timeout time.Duration
+}
+
+// Twilio - адаптер к Twilio Messaging API для SMS
+type Twilio struct {
+	cfg        TwilioConfig
+	httpClient *http.Client
+}
+
+func NewTwilio(cfg TwilioConfig) *Twilio {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultTwilioBaseURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &Twilio{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (t *Twilio) Send(ctx context.Context, msg Message) error {
+	form := url.Values{}
+	form.Set("To", msg.Phone)
+	form.Set("From", t.cfg.From)
+	form.Set("Body", msg.Text)
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", t.cfg.BaseURL, url.PathEscape(t.cfg.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.cfg.AccountSID, t.cfg.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		// 21211 - некорректный номер, 21614 - номер не принимает SMS
+		if strings.Contains(string(data), "21211") || strings.Contains(string(data), "21614") {
+			return ErrUndeliverable
+		}
+		return fmt.Errorf("twilio API error %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
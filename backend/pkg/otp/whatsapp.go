@@ -0,0 +1,106 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWhatsAppBaseURL = "https://graph.facebook.com/v19.0"
+	DefaultTimeout         = 15 * time.Second
+)
+
+type WhatsAppConfig struct {
+	BaseURL string
+	// Token - постоянный токен системного пользователя WhatsApp Business
+	Token         string
+	PhoneNumberID string
+	// Template - одобренный шаблон категории AUTHENTICATION с кнопкой копирования кода
+	Template string
+	Timeout  time.Duration
+}
+
+// WhatsApp - адаптер к WhatsApp Cloud API. Коды уходят с номера платформы,
+// а не с WhatsApp владельца: у нового пользователя его ещё нет.
+type WhatsApp struct {
+	cfg        WhatsAppConfig
+	httpClient *http.Client
+}
+
+func NewWhatsApp(cfg WhatsAppConfig) *WhatsApp {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultWhatsAppBaseURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &WhatsApp{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+type whatsAppParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type whatsAppComponent struct {
+	Type       string              `json:"type"`
+	SubType    string              `json:"sub_type,omitempty"`
+	Index      string              `json:"index,omitempty"`
+	Parameters []whatsAppParameter `json:"parameters"`
+}
+
+func (w *WhatsApp) Send(ctx context.Context, msg Message) error {
+	code := []whatsAppParameter{{Type: "text", Text: msg.Code}}
+	body := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(msg.Phone, "+"),
+		"type":              "template",
+		"template": map[string]interface{}{
+			"name":     w.cfg.Template,
+			"language": map[string]string{"code": msg.Language},
+			"components": []whatsAppComponent{
+				{Type: "body", Parameters: code},
+				{Type: "button", SubType: "url", Index: "0", Parameters: code},
+			},
+		},
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.BaseURL+"/"+w.cfg.PhoneNumberID+"/messages", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.cfg.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("whatsapp request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		// 131026 - номер не зарегистрирован в WhatsApp
+		if strings.Contains(string(data), "131026") {
+			return ErrUndeliverable
+		}
+		return fmt.Errorf("whatsapp API error %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery - раз в сколько обращений память очищается от ключей без свежих событий
+const sweepEvery = 1000
+
+// Memory - хранилище в памяти процесса. Подходит, пока сервер один.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	ops     int
+}
+
+type memoryEntry struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]*memoryEntry)}
+}
+
+func (m *Memory) Hit(ctx context.Context, key string, now time.Time, window time.Duration) (Window, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+	entry, ok := m.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		m.entries[key] = entry
+	}
+	entry.window = window
+	entry.hits = append(prune(entry.hits, now, window), now)
+	return windowOf(entry.hits), nil
+}
+
+func (m *Memory) HitUnder(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (Window, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+	entry, ok := m.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		m.entries[key] = entry
+	}
+	entry.window = window
+	entry.hits = prune(entry.hits, now, window)
+
+	before := windowOf(entry.hits)
+	if before.Count >= limit {
+		return before, false, nil
+	}
+	entry.hits = append(entry.hits, now)
+	return before, true, nil
+}
+
+func (m *Memory) Peek(ctx context.Context, key string, now time.Time, window time.Duration) (Window, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return Window{}, nil
+	}
+	entry.hits = prune(entry.hits, now, window)
+	return windowOf(entry.hits), nil
+}
+
+func (m *Memory) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	m.ops++
+	if m.ops < sweepEvery {
+		return
+	}
+	m.ops = 0
+	for key, entry := range m.entries {
+		if len(entry.hits) == 0 || now.Sub(entry.hits[len(entry.hits)-1]) >= entry.window {
+			delete(m.entries, key)
+		}
+	}
+}
+
+func prune(hits []time.Time, now time.Time, window time.Duration) []time.Time {
+	i := 0
+	for i < len(hits) && now.Sub(hits[i]) >= window {
+		i++
+	}
+	return hits[i:]
+}
+
+func windowOf(hits []time.Time) Window {
+	if len(hits) == 0 {
+		return Window{}
+	}
+	return Window{Count: len(hits), Oldest: hits[0], Newest: hits[len(hits)-1]}
+}
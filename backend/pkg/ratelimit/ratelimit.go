@@ -0,0 +1,84 @@
+// Package ratelimit - ограничение частоты запросов скользящим окном.
+// Счётчики хранятся в памяти процесса или в общем хранилище, если серверов несколько.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Window - события ключа внутри окна
+type Window struct {
+	Count  int
+	Oldest time.Time
+	Newest time.Time
+}
+
+// Store хранит отметки времени событий по ключам
+type Store interface {
+	// Hit записывает событие и возвращает окно вместе с ним
+	Hit(ctx context.Context, key string, now time.Time, window time.Duration) (Window, error)
+	// HitUnder записывает событие, только если в окне меньше limit событий, и возвращает
+	// окно до записи. Проверка и запись атомарны: параллельные запросы не проходят сверх limit.
+	HitUnder(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (Window, bool, error)
+	// Peek возвращает окно, ничего не записывая
+	Peek(ctx context.Context, key string, now time.Time, window time.Duration) (Window, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// Limit - не больше Requests событий за Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter - через сколько освободится место в окне, если запрос отклонён
+	RetryAfter time.Duration
+}
+
+// Limiter - именованное ограничение поверх хранилища. Имя отделяет ключи разных ограничений.
+type Limiter struct {
+	store Store
+	name  string
+	limit Limit
+}
+
+func New(store Store, name string, limit Limit) *Limiter {
+	return &Limiter{store: store, name: name, limit: limit}
+}
+
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow учитывает запрос, если для него есть место в окне. Отклонённые запросы не учитываются,
+// иначе постоянный поток запросов никогда не выпустил бы клиента из ограничения.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	now := time.Now()
+	key = l.name + ":" + key
+
+	w, allowed, err := l.store.HitUnder(ctx, key, now, l.limit.Window, l.limit.Requests)
+	if err != nil {
+		return Result{}, err
+	}
+	if !allowed {
+		return Result{RetryAfter: retryAfter(w, now, l.limit.Window)}, nil
+	}
+
+	remaining := l.limit.Requests - w.Count - 1
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{Allowed: true, Remaining: remaining}, nil
+}
+
+func retryAfter(w Window, now time.Time, window time.Duration) time.Duration {
+	wait := w.Oldest.Add(window).Sub(now)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryHitUnderSlidingWindow(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+	start := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name    string
+		at      time.Duration
+		allowed bool
+		count   int
+	}{
+		{"first", 0, true, 0},
+		{"second", 10 * time.Second, true, 1},
+		{"third", 20 * time.Second, true, 2},
+		{"over the limit", 30 * time.Second, false, 3},
+		// Через минуту после первого события оно выходит из окна
+		{"first hit expired", 60 * time.Second, true, 2},
+		{"window full again", 65 * time.Second, false, 3},
+		{"second and third expired", 80 * time.Second, true, 1},
+	}
+	// Шаги выполняются по порядку: окно каждого зависит от предыдущих
+	for _, st := range steps {
+		w, allowed, err := m.HitUnder(ctx, "key", start.Add(st.at), time.Minute, 3)
+		if err != nil {
+			t.Fatalf("%s: %v", st.name, err)
+		}
+		if allowed != st.allowed || w.Count != st.count {
+			t.Errorf("%s: allowed = %v, count before = %d; want %v, %d", st.name, allowed, w.Count, st.allowed, st.count)
+		}
+	}
+}
+
+func TestMemoryHitUnderDoesNotRecordRejected(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		m.HitUnder(ctx, "key", now, time.Minute, 2)
+	}
+
+	w, err := m.Peek(ctx, "key", now, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Count != 2 {
+		t.Errorf("count = %d, want 2: rejected hits must not be recorded", w.Count)
+	}
+}
+
+func TestMemoryHitUnderIsAtomic(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok, _ := m.HitUnder(ctx, "key", now, time.Minute, 10); ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 10 {
+		t.Errorf("%d concurrent hits allowed, want 10", allowed)
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	store := NewMemory()
+	limiter := New(store, "api", Limit{Requests: 3, Window: time.Minute})
+	ctx := context.Background()
+
+	for want := 2; want >= 0; want-- {
+		r, err := limiter.Allow(ctx, "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Allowed || r.Remaining != want {
+			t.Fatalf("result = %+v, want allowed with %d remaining", r, want)
+		}
+	}
+
+	r, err := limiter.Allow(ctx, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Allowed {
+		t.Fatal("request over the limit allowed")
+	}
+	if r.RetryAfter < 59*time.Second || r.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %v, want about a minute", r.RetryAfter)
+	}
+
+	// Ключи разных клиентов и разных ограничений не пересекаются
+	if r, _ := limiter.Allow(ctx, "10.0.0.2"); !r.Allowed {
+		t.Error("another client limited")
+	}
+	if r, _ := New(store, "login", Limit{Requests: 3, Window: time.Minute}).Allow(ctx, "10.0.0.1"); !r.Allowed {
+		t.Error("another limiter shares the counter")
+	}
+}
+
+func TestRetryAfterIsAtLeastASecond(t *testing.T) {
+	now := time.Now()
+	w := Window{Count: 1, Oldest: now.Add(-time.Minute + time.Millisecond)}
+	if got := retryAfter(w, now, time.Minute); got != time.Second {
+		t.Errorf("retryAfter = %v, want 1s", got)
+	}
+}
@@ -0,0 +1,56 @@
+package stt
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake - распознавание для тестов и офлайн-запуска.
+// Возвращает заданные расшифровки по очереди, а когда они закончились - Default.
+type Fake struct {
+	mu          sync.Mutex
+	transcripts []string
+	requests    []Audio
+	Default     string
+	Err         error
+}
+
+func NewFake(transcripts ...string) *Fake {
+	return &Fake{
+		transcripts: transcripts,
+		Default:     "Здравствуйте, квартира свободна?",
+	}
+}
+
+func (f *Fake) Transcribe(ctx context.Context, audio Audio) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, audio)
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	if len(f.transcripts) == 0 {
+		if f.Default == "" {
+			return "", ErrEmptyTranscript
+		}
+		return f.Default, nil
+	}
+
+	text := f.transcripts[0]
+	f.transcripts = f.transcripts[1:]
+	return text, nil
+}
+
+// Requests возвращает все записи, переданные на распознавание
+func (f *Fake) Requests() []Audio {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Audio(nil), f.requests...)
+}
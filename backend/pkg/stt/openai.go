@@ -0,0 +1,146 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://api.openai.com/v1"
+	DefaultModel   = "whisper-1"
+	DefaultTimeout = 60 * time.Second
+)
+
+type OpenAIConfig struct {
+	// BaseURL - любой сервер с OpenAI-совместимым /audio/transcriptions,
+	// например локальный faster-whisper-server
+	BaseURL string
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+}
+
+// OpenAI - адаптер к OpenAI-совместимому Audio Transcriptions API
+type OpenAI struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+func NewOpenAI(cfg OpenAIConfig) *OpenAI {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultModel
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	return &OpenAI{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+type transcriptionResponse struct {
+	Text string `json:"text"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *OpenAI) Transcribe(ctx context.Context, audio Audio) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile("file", "voice"+extension(audio.MimeType))
+	if err != nil {
+		return "", fmt.Errorf("error creating form: %v", err)
+	}
+	if _, err := file.Write(audio.Data); err != nil {
+		return "", fmt.Errorf("error writing audio: %v", err)
+	}
+	if err := form.WriteField("model", c.model); err != nil {
+		return "", fmt.Errorf("error creating form: %v", err)
+	}
+	if audio.Language != "" {
+		if err := form.WriteField("language", audio.Language); err != nil {
+			return "", fmt.Errorf("error creating form: %v", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("error creating form: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+
+	httpReq.Header.Set("Content-Type", form.FormDataContentType())
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	// Текст расшифровки не логируем - это сообщение гостя
+	if resp.StatusCode != http.StatusOK {
+		var apiErr errorResponse
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return "", fmt.Errorf("API error (status %d)", resp.StatusCode)
+	}
+
+	var parsed transcriptionResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return "", fmt.Errorf("error parsing response: %v", err)
+	}
+
+	text := strings.TrimSpace(parsed.Text)
+	if text == "" {
+		return "", ErrEmptyTranscript
+	}
+	return text, nil
+}
+
+// extension подбирает расширение файла: по нему сервер определяет формат записи
+func extension(mimeType string) string {
+	mimeType = strings.TrimSpace(strings.Split(mimeType, ";")[0])
+	switch mimeType {
+	case "audio/ogg", "audio/opus":
+		return ".ogg"
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/mp4", "audio/aac":
+		return ".m4a"
+	case "audio/wav", "audio/x-wav":
+		return ".wav"
+	}
+	return ".ogg"
+}
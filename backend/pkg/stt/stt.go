@@ -0,0 +1,23 @@
+// Package stt описывает распознавание речи для голосовых сообщений гостей.
+package stt
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrEmptyTranscript - сервис не распознал в записи ни одного слова
+var ErrEmptyTranscript = errors.New("empty transcript")
+
+// Audio - запись для распознавания
+type Audio struct {
+	Data     []byte
+	MimeType string
+	// Language - подсказка с кодом языка (ru, kk, en), может быть пустой
+	Language string
+}
+
+// Transcriber превращает голосовое сообщение в текст
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio Audio) (string, error)
+}